@@ -3,18 +3,32 @@ package main
 import (
 	"ai_agent/internal/constants/model/dto"
 	"ai_agent/internal/constants/model/response"
+	adminHandler "ai_agent/internal/handler/admin"
 	agentHandler "ai_agent/internal/handler/agent"
+	oauthHandler "ai_agent/internal/handler/oauth"
 	"ai_agent/internal/service/agent"
+	"ai_agent/internal/storage/confirmation"
+	jobStore "ai_agent/internal/storage/job"
+	outboxStore "ai_agent/internal/storage/outbox"
+	reminderStore "ai_agent/internal/storage/reminder"
+	"ai_agent/internal/storage/session"
+	"ai_agent/internal/worker/outbox"
+	"ai_agent/internal/worker/scheduler"
 	"ai_agent/platform"
 	"ai_agent/platform/calendar"
 	"ai_agent/platform/email"
-	"ai_agent/platform/gemini"
+	"ai_agent/platform/googleauth"
+	"ai_agent/platform/llm"
 	"ai_agent/platform/logger"
 	"context"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -41,13 +55,47 @@ func main() {
 	logger := logger.InitLogger(zapLogger)
 	logger.Info(context.Background(), "Starting AI Executive Assistant")
 
+	// Initialize Google authentication; without it Calendar requests carry only the API key
+	googleAuth, err := googleauth.InitAuth(config, logger)
+	if err != nil {
+		log.Fatal("Failed to initialize Google authentication:", err)
+	}
+
 	// Initialize platform services
+	backend := config.CalendarBackend
+	if backend != "caldav" && backend != "google" && backend != "local" {
+		if googleAuth != nil || (config.GoogleCalendarAPIKey != "" && config.GoogleCalendarAPIKey != "your_google_calendar_api_key_here" && len(config.GoogleCalendarAPIKey) > 30) {
+			backend = "google"
+		} else {
+			log.Println("⚠️  Using local calendar in " + config.LocalCalendarFile + " (no Google Calendar credentials)")
+			backend = "local"
+		}
+	}
+	openCalendar := func(config dto.Config) platform.Calendar {
+		switch backend {
+		case "caldav":
+			return calendar.InitCalDAVCalendar(config, logger)
+		case "google":
+			return calendar.InitCalendar(config, googleAuth, logger)
+		default:
+			return calendar.InitLocalCalendar(config, logger)
+		}
+	}
+
 	var calendarService platform.Calendar
-	if config.GoogleCalendarAPIKey != "" && config.GoogleCalendarAPIKey != "your_google_calendar_api_key_here" && len(config.GoogleCalendarAPIKey) > 30 {
-		calendarService = calendar.InitCalendar(config, logger)
+	if len(config.Calendars) > 0 {
+		calendarService = calendar.InitMultiCalendar(config, func(cal dto.Calendar) platform.Calendar {
+			calConfig := config
+			calConfig.CalendarID = cal.ID
+			// Each local calendar beside the default gets a file of its own
+			if backend == "local" && !cal.Default && calConfig.LocalCalendarFile != "" {
+				ext := filepath.Ext(calConfig.LocalCalendarFile)
+				calConfig.LocalCalendarFile = strings.TrimSuffix(calConfig.LocalCalendarFile, ext) + "-" + cal.ID + ext
+			}
+			return openCalendar(calConfig)
+		}, logger)
 	} else {
-		log.Println("⚠️  Using Simple Calendar Mode (no valid Google Calendar API key)")
-		calendarService = calendar.InitSimpleCalendar(config, logger)
+		calendarService = openCalendar(config)
 	}
 
 	// Initialize email service (SendGrid or Gmail)
@@ -61,21 +109,82 @@ func main() {
 		emailService = email.InitEmail(config, logger) // This will show errors but won't crash
 	}
 
-	geminiService := gemini.InitGemini(config, logger)
+	// Email is queued and delivered in the background, with retries
+	messages, err := outboxStore.InitOutboxStore(config.OutboxFile)
+	if err != nil {
+		log.Fatal("Failed to initialize outbox:", err)
+	}
+	emailOutbox := outbox.NewOutbox(emailService, messages, config, logger)
+
+	// Without a usable provider, commands are handled by the offline rule-based parser
+	llmService := llm.InitLLM(config, logger)
+	if llmService == nil {
+		log.Println("⚠️  No LLM provider configured, natural language commands limited to built-in patterns")
+	}
+
+	sessionStore := session.InitSessionStore(time.Duration(config.SessionTTLMinutes) * time.Minute)
+	confirmationStore := confirmation.InitConfirmationStore()
+	reminders, err := reminderStore.InitReminderStore(config.ReminderStateDir)
+	if err != nil {
+		log.Fatal("Failed to initialize reminder store:", err)
+	}
 
 	// Initialize business service
-	service := agent.NewService(calendarService, emailService, geminiService, logger, config)
+	service := agent.NewService(calendarService, emailOutbox, llmService, sessionStore, confirmationStore, reminders, logger, config)
+
+	// Schedule the background jobs
+	jobs, err := jobStore.InitJobStore(config.SchedulerStateFile)
+	if err != nil {
+		log.Fatal("Failed to initialize job store:", err)
+	}
+	loc, err := time.LoadLocation(config.TimeZone)
+	if err != nil {
+		log.Println("⚠️  Unknown TIMEZONE " + config.TimeZone + ", scheduling in UTC")
+		loc = time.UTC
+	}
+	dailyReminder, err := scheduler.Daily(config.DailyReminderTime, loc)
+	if err != nil {
+		log.Fatal("Invalid DAILY_REMINDER_TIME:", err)
+	}
+	jobScheduler := scheduler.NewScheduler(jobs, logger)
+	jobScheduler.Add("daily_reminder", "Email the user a digest of the coming week's events", dailyReminder, service.SendDailyReminder)
+	if config.MeetingReminderMinutes > 0 {
+		jobScheduler.Add("meeting_reminders", "Email reminders for meetings about to start", scheduler.Every(time.Minute), func(ctx context.Context) error {
+			_, err := service.SendMeetingReminders(ctx)
+			return err
+		})
+	}
 
 	// Initialize HTTP handler
 	handler := agentHandler.NewHandler(service, logger)
+	admin := adminHandler.NewHandler(jobScheduler, emailOutbox, config.AdminToken, logger)
 
 	// Set up HTTP routes
 	mux := http.NewServeMux()
 	mux.HandleFunc("POST /api/command", handler.ProcessCommand)
+	mux.HandleFunc("POST /api/command/confirm", handler.ConfirmCommand)
 	mux.HandleFunc("POST /api/schedule", handler.ScheduleMeeting)
 	mux.HandleFunc("POST /api/email", handler.SendEmail)
 	mux.HandleFunc("GET /api/events", handler.GetEvents)
+	mux.HandleFunc("GET /api/calendars", handler.GetCalendars)
+	mux.HandleFunc("GET /api/events.ics", handler.ExportEvents)
+	mux.HandleFunc("POST /api/events/import", handler.ImportEvents)
+	mux.HandleFunc("GET /api/events/{id}", handler.GetEvent)
+	mux.HandleFunc("PATCH /api/events/{id}", handler.UpdateEvent)
+	mux.HandleFunc("POST /api/events/{id}/reschedule", handler.RescheduleMeeting)
+	mux.HandleFunc("DELETE /api/events/{id}", handler.CancelMeeting)
+	mux.HandleFunc("GET /api/availability", handler.GetAvailability)
 	mux.HandleFunc("POST /api/reminder", handler.SendDailyReminder)
+	mux.HandleFunc("GET /api/admin/jobs", admin.ListJobs)
+	mux.HandleFunc("POST /api/admin/jobs/{name}/run", admin.RunJob)
+	mux.HandleFunc("GET /api/outbox", admin.ListOutbox)
+
+	// The consent flow connects a user's Google account; service accounts need none
+	if googleAuth != nil && !googleAuth.ServiceAccount() {
+		oauth := oauthHandler.NewHandler(googleAuth, logger)
+		mux.HandleFunc("GET /oauth/google/start", oauth.Start)
+		mux.HandleFunc("GET /oauth/google/callback", oauth.Callback)
+	}
 
 	// Add health check endpoint
 	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
@@ -92,10 +201,20 @@ func main() {
 			"endpoints": {
 				"health": "GET /health",
 				"command": "POST /api/command",
+				"confirm": "POST /api/command/confirm",
 				"schedule": "POST /api/schedule", 
 				"email": "POST /api/email",
 				"events": "GET /api/events",
-				"reminder": "POST /api/reminder"
+				"calendars": "GET /api/calendars",
+				"event": "GET|PATCH|DELETE /api/events/{id}",
+				"reschedule": "POST /api/events/{id}/reschedule",
+				"export": "GET /api/events.ics",
+				"import": "POST /api/events/import",
+				"availability": "GET /api/availability",
+				"reminder": "POST /api/reminder",
+				"jobs": "GET /api/admin/jobs",
+				"outbox": "GET /api/outbox",
+				"google_oauth": "GET /oauth/google/start"
 			},
 			"note": "Set API keys in environment variables for full functionality"
 		}`))
@@ -119,12 +238,19 @@ func main() {
 		}
 	}()
 
+	// Run the scheduled jobs and deliver email until shutdown
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	jobScheduler.Start(workerCtx)
+	outboxCtx, stopOutbox := context.WithCancel(context.Background())
+	emailOutbox.Start(outboxCtx)
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	logger.Info(context.Background(), "Shutting down server...")
+	stopWorkers()
 
 	// Graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -133,30 +259,96 @@ func main() {
 	if err := server.Shutdown(ctx); err != nil {
 		logger.Error(context.Background(), "Server forced to shutdown", zap.Error(err))
 	}
+	if err := jobScheduler.Wait(ctx); err != nil {
+		logger.Error(context.Background(), "Jobs still running at shutdown", zap.Error(err))
+	}
+	// Requests and jobs have stopped queueing email; finish the sends under way
+	stopOutbox()
+	if err := emailOutbox.Wait(ctx); err != nil {
+		logger.Error(context.Background(), "Email still sending at shutdown", zap.Error(err))
+	}
 
 	logger.Info(context.Background(), "Server exited")
 }
 
 func loadConfig() dto.Config {
 	config := dto.Config{
-		GoogleCalendarAPIKey:   getEnv("GOOGLE_CALENDAR_API_KEY", ""),
-		SendGridAPIKey:         getEnv("SENDGRID_API_KEY", ""),
-		GeminiAPIKey:           getEnv("GEMINI_API_KEY", ""),
-		GmailAppPassword:       getEnv("GMAIL_APP_PASSWORD", ""),
-		ServerPort:             getEnv("SERVER_PORT", "8080"),
-		FromEmail:              getEnv("FROM_EMAIL", "assistant@example.com"),
-		FromName:               getEnv("FROM_NAME", "AI Executive Assistant"),
-		UserEmail:              getEnv("USER_EMAIL", "user@example.com"),
-		TimeZone:               getEnv("TIMEZONE", "UTC"),
-		DailyReminderTime:      getEnv("DAILY_REMINDER_TIME", "09:00"),
-		MeetingReminderMinutes: 15,
+		GoogleCalendarAPIKey:     getEnv("GOOGLE_CALENDAR_API_KEY", ""),
+		SendGridAPIKey:           getEnv("SENDGRID_API_KEY", ""),
+		GoogleClientID:           getEnv("GOOGLE_CLIENT_ID", ""),
+		GoogleClientSecret:       getEnv("GOOGLE_CLIENT_SECRET", ""),
+		GoogleRefreshToken:       getEnv("GOOGLE_REFRESH_TOKEN", ""),
+		GoogleTokenFile:          getEnv("GOOGLE_TOKEN_FILE", "data/google_token.json"),
+		GoogleRedirectURL:        getEnv("GOOGLE_REDIRECT_URL", ""),
+		GoogleServiceAccountFile: getEnv("GOOGLE_SERVICE_ACCOUNT_FILE", ""),
+		GoogleImpersonateUser:    getEnv("GOOGLE_IMPERSONATE_USER", ""),
+		GoogleTokenURL:           getEnv("GOOGLE_TOKEN_URL", ""),
+		GoogleAuthURL:            getEnv("GOOGLE_AUTH_URL", ""),
+		GeminiAPIKey:             getEnv("GEMINI_API_KEY", ""),
+		GeminiURL:                getEnv("GEMINI_URL", ""),
+		GeminiModel:              getEnv("GEMINI_MODEL", ""),
+		LLMProviders:             getEnvList("LLM_PROVIDERS"),
+		OpenAIAPIKey:             getEnv("OPENAI_API_KEY", ""),
+		OpenAIURL:                getEnv("OPENAI_URL", ""),
+		OpenAIModel:              getEnv("OPENAI_MODEL", ""),
+		OllamaURL:                getEnv("OLLAMA_URL", ""),
+		OllamaModel:              getEnv("OLLAMA_MODEL", ""),
+		LLMTemperature:           getEnvFloat("LLM_TEMPERATURE", 0.2),
+		LLMMaxOutputTokens:       getEnvInt("LLM_MAX_OUTPUT_TOKENS", 1024),
+		LLMRepairAttempts:        getEnvInt("LLM_REPAIR_ATTEMPTS", 2),
+		GeminiSafetyThreshold:    getEnv("GEMINI_SAFETY_THRESHOLD", ""),
+		HTTPMaxRetries:           getEnvInt("HTTP_MAX_RETRIES", 3),
+		GeminiRatePerMinute:      getEnvFloat("GEMINI_RATE_PER_MINUTE", 15),
+		CalendarRatePerMinute:    getEnvFloat("CALENDAR_RATE_PER_MINUTE", 0),
+		EmailRatePerMinute:       getEnvFloat("EMAIL_RATE_PER_MINUTE", 0),
+		BreakerThreshold:         getEnvInt("CIRCUIT_BREAKER_THRESHOLD", 5),
+		BreakerCooldownSeconds:   getEnvInt("CIRCUIT_BREAKER_COOLDOWN_SECONDS", 30),
+		GmailAppPassword:         getEnv("GMAIL_APP_PASSWORD", ""),
+		ServerPort:               getEnv("SERVER_PORT", "8080"),
+		FromEmail:                getEnv("FROM_EMAIL", "assistant@example.com"),
+		FromName:                 getEnv("FROM_NAME", "AI Executive Assistant"),
+		UserEmail:                getEnv("USER_EMAIL", "user@example.com"),
+		CalendarBackend:          getEnv("CALENDAR_BACKEND", ""),
+		CalendarID:               getEnv("CALENDAR_ID", ""),
+		CalDAVURL:                getEnv("CALDAV_URL", ""),
+		CalDAVUsername:           getEnv("CALDAV_USERNAME", ""),
+		CalDAVPassword:           getEnv("CALDAV_PASSWORD", ""),
+		Calendars:                getEnvCalendars("CALENDARS", getEnv("DEFAULT_CALENDAR", "")),
+		LocalCalendarFile:        getEnv("LOCAL_CALENDAR_FILE", "data/calendar.json"),
+		TimeZone:                 getEnv("TIMEZONE", "UTC"),
+		WorkdayStart:             getEnv("WORKDAY_START", "09:00"),
+		WorkdayEnd:               getEnv("WORKDAY_END", "17:00"),
+		MeetingBufferMinutes:     getEnvInt("MEETING_BUFFER_MINUTES", 0),
+		ConflictPolicy:           getEnv("CONFLICT_POLICY", dto.ConflictReject),
+		DailyReminderTime:        getEnv("DAILY_REMINDER_TIME", "09:00"),
+		MeetingReminderMinutes:   getEnvInt("MEETING_REMINDER_MINUTES", 15),
+		ReminderRecipients:       getEnv("MEETING_REMINDER_RECIPIENTS", dto.RemindAttendees),
+		RecipientTimeZones:       getEnvMap("RECIPIENT_TIMEZONES"),
+		ReminderStateDir:         getEnv("REMINDER_STATE_DIR", "data/reminders"),
+		SchedulerStateFile:       getEnv("SCHEDULER_STATE_FILE", "data/scheduler.json"),
+		AdminToken:               getEnv("ADMIN_TOKEN", ""),
+		OutboxFile:               getEnv("OUTBOX_FILE", "data/outbox.json"),
+		EmailWorkers:             getEnvInt("EMAIL_WORKERS", 2),
+		EmailMaxAttempts:         getEnvInt("EMAIL_MAX_ATTEMPTS", 5),
+		AgentMaxSteps:            getEnvInt("AGENT_MAX_STEPS", 6),
+		SessionTTLMinutes:        getEnvInt("SESSION_TTL_MINUTES", 30),
+		ConfirmActions:           getEnvList("CONFIRM_ACTIONS"),
+		ConfirmationTTLMinutes:   getEnvInt("CONFIRMATION_TTL_MINUTES", 10),
+	}
+
+	if config.GoogleRedirectURL == "" {
+		config.GoogleRedirectURL = "http://localhost:" + config.ServerPort + "/oauth/google/callback"
+	}
+
+	if len(config.LLMProviders) == 0 {
+		config.LLMProviders = []string{"gemini"}
 	}
 
 	// Check if we're in demo mode (no API keys provided)
-	if config.GoogleCalendarAPIKey == "" && config.SendGridAPIKey == "" && config.GeminiAPIKey == "" && config.GmailAppPassword == "" {
+	if config.GoogleCalendarAPIKey == "" && config.GoogleClientID == "" && config.GoogleServiceAccountFile == "" && config.CalDAVURL == "" && config.SendGridAPIKey == "" && config.GeminiAPIKey == "" && config.GmailAppPassword == "" {
 		log.Println("⚠️  Running in DEMO MODE - No API keys provided")
 		log.Println("   Set the following environment variables for full functionality:")
-		log.Println("   - GOOGLE_CALENDAR_API_KEY")
+		log.Println("   - GOOGLE_CALENDAR_API_KEY (or GOOGLE_CLIENT_ID / GOOGLE_SERVICE_ACCOUNT_FILE)")
 		log.Println("   - SENDGRID_API_KEY (or GMAIL_APP_PASSWORD for Gmail SMTP)")
 		log.Println("   - GEMINI_API_KEY")
 		log.Println("   Visit http://localhost:8080/demo for more info")
@@ -171,3 +363,101 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+var calendarNameRe = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// getEnvCalendars reads calendars listed as name=id, optionally followed by
+// :r (read-only), :w (write-only) or :rw, the default. The calendar named
+// defaultName, or else the first writable one, is the default.
+func getEnvCalendars(key, defaultName string) []dto.Calendar {
+	var calendars []dto.Calendar
+	for _, entry := range getEnvList(key) {
+		name, id, ok := strings.Cut(entry, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || !calendarNameRe.MatchString(name) {
+			log.Fatalf("Invalid %s entry %q: want name=id[:r|:w|:rw] with a name of lowercase letters, digits, - or _", key, entry)
+		}
+		cal := dto.Calendar{Name: name, ID: strings.TrimSpace(id), Read: true, Write: true}
+		if i := strings.LastIndex(cal.ID, ":"); i >= 0 {
+			switch cal.ID[i+1:] {
+			case "r":
+				cal.Write = false
+				cal.ID = cal.ID[:i]
+			case "w":
+				cal.Read = false
+				cal.ID = cal.ID[:i]
+			case "rw":
+				cal.ID = cal.ID[:i]
+			}
+		}
+		if cal.ID == "" {
+			log.Fatalf("Invalid %s entry %q: missing calendar ID", key, entry)
+		}
+		for _, other := range calendars {
+			if other.Name == cal.Name {
+				log.Fatalf("Invalid %s: calendar %q is listed twice", key, cal.Name)
+			}
+		}
+		calendars = append(calendars, cal)
+	}
+	if len(calendars) == 0 {
+		return nil
+	}
+
+	def := -1
+	for i, cal := range calendars {
+		if defaultName != "" && cal.Name == strings.ToLower(defaultName) || defaultName == "" && def < 0 && cal.Write {
+			def = i
+		}
+	}
+	switch {
+	case def < 0 && defaultName != "":
+		log.Fatalf("DEFAULT_CALENDAR %q is not in %s", defaultName, key)
+	case def < 0:
+		log.Fatalf("Invalid %s: no writable calendar to use as the default", key)
+	case !calendars[def].Write:
+		log.Fatalf("DEFAULT_CALENDAR %q is read-only", defaultName)
+	}
+	calendars[def].Default = true
+	return calendars
+}
+
+// getEnvMap reads comma-separated key=value pairs.
+func getEnvMap(key string) map[string]string {
+	values := map[string]string{}
+	for _, entry := range getEnvList(key) {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			log.Printf("⚠️  Ignoring %s entry %q: want key=value", key, entry)
+			continue
+		}
+		values[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return values
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
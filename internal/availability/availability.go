@@ -0,0 +1,222 @@
+// Package availability finds times when everyone invited to a meeting is
+// free. It works on busy intervals, so it is independent of where they come
+// from: Google's freeBusy query or events stored locally.
+//
+// Candidate slots start on a fixed grid inside working hours, keep the
+// requested buffers clear of busy time, and are ranked by how soon they are,
+// how much room they leave around them and how close they sit to the middle of
+// the working day.
+package availability
+
+import (
+	"ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRequest is returned for requests that cannot be searched.
+var ErrInvalidRequest = fmt.Errorf("%w: invalid availability request", errors.ErrInvalidInput)
+
+const (
+	// step is the grid slot start times are aligned to.
+	step         = 15 * time.Minute
+	defaultLimit = 5
+
+	// Ranking weights; they sum to 1 so scores fall in [0, 1].
+	weightSoon    = 0.6
+	weightRoom    = 0.2
+	weightMidday  = 0.2
+	roomSaturated = time.Hour
+)
+
+// FindSlots returns up to req.Limit non-overlapping slots, best first. Days and
+// working hours are taken in loc, so daylight saving changes are respected.
+func FindSlots(busy []dto.TimeRange, req dto.AvailabilityRequest, loc *time.Location) ([]dto.Slot, error) {
+	candidates, err := freeSlots(busy, req, loc)
+	if err != nil {
+		return nil, err
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+
+	var slots []dto.Slot
+	for _, candidate := range candidates {
+		if len(slots) == limit {
+			break
+		}
+		if !overlapsAny(slots, candidate) {
+			slots = append(slots, candidate)
+		}
+	}
+	return slots, nil
+}
+
+// Nearest returns the free slot starting closest to target, earlier or later,
+// and false if there is none in the window.
+func Nearest(busy []dto.TimeRange, req dto.AvailabilityRequest, loc *time.Location, target time.Time) (dto.Slot, bool, error) {
+	candidates, err := freeSlots(busy, req, loc)
+	if err != nil || len(candidates) == 0 {
+		return dto.Slot{}, false, err
+	}
+
+	nearest := candidates[0]
+	for _, candidate := range candidates[1:] {
+		if abs(float64(candidate.Start.Sub(target))) < abs(float64(nearest.Start.Sub(target))) {
+			nearest = candidate
+		}
+	}
+	return nearest, true, nil
+}
+
+// freeSlots returns every free slot on the grid, scored but unsorted.
+func freeSlots(busy []dto.TimeRange, req dto.AvailabilityRequest, loc *time.Location) ([]dto.Slot, error) {
+	if req.Duration <= 0 {
+		return nil, fmt.Errorf("%w: duration must be positive", ErrInvalidRequest)
+	}
+	if !req.WindowEnd.After(req.WindowStart) {
+		return nil, fmt.Errorf("%w: window end must be after its start", ErrInvalidRequest)
+	}
+	dayStart, err := ParseClock(req.WorkdayStart)
+	if err != nil {
+		return nil, err
+	}
+	dayEnd, err := ParseClock(req.WorkdayEnd)
+	if err != nil {
+		return nil, err
+	}
+	if dayEnd <= dayStart {
+		return nil, fmt.Errorf("%w: working day must end after it starts", ErrInvalidRequest)
+	}
+
+	blocked := merge(busy, req.BufferBefore, req.BufferAfter)
+	window := req.WindowEnd.Sub(req.WindowStart)
+
+	var candidates []dto.Slot
+	first := req.WindowStart.In(loc)
+	for day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc); day.Before(req.WindowEnd); day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc) {
+		if !req.IncludeWeekends && (day.Weekday() == time.Saturday || day.Weekday() == time.Sunday) {
+			continue
+		}
+
+		workStart := atClock(day, dayStart)
+		workEnd := atClock(day, dayEnd)
+		midday := workStart.Add(workEnd.Sub(workStart) / 2)
+
+		// Align starts to the grid from the start of the working day
+		start := workStart
+		if req.WindowStart.After(start) {
+			start = start.Add((req.WindowStart.Sub(start) + step - 1) / step * step)
+		}
+		for ; !start.Add(req.Duration).After(earlier(workEnd, req.WindowEnd)); start = start.Add(step) {
+			end := start.Add(req.Duration)
+			before, after, free := room(blocked, start, end)
+			if !free {
+				continue
+			}
+
+			soon := 1 - float64(start.Sub(req.WindowStart))/float64(window)
+			spacing := float64(min(before, after, roomSaturated)) / float64(roomSaturated)
+			centred := 1 - abs(float64(start.Add(req.Duration/2).Sub(midday)))/float64(workEnd.Sub(workStart)/2)
+			candidates = append(candidates, dto.Slot{
+				Start: start,
+				End:   end,
+				Score: weightSoon*soon + weightRoom*spacing + weightMidday*max(centred, 0),
+			})
+		}
+	}
+	return candidates, nil
+}
+
+// ParseClock parses a time of day such as "09:00" into an offset from midnight.
+func ParseClock(value string) (time.Duration, error) {
+	hours, minutes, ok := strings.Cut(strings.TrimSpace(value), ":")
+	h, errH := strconv.Atoi(hours)
+	m, errM := strconv.Atoi(minutes)
+	if !ok || errH != nil || errM != nil || h < 0 || h > 24 || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("%w: invalid time of day %q", ErrInvalidRequest, value)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+// atClock returns the wall-clock time offset from midnight on day. It builds
+// the time from its fields rather than adding a duration, so 09:00 stays 09:00
+// on days when the clocks change.
+func atClock(day time.Time, offset time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), int(offset/time.Hour), int(offset%time.Hour/time.Minute), 0, 0, day.Location())
+}
+
+// merge widens busy intervals by the buffers and joins the ones that overlap.
+// A slot needs bufferBefore free ahead of it, so intervals are extended by
+// that much after they end, and bufferAfter free behind it, so they are
+// extended by that much before they start.
+func merge(busy []dto.TimeRange, bufferBefore, bufferAfter time.Duration) []dto.TimeRange {
+	widened := make([]dto.TimeRange, 0, len(busy))
+	for _, b := range busy {
+		if !b.End.After(b.Start) {
+			continue
+		}
+		widened = append(widened, dto.TimeRange{Start: b.Start.Add(-bufferAfter), End: b.End.Add(bufferBefore)})
+	}
+	sort.Slice(widened, func(i, j int) bool { return widened[i].Start.Before(widened[j].Start) })
+
+	var merged []dto.TimeRange
+	for _, b := range widened {
+		if n := len(merged); n > 0 && !b.Start.After(merged[n-1].End) {
+			if b.End.After(merged[n-1].End) {
+				merged[n-1].End = b.End
+			}
+			continue
+		}
+		merged = append(merged, b)
+	}
+	return merged
+}
+
+// room reports whether [start, end) is clear of blocked time and how much free
+// time it has on either side, up to roomSaturated.
+func room(blocked []dto.TimeRange, start, end time.Time) (before, after time.Duration, free bool) {
+	before, after = roomSaturated, roomSaturated
+	for _, b := range blocked {
+		if b.Start.Before(end) && b.End.After(start) {
+			return 0, 0, false
+		}
+		if !b.End.After(start) && start.Sub(b.End) < before {
+			before = start.Sub(b.End)
+		}
+		if !b.Start.Before(end) && b.Start.Sub(end) < after {
+			after = b.Start.Sub(end)
+		}
+	}
+	return before, after, true
+}
+
+func overlapsAny(slots []dto.Slot, candidate dto.Slot) bool {
+	for _, slot := range slots {
+		if slot.Start.Before(candidate.End) && slot.End.After(candidate.Start) {
+			return true
+		}
+	}
+	return false
+}
+
+func earlier(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func abs(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
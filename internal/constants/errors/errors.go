@@ -1,38 +1,90 @@
 package errors
 
 import (
+	"context"
 	"errors"
 	"net/http"
 )
 
 var (
-	ErrUnexpected                  = errors.New("unexpected error")
-	ErrInternalServerError         = errors.New("internal server error")
-	ErrBeneficiaryAlreadyExist     = errors.New("beneficiary already exist")
-	ErrBeneficiaryNotFound         = errors.New("beneficiary not found")
-	ErrInividualsNotFound          = errors.New("one or more beneficiaries not found")
-	ErrGroupBeneficaryAlreadyExist = errors.New("group beneficiary already exist")
-	ErrRequestTimeout              = errors.New("request timeout")
-	ErrAccountNotFound             = errors.New("account not found")
-	ErrBadRequest                  = errors.New("bad request")
-	ErrInvalidData                 = errors.New("invalid data")
-	ErrUnauthorized                = errors.New("unauthorized")
-	ErrActionNotAllowed            = errors.New("action not allowed")
-	ErrInvalidPhoneNumber          = errors.New("invalid phone number")
+	ErrUnexpected               = errors.New("unexpected error")
+	ErrInternalServerError      = errors.New("internal server error")
+	ErrRequestTimeout           = errors.New("request timeout")
+	ErrBadRequest               = errors.New("bad request")
+	ErrInvalidData              = errors.New("invalid data")
+	ErrUnauthorized             = errors.New("unauthorized")
+	ErrActionNotAllowed         = errors.New("action not allowed")
+	ErrInvalidAIResponse        = errors.New("invalid AI response")
+	ErrUnknownAction            = errors.New("unknown action")
+	ErrInvalidActionParameters  = errors.New("invalid action parameters")
+	ErrAgentStepLimit           = errors.New("agent step limit reached")
+	ErrInvalidConfirmationToken = errors.New("invalid or expired confirmation token")
+	ErrLLMUnavailable           = errors.New("language model unavailable")
+	ErrConflict                 = errors.New("scheduling conflict")
+
+	// Provider errors returned by the calendar, email and model clients
+	ErrRateLimited  = errors.New("rate limited by provider")
+	ErrNotFound     = errors.New("not found")
+	ErrUnavailable  = errors.New("provider unavailable")
+	ErrInvalidInput = errors.New("invalid input")
 )
 
 var ErrorMap = map[error]int{
-	ErrUnexpected:                  http.StatusInternalServerError,
-	ErrInternalServerError:         http.StatusInternalServerError,
-	ErrBeneficiaryAlreadyExist:     http.StatusBadRequest,
-	ErrBeneficiaryNotFound:         http.StatusNotFound,
-	ErrInividualsNotFound:          http.StatusNotFound,
-	ErrGroupBeneficaryAlreadyExist: http.StatusBadRequest,
-	ErrRequestTimeout:              http.StatusRequestTimeout,
-	ErrAccountNotFound:             http.StatusNotFound,
-	ErrBadRequest:                  http.StatusBadRequest,
-	ErrInvalidData:                 http.StatusBadRequest,
-	ErrUnauthorized:                http.StatusUnauthorized,
-	ErrActionNotAllowed:            http.StatusForbidden,
-	ErrInvalidPhoneNumber:          http.StatusBadRequest,
+	ErrUnexpected:               http.StatusInternalServerError,
+	ErrInternalServerError:      http.StatusInternalServerError,
+	ErrRequestTimeout:           http.StatusRequestTimeout,
+	ErrBadRequest:               http.StatusBadRequest,
+	ErrInvalidData:              http.StatusBadRequest,
+	ErrUnauthorized:             http.StatusUnauthorized,
+	ErrActionNotAllowed:         http.StatusForbidden,
+	ErrInvalidAIResponse:        http.StatusBadGateway,
+	ErrUnknownAction:            http.StatusUnprocessableEntity,
+	ErrInvalidActionParameters:  http.StatusUnprocessableEntity,
+	ErrAgentStepLimit:           http.StatusUnprocessableEntity,
+	ErrInvalidConfirmationToken: http.StatusNotFound,
+	ErrLLMUnavailable:           http.StatusServiceUnavailable,
+	ErrConflict:                 http.StatusConflict,
+	ErrRateLimited:              http.StatusTooManyRequests,
+	ErrNotFound:                 http.StatusNotFound,
+	ErrUnavailable:              http.StatusServiceUnavailable,
+	ErrInvalidInput:             http.StatusBadRequest,
+	context.DeadlineExceeded:    http.StatusGatewayTimeout,
+}
+
+// StatusCode returns the HTTP status for the outermost error in err's chain
+// that appears in ErrorMap.
+func StatusCode(err error) (int, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if statusCode, ok := ErrorMap[err]; ok {
+			return statusCode, true
+		}
+	}
+	return 0, false
+}
+
+// Details returns structured information carried by an error in err's chain,
+// for errors that provide it through an ErrorDetails method.
+func Details(err error) any {
+	var detailed interface{ ErrorDetails() any }
+	if errors.As(err, &detailed) {
+		return detailed.ErrorDetails()
+	}
+	return nil
+}
+
+// FromStatus classifies an error status returned by a provider's API.
+func FromStatus(statusCode int) error {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case statusCode == http.StatusUnauthorized, statusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case statusCode == http.StatusNotFound, statusCode == http.StatusGone:
+		return ErrNotFound
+	case statusCode >= 500:
+		return ErrUnavailable
+	case statusCode >= 400:
+		return ErrInvalidInput
+	}
+	return ErrUnexpected
 }
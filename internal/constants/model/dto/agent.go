@@ -1,12 +1,105 @@
 package dto
 
-
+import "time"
 
 type AgentRequest struct {
 	Task string `json:"task"`
 }
 
 type NaturalLanguageRequest struct {
-	Command string `json:"command"`
+	Command   string `json:"command"`
+	SessionID string `json:"session_id,omitempty"`
+	DryRun    bool   `json:"dry_run,omitempty"`
+}
+
+// Actions the assistant can extract from a natural language command.
+const (
+	ActionScheduleMeeting   = "schedule_meeting"
+	ActionRescheduleMeeting = "reschedule_meeting"
+	ActionCancelMeeting     = "cancel_meeting"
+	ActionSendEmail         = "send_email"
+	ActionGetEvents         = "get_events"
+	ActionFindFreeSlot      = "find_free_slot"
+	ActionRemind            = "remind"
+	ActionFinalAnswer       = "final_answer"
+	ActionAskUser           = "ask_user"
+)
+
+// AgentAction is the structured action the model returns for a command.
+// The enum and desc tags feed the JSON schema sent to the model.
+type AgentAction struct {
+	Action     string           `json:"action" enum:"get_events,find_free_slot,schedule_meeting,reschedule_meeting,cancel_meeting,send_email,remind,ask_user,final_answer"`
+	Parameters ActionParameters `json:"parameters"`
+	Answer     string           `json:"answer,omitempty" desc:"Message for the user, with final_answer"`
+	Question   string           `json:"question,omitempty" desc:"Clarifying question, with ask_user"`
 }
 
+type ActionParameters struct {
+	Attendees       []string `json:"attendees,omitempty" desc:"Attendee email addresses"`
+	StartTime       string   `json:"start_time,omitempty" desc:"RFC3339 timestamp"`
+	TimeExpression  string   `json:"time_expression,omitempty" desc:"The user's own words for the time"`
+	DurationMinutes int      `json:"duration_minutes,omitempty"`
+	Title           string   `json:"title,omitempty"`
+	Recurrence      []string `json:"recurrence,omitempty" desc:"RFC 5545 lines for a repeating meeting, e.g. RRULE:FREQ=WEEKLY;BYDAY=TU"`
+	EventID         string   `json:"event_id,omitempty" desc:"ID of an existing event, from get_events"`
+	Event           string   `json:"event,omitempty" desc:"Title or time of an existing event when its ID is unknown"`
+	ToEmail         string   `json:"to_email,omitempty"`
+	To              []string `json:"to,omitempty" desc:"Further recipient addresses, besides to_email"`
+	Cc              []string `json:"cc,omitempty" desc:"Addresses to copy"`
+	Bcc             []string `json:"bcc,omitempty" desc:"Addresses to copy without the others seeing"`
+	ReplyTo         string   `json:"reply_to,omitempty" desc:"Address replies should go to"`
+	Subject         string   `json:"subject,omitempty"`
+	Body            string   `json:"body,omitempty"`
+	ReminderText    string   `json:"reminder_text,omitempty"`
+	WindowStart     string   `json:"window_start,omitempty" desc:"RFC3339 timestamp"`
+	WindowEnd       string   `json:"window_end,omitempty" desc:"RFC3339 timestamp"`
+	Calendar        string   `json:"calendar,omitempty" desc:"Name of the calendar, e.g. team; empty for the default"`
+}
+
+// GenerationOptions constrain a model response. Zero values leave the
+// provider's defaults in place.
+type GenerationOptions struct {
+	ResponseMIMEType string
+	ResponseSchema   map[string]any
+	Temperature      *float64
+	MaxOutputTokens  int
+}
+
+// ActionPlan describes what a side-effecting action will do, for review before it runs.
+type ActionPlan struct {
+	Action    string        `json:"action"`
+	Title     string        `json:"title,omitempty"`
+	Calendar  string        `json:"calendar,omitempty"`
+	Attendees []string      `json:"attendees,omitempty"`
+	StartTime string        `json:"start_time,omitempty"`
+	EndTime   string        `json:"end_time,omitempty"`
+	TimeZone  string        `json:"time_zone,omitempty"`
+	Email     *EmailPreview `json:"email,omitempty"`
+	// Recurrence describes how a new meeting repeats.
+	Recurrence string `json:"recurrence,omitempty"`
+	// Conflicts previews busy time a new meeting would overlap.
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+}
+
+type EmailPreview struct {
+	To      []string `json:"to"`
+	Cc      []string `json:"cc,omitempty"`
+	Bcc     []string `json:"bcc,omitempty"`
+	Subject string   `json:"subject"`
+	Body    string   `json:"body"`
+}
+
+// PendingAction is a planned action waiting for the user to confirm it.
+type PendingAction struct {
+	Token     string
+	SessionID string
+	Action    AgentAction
+	Plan      ActionPlan
+	// Meeting, Email or Update is the request to run instead of Action, for
+	// actions requested directly through the API. An update applies to the
+	// event Action.Parameters.EventID.
+	Meeting   *Meeting
+	Email     *EmailMessage
+	Update    *EventUpdate
+	ExpiresAt time.Time
+}
@@ -0,0 +1,34 @@
+package dto
+
+import "time"
+
+// TimeRange is a half-open interval [Start, End).
+type TimeRange struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// AvailabilityRequest describes the meeting a free slot is wanted for.
+type AvailabilityRequest struct {
+	// Attendees whose calendars must be free, in addition to the user's.
+	Attendees []string
+	Duration  time.Duration
+	// WindowStart and WindowEnd bound the search.
+	WindowStart time.Time
+	WindowEnd   time.Time
+	// WorkdayStart and WorkdayEnd are times of day such as "09:00".
+	WorkdayStart string
+	WorkdayEnd   string
+	// BufferBefore and BufferAfter keep free time around existing events.
+	BufferBefore    time.Duration
+	BufferAfter     time.Duration
+	IncludeWeekends bool
+	Limit           int
+}
+
+// Slot is a candidate meeting time. Higher scores are better.
+type Slot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Score float64   `json:"score"`
+}
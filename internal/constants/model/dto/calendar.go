@@ -1,7 +1,17 @@
 package dto
 
+// Calendar is one of the user's calendars, such as their work, personal or a
+// shared team calendar.
 type Calendar struct {
-	GoogleCalendarAPIKey string
-	CalendarID           string
-	TimeZone             string
+	// Name is how the user refers to the calendar, such as "team".
+	Name string `json:"name"`
+	// ID identifies the calendar to the provider: a Google calendar ID, a
+	// CalDAV calendar name or, for the local calendar, part of its file name.
+	ID string `json:"id"`
+	// Read calendars are listed and count as busy time; Write calendars can
+	// have meetings scheduled, changed and cancelled on them.
+	Read  bool `json:"read"`
+	Write bool `json:"write"`
+	// Default is where new meetings go when no calendar is named.
+	Default bool `json:"default,omitempty"`
 }
@@ -1,5 +1,13 @@
 package dto
 
+// Meeting reminder recipients.
+const (
+	// RemindAttendees reminds everyone invited, the user included.
+	RemindAttendees = "attendees"
+	// RemindUser reminds only the user.
+	RemindUser = "user"
+)
+
 type Config struct {
 	GoogleCalendarAPIKey string
 	SendGridAPIKey       string
@@ -11,13 +19,116 @@ type Config struct {
 	SendGridURL       string
 	GeminiURL         string
 
+	// Google OAuth2: a user's refresh token, from GoogleRefreshToken or the
+	// consent flow, which saves it to GoogleTokenFile.
+	GoogleClientID     string
+	GoogleClientSecret string
+	GoogleRefreshToken string
+	GoogleTokenFile    string
+	GoogleRedirectURL  string
+	// A service account key file, acting as GoogleImpersonateUser through
+	// domain-wide delegation when that is set.
+	GoogleServiceAccountFile string
+	GoogleImpersonateUser    string
+	// GoogleTokenURL and GoogleAuthURL override Google's OAuth2 endpoints.
+	GoogleTokenURL string
+	GoogleAuthURL  string
+
+	// LLMProviders is the ordered fallback chain of language model providers
+	// (gemini, openai, ollama).
+	LLMProviders []string
+	GeminiModel  string
+	OpenAIAPIKey string
+	OpenAIURL    string
+	OpenAIModel  string
+	OllamaURL    string
+	OllamaModel  string
+
+	LLMTemperature     float64
+	LLMMaxOutputTokens int
+	// LLMRepairAttempts bounds how often an invalid JSON reply is sent back for correction.
+	LLMRepairAttempts int
+	// GeminiSafetyThreshold applies to all harm categories, e.g. BLOCK_ONLY_HIGH.
+	GeminiSafetyThreshold string
+
+	// HTTPMaxRetries bounds retries of transient failures in outbound clients.
+	HTTPMaxRetries int
+	// Per-provider rate limits in requests per minute; 0 disables limiting.
+	GeminiRatePerMinute   float64
+	CalendarRatePerMinute float64
+	EmailRatePerMinute    float64
+	// BreakerThreshold consecutive failures stop calls to a provider for
+	// BreakerCooldownSeconds; 0 disables the breakers.
+	BreakerThreshold       int
+	BreakerCooldownSeconds int
+
 	FromEmail string
 	FromName  string
 	UserEmail string
 
+	// CalendarBackend selects the calendar provider: google, caldav or
+	// local. Empty uses Google when Google credentials are set.
+	CalendarBackend string
+	// CalendarID is the calendar used when Calendars is empty: a Google
+	// calendar ID, "primary" by default, or a CalDAV calendar name.
 	CalendarID string
-	TimeZone   string
+	// Calendars are the user's calendars on the provider, one of them the
+	// default. Listings and free/busy combine the readable ones.
+	Calendars []Calendar
+	TimeZone  string
 
-	DailyReminderTime      string
+	// CalDAVURL is the server's DAV root, principal or a calendar collection.
+	// CalendarID picks a calendar by name when the URL is not a calendar.
+	CalDAVURL      string
+	CalDAVUsername string
+	CalDAVPassword string
+	// LocalCalendarFile is where the local calendar keeps its events; empty
+	// keeps them in memory only.
+	LocalCalendarFile string
+
+	// Working hours used when looking for free slots, such as "09:00" and "17:00".
+	WorkdayStart string
+	WorkdayEnd   string
+	// MeetingBufferMinutes of free time are kept around existing events.
+	MeetingBufferMinutes int
+	// ConflictPolicy is what happens when a new meeting overlaps busy time:
+	// ConflictReject, ConflictWarn or ConflictPropose.
+	ConflictPolicy string
+
+	// DailyReminderTime is when the daily digest is sent, such as "09:00" in
+	// TimeZone.
+	DailyReminderTime string
+	// MeetingReminderMinutes before a meeting starts a reminder is emailed;
+	// zero turns meeting reminders off.
 	MeetingReminderMinutes int
+	// ReminderRecipients is RemindAttendees or RemindUser.
+	ReminderRecipients string
+	// RecipientTimeZones gives the IANA zone of people whose reminders should
+	// not use TimeZone, by email address.
+	RecipientTimeZones map[string]string
+	// ReminderStateDir keeps a record of the reminders sent, shared by every
+	// replica; empty keeps it in memory.
+	ReminderStateDir string
+	// SchedulerStateFile records when each scheduled job last ran; empty
+	// keeps it in memory.
+	SchedulerStateFile string
+	// AdminToken must be sent as a bearer token to the admin API, which is
+	// disabled while it is empty.
+	AdminToken string
+
+	// OutboxFile keeps email waiting to be delivered; empty keeps it in
+	// memory, so queued email is lost on restart.
+	OutboxFile string
+	// EmailWorkers deliver queued email at the same time.
+	EmailWorkers int
+	// EmailMaxAttempts is how many times an email is tried before it is
+	// given up as dead.
+	EmailMaxAttempts int
+
+	AgentMaxSteps     int
+	SessionTTLMinutes int
+
+	// ConfirmActions lists side-effecting actions that must be confirmed before they run.
+	ConfirmActions         []string
+	ConfirmationTTLMinutes int
 }
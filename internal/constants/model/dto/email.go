@@ -0,0 +1,107 @@
+package dto
+
+import (
+	"ai_agent/internal/constants/errors"
+	"fmt"
+	"net/mail"
+	"net/textproto"
+	"strings"
+)
+
+// MaxAttachmentBytes bounds the combined size of an email's attachments,
+// below what the email providers accept once encoded.
+const MaxAttachmentBytes = 10 << 20
+
+// reservedHeaders are set from the message's fields and cannot be given as
+// custom headers.
+var reservedHeaders = map[string]bool{
+	"From": true, "To": true, "Cc": true, "Bcc": true, "Reply-To": true, "Subject": true, "Date": true,
+	"Mime-Version": true, "Content-Type": true, "Content-Transfer-Encoding": true,
+	"Received": true, "Dkim-Signature": true,
+}
+
+// EmailMessage is an email to send. It is sent from the configured FromEmail
+// and needs at least one recipient and a plain or an HTML body, or both as
+// alternatives of each other.
+type EmailMessage struct {
+	To      []string `json:"to,omitempty"`
+	Cc      []string `json:"cc,omitempty"`
+	Bcc     []string `json:"bcc,omitempty"`
+	ReplyTo string   `json:"reply_to,omitempty"`
+	Subject string   `json:"subject"`
+	Text    string   `json:"text,omitempty"`
+	HTML    string   `json:"html,omitempty"`
+	// Headers are extra headers such as In-Reply-To or List-Unsubscribe.
+	Headers     map[string]string `json:"headers,omitempty"`
+	Attachments []EmailAttachment `json:"attachments,omitempty"`
+}
+
+// EmailAttachment is a file attached to an email. Content is base64 in JSON.
+type EmailAttachment struct {
+	Filename string `json:"filename"`
+	// ContentType defaults to one guessed from the file name.
+	ContentType string `json:"content_type,omitempty"`
+	Content     []byte `json:"content,omitempty"`
+}
+
+// Recipients returns every address the message is sent to.
+func (m EmailMessage) Recipients() []string {
+	recipients := make([]string, 0, len(m.To)+len(m.Cc)+len(m.Bcc))
+	recipients = append(recipients, m.To...)
+	recipients = append(recipients, m.Cc...)
+	return append(recipients, m.Bcc...)
+}
+
+// Validate checks that the message can be sent, so that a bad one is
+// rejected before it is queued rather than failing on every attempt.
+func (m EmailMessage) Validate() error {
+	if len(m.Recipients()) == 0 {
+		return fmt.Errorf("%w: an email needs at least one recipient", errors.ErrInvalidInput)
+	}
+	for _, address := range m.Recipients() {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return fmt.Errorf("%w: invalid email address %q", errors.ErrInvalidInput, address)
+		}
+	}
+	if m.ReplyTo != "" {
+		if _, err := mail.ParseAddress(m.ReplyTo); err != nil {
+			return fmt.Errorf("%w: invalid reply-to address %q", errors.ErrInvalidInput, m.ReplyTo)
+		}
+	}
+	if strings.TrimSpace(m.Subject) == "" {
+		return fmt.Errorf("%w: subject is required", errors.ErrInvalidInput)
+	}
+	if strings.ContainsAny(m.Subject, "\r\n") {
+		return fmt.Errorf("%w: subject must be a single line", errors.ErrInvalidInput)
+	}
+	if m.Text == "" && m.HTML == "" {
+		return fmt.Errorf("%w: an email needs a text or an HTML body", errors.ErrInvalidInput)
+	}
+
+	for name, value := range m.Headers {
+		if name == "" || strings.IndexFunc(name, func(r rune) bool { return r <= ' ' || r > '~' || r == ':' }) >= 0 {
+			return fmt.Errorf("%w: invalid header name %q", errors.ErrInvalidInput, name)
+		}
+		if reservedHeaders[textproto.CanonicalMIMEHeaderKey(name)] {
+			return fmt.Errorf("%w: header %s cannot be set", errors.ErrInvalidInput, name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("%w: header %s must be a single line", errors.ErrInvalidInput, name)
+		}
+	}
+
+	size := 0
+	for _, attachment := range m.Attachments {
+		if strings.TrimSpace(attachment.Filename) == "" || strings.ContainsAny(attachment.Filename, "\r\n/\\") {
+			return fmt.Errorf("%w: invalid attachment file name %q", errors.ErrInvalidInput, attachment.Filename)
+		}
+		if strings.ContainsAny(attachment.ContentType, "\r\n") {
+			return fmt.Errorf("%w: invalid content type for %s", errors.ErrInvalidInput, attachment.Filename)
+		}
+		size += len(attachment.Content)
+	}
+	if size > MaxAttachmentBytes {
+		return fmt.Errorf("%w: attachments exceed %d MB", errors.ErrInvalidInput, MaxAttachmentBytes>>20)
+	}
+	return nil
+}
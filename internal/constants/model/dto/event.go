@@ -2,9 +2,96 @@ package dto
 
 import "time"
 
+// Event statuses.
+const (
+	EventConfirmed = "confirmed"
+	EventTentative = "tentative"
+	EventCancelled = "cancelled"
+)
+
+// Attendee responses to an invitation.
+const (
+	ResponseNeedsAction = "needs_action"
+	ResponseAccepted    = "accepted"
+	ResponseDeclined    = "declined"
+	ResponseTentative   = "tentative"
+)
+
 type Event struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	// Calendar is the name of the calendar holding the event when several
+	// are configured.
+	Calendar    string   `json:"calendar,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Location    string   `json:"location,omitempty"`
+	Attendees   []string `json:"attendees"`
+	// Responses holds the attendees' replies to the invitation, by email
+	// address, where the calendar reports them.
+	Responses map[string]string `json:"responses,omitempty"`
+	// Organizer is the email address of the person who owns the event.
+	Organizer string    `json:"organizer,omitempty"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	// AllDay events start at midnight of their first day and end at midnight
+	// after their last, in the calendar's time zone.
+	AllDay bool `json:"all_day,omitempty"`
+	// Status is one of the event statuses; empty means confirmed.
+	Status string `json:"status,omitempty"`
+	// ConferenceURL is the video call link, such as a Google Meet URL.
+	ConferenceURL string `json:"conference_url,omitempty"`
+	// Recurrence holds RFC 5545 RRULE and EXDATE lines for a recurring
+	// event; StartTime and EndTime are then its first occurrence.
+	Recurrence []string `json:"recurrence,omitempty"`
+	// RecurringEventID is set on an occurrence of a recurring event and is
+	// the ID of the series.
+	RecurringEventID string `json:"recurring_event_id,omitempty"`
+	// OriginalStartTime is when the series scheduled an occurrence, which
+	// differs from StartTime once the occurrence has been moved.
+	OriginalStartTime time.Time `json:"original_start_time,omitzero"`
+}
+
+// EventUpdate changes an existing event. Zero fields are left unchanged.
+type EventUpdate struct {
 	Title     string
 	Attendees []string
 	StartTime time.Time
-	EndTime   time.Time
+	Duration  time.Duration
+}
+
+// EventQuery selects the events overlapping [From, To), with recurring
+// events given as their occurrences, ordered by start time.
+type EventQuery struct {
+	From time.Time
+	To   time.Time
+	// Query keeps events whose title or attendees contain it, ignoring case.
+	Query string
+	// Attendee keeps events the email address is invited to or organizes.
+	Attendee string
+	// Limit is the page size; zero returns every event in the range.
+	Limit int
+	// Cursor is the NextCursor of the previous page.
+	Cursor string
+	// Calendar limits the listing to the calendar with this name.
+	Calendar string
+}
+
+// EventPage is one page of a listing. NextCursor is empty on the last page.
+type EventPage struct {
+	Events     []Event
+	NextCursor string
+}
+
+// ImportResult reports the events an iCalendar import added and those it
+// could not.
+type ImportResult struct {
+	Imported []Event
+	Skipped  []SkippedEvent
+}
+
+// SkippedEvent is an event from an import that was not added.
+type SkippedEvent struct {
+	UID    string `json:"uid"`
+	Title  string `json:"title"`
+	Reason string `json:"reason"`
 }
@@ -0,0 +1,32 @@
+package dto
+
+import "time"
+
+// Job describes a scheduled job, for the admin API.
+type Job struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Schedule says when the job runs, such as "daily at 09:00 America/New_York".
+	Schedule string    `json:"schedule"`
+	NextRun  time.Time `json:"next_run,omitzero"`
+	Running  bool      `json:"running"`
+	LastRun  *JobRun   `json:"last_run,omitempty"`
+}
+
+// JobRun is one run of a job.
+type JobRun struct {
+	// Scheduled is when the run was due; it is zero for runs triggered by hand.
+	Scheduled  time.Time `json:"scheduled,omitzero"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at,omitzero"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// JobState is what the scheduler remembers about a job across restarts.
+type JobState struct {
+	Name string `json:"name"`
+	// LastScheduled is the latest due time that was run, so it is not run
+	// again and earlier missed runs can be caught up.
+	LastScheduled time.Time `json:"last_scheduled,omitzero"`
+	LastRun       *JobRun   `json:"last_run,omitempty"`
+}
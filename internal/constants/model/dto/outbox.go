@@ -0,0 +1,31 @@
+package dto
+
+import "time"
+
+// Outbox statuses track an email from queueing to delivery.
+const (
+	// OutboxQueued is waiting for its first attempt.
+	OutboxQueued = "queued"
+	// OutboxSending is being handed to the email provider.
+	OutboxSending = "sending"
+	// OutboxRetrying failed and is tried again at NextAttemptAt.
+	OutboxRetrying = "retrying"
+	// OutboxSent was accepted by the email provider.
+	OutboxSent = "sent"
+	// OutboxDead failed for good, after too many attempts or with an error
+	// that retrying cannot fix.
+	OutboxDead = "dead"
+)
+
+// OutboxMessage is an email in the outbox and the state of its delivery.
+type OutboxMessage struct {
+	ID            string       `json:"id"`
+	Message       EmailMessage `json:"message"`
+	Status        string       `json:"status"`
+	Attempts      int          `json:"attempts"`
+	LastError     string       `json:"last_error,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+	NextAttemptAt time.Time    `json:"next_attempt_at,omitzero"`
+	SentAt        time.Time    `json:"sent_at,omitzero"`
+}
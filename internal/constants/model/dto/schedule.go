@@ -0,0 +1,91 @@
+package dto
+
+import (
+	"ai_agent/internal/constants/errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Conflict policies decide what happens when a new meeting overlaps busy time.
+const (
+	// ConflictReject refuses to book and reports the clashes.
+	ConflictReject = "reject"
+	// ConflictWarn books anyway and reports the clashes.
+	ConflictWarn = "warn"
+	// ConflictPropose refuses to book and suggests the nearest free slot.
+	ConflictPropose = "propose"
+)
+
+// Schedule outcomes report what was done with a meeting request.
+const (
+	OutcomeScheduled              = "scheduled"
+	OutcomeScheduledWithConflicts = "scheduled_with_conflicts"
+	OutcomeRejected               = "rejected"
+	OutcomeAlternativeProposed    = "alternative_proposed"
+)
+
+// Meeting is a request to schedule a new meeting.
+type Meeting struct {
+	Title       string
+	Description string
+	Location    string
+	Attendees   []string
+	StartTime   time.Time
+	Duration    time.Duration
+	// Recurrence holds RFC 5545 RRULE and EXDATE lines for a recurring meeting.
+	Recurrence []string
+	// Calendar names the calendar to book on; empty uses the default.
+	Calendar string
+	// ConflictPolicy overrides Config.ConflictPolicy when set.
+	ConflictPolicy string
+}
+
+// Conflict is busy time on one calendar that overlaps a requested meeting.
+// EventID and Title are known only for events on the user's own calendar.
+type Conflict struct {
+	Calendar string    `json:"calendar"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	EventID  string    `json:"event_id,omitempty"`
+	Title    string    `json:"title,omitempty"`
+}
+
+// ScheduleResult is a booked meeting and any conflicts it was booked over.
+type ScheduleResult struct {
+	Event     Event
+	Outcome   string
+	Conflicts []Conflict
+}
+
+// ConflictError is returned when a meeting is not booked because of conflicts.
+// It wraps errors.ErrConflict and is reported in full in error responses.
+type ConflictError struct {
+	Outcome     string     `json:"outcome"`
+	Conflicts   []Conflict `json:"conflicts"`
+	Alternative *TimeRange `json:"alternative,omitempty"`
+}
+
+func (e *ConflictError) Error() string {
+	clashes := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		clash := c.Calendar + " is busy " + c.Start.Format(time.RFC3339) + " to " + c.End.Format(time.RFC3339)
+		if c.Title != "" {
+			clash += fmt.Sprintf(" (%s)", c.Title)
+		}
+		clashes = append(clashes, clash)
+	}
+
+	msg := fmt.Sprintf("%v: %s", errors.ErrConflict, strings.Join(clashes, "; "))
+	if e.Alternative != nil {
+		msg += "; nearest free slot is " + e.Alternative.Start.Format(time.RFC3339) + " to " + e.Alternative.End.Format(time.RFC3339)
+	} else if e.Outcome == OutcomeAlternativeProposed {
+		msg += "; no free slot found nearby"
+	}
+	return msg
+}
+
+func (e *ConflictError) Unwrap() error { return errors.ErrConflict }
+
+// ErrorDetails exposes the conflicts in error responses.
+func (e *ConflictError) ErrorDetails() any { return e }
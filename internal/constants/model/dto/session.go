@@ -0,0 +1,33 @@
+package dto
+
+import "time"
+
+// Conversation roles recorded in a session.
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Session is the server-side conversation history for a client session.
+type Session struct {
+	ID        string
+	Turns     []ConversationTurn
+	UpdatedAt time.Time
+}
+
+type ConversationTurn struct {
+	Role    string
+	Content string
+}
+
+// CommandResult is the outcome of one natural language command.
+type CommandResult struct {
+	SessionID          string
+	Result             string
+	NeedsClarification bool
+
+	// Set when the action was planned but not run, pending confirmation.
+	Plan              *ActionPlan
+	ConfirmationToken string
+	ExpiresAt         time.Time
+}
@@ -9,4 +9,5 @@ type Response struct {
 type ErrorResponse struct {
 	StausCode int    `json:"status_code"`
 	Message   string `json:"message"`
+	Details   any    `json:"details,omitempty"`
 }
@@ -28,7 +28,7 @@ func SendSuccessResponse(w http.ResponseWriter, statusCode int, data any) {
 func SendErrorResponse(w http.ResponseWriter, err error) {
 	w.Header().Set("Content-Type", "application/json")
 
-	statusCode, ok := errors.ErrorMap[err]
+	statusCode, ok := errors.StatusCode(err)
 	if !ok {
 		w.WriteHeader(errors.ErrorMap[errors.ErrUnexpected])
 		json.NewEncoder(w).Encode(Response{
@@ -45,8 +45,9 @@ func SendErrorResponse(w http.ResponseWriter, err error) {
 	if err := json.NewEncoder(w).Encode(Response{
 		Ok: false,
 		Error: &ErrorResponse{
-			StausCode: errors.ErrorMap[err],
+			StausCode: statusCode,
 			Message:   err.Error(),
+			Details:   errors.Details(err),
 		},
 	}); err != nil {
 		w.WriteHeader(errors.ErrorMap[errors.ErrUnexpected])
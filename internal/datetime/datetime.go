@@ -0,0 +1,409 @@
+// Package datetime resolves relative date and time expressions such as
+// "tomorrow at 3pm", "next Tuesday" or "in 2 hours" against a clock and an
+// IANA time zone.
+//
+// Conventions:
+//   - A date without a time resolves to the start of the working day (09:00).
+//   - A time without a date resolves to today, or tomorrow if it has passed.
+//   - "friday" and "this friday" are the nearest Friday on or after today;
+//     "next friday" is the Friday of the following week.
+//   - "end of day" is 17:00 today, or tomorrow once 17:00 has passed;
+//     "end of week" is 17:00 on Friday.
+//   - A weekday or end of week that has already passed today means next week.
+//   - A bare hour from 1 to 7 ("at 3") is read as afternoon, and any hour
+//     before noon is read as evening after "tonight".
+package datetime
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnrecognized is returned when an expression cannot be resolved.
+var ErrUnrecognized = errors.New("unrecognized date/time expression")
+
+const (
+	workdayStartHour = 9
+	workdayEndHour   = 17
+)
+
+// Resolver turns natural language expressions into absolute times.
+type Resolver struct {
+	loc *time.Location
+	now func() time.Time
+}
+
+// NewResolver anchors expressions to now() in loc. A nil now uses time.Now.
+func NewResolver(loc *time.Location, now func() time.Time) *Resolver {
+	if loc == nil {
+		loc = time.UTC
+	}
+	if now == nil {
+		now = time.Now
+	}
+	return &Resolver{loc: loc, now: now}
+}
+
+// Location returns the zone expressions are resolved in.
+func (r *Resolver) Location() *time.Location {
+	return r.loc
+}
+
+// Now returns the resolver's current time in its zone.
+func (r *Resolver) Now() time.Time {
+	return r.now().In(r.loc)
+}
+
+var (
+	spaceRe    = regexp.MustCompile(`\s+`)
+	relativeRe = regexp.MustCompile(`^(?:in\s+)?(\d+|an?|half an)\s+(minute|min|hour|hr|day|week)s?(\s+from now)?$`)
+
+	isoDateRe     = regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})\b`)
+	dayAfterRe    = regexp.MustCompile(`\bday after tomorrow\b`)
+	relativeDayRe = regexp.MustCompile(`\b(today|tonight|tomorrow|yesterday)\b`)
+	endOfRe       = regexp.MustCompile(`\b(?:end of (?:the )?(day|week)|eod)\b`)
+	weekdayRe     = regexp.MustCompile(`\b(?:(this|next|coming)\s+)?(monday|tuesday|wednesday|thursday|friday|saturday|sunday|mon|tues|tue|wed|thurs|thu|fri|sat|sun)\b`)
+	monthDayRe    = regexp.MustCompile(`\b(january|february|march|april|may|june|july|august|september|october|november|december|jan|feb|mar|apr|jun|jul|aug|sept|sep|oct|nov|dec)\.?\s+(\d{1,2})(?:st|nd|rd|th)?\b`)
+	dayMonthRe    = regexp.MustCompile(`\b(\d{1,2})(?:st|nd|rd|th)?\s+(?:of\s+)?(january|february|march|april|may|june|july|august|september|october|november|december|jan|feb|mar|apr|jun|jul|aug|sept|sep|oct|nov|dec)\b`)
+
+	namedTimeRe = regexp.MustCompile(`\b(noon|midday|midnight|morning|afternoon|evening|night)\b`)
+	clockRe     = regexp.MustCompile(`\b(\d{1,2}):(\d{2})\s*(am|pm)?\b`)
+	meridiemRe  = regexp.MustCompile(`\b(\d{1,2})\s*(am|pm)\b`)
+	bareHourRe  = regexp.MustCompile(`\b(\d{1,2})\b`)
+
+	fillerWords = map[string]bool{"at": true, "on": true, "the": true, "in": true, "of": true, "this": true, "by": true}
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+var months = map[string]time.Month{
+	"january": time.January, "jan": time.January,
+	"february": time.February, "feb": time.February,
+	"march": time.March, "mar": time.March,
+	"april": time.April, "apr": time.April,
+	"may":  time.May,
+	"june": time.June, "jun": time.June,
+	"july": time.July, "jul": time.July,
+	"august": time.August, "aug": time.August,
+	"september": time.September, "sep": time.September, "sept": time.September,
+	"october": time.October, "oct": time.October,
+	"november": time.November, "nov": time.November,
+	"december": time.December, "dec": time.December,
+}
+
+var namedTimes = map[string]int{
+	"midnight":  0,
+	"morning":   9,
+	"noon":      12,
+	"midday":    12,
+	"afternoon": 14,
+	"evening":   18,
+	"night":     20,
+}
+
+// Resolve returns the absolute time an expression refers to.
+func (r *Resolver) Resolve(expression string) (time.Time, error) {
+	return r.resolve(expression, time.Time{})
+}
+
+// ResolveFrom resolves the new time for something currently at ref, as in
+// "move my 3pm to tomorrow": a missing date keeps ref's date and a missing
+// time keeps ref's time of day.
+func (r *Resolver) ResolveFrom(expression string, ref time.Time) (time.Time, error) {
+	return r.resolve(expression, ref.In(r.loc))
+}
+
+func (r *Resolver) resolve(expression string, ref time.Time) (time.Time, error) {
+	expr := normalize(expression)
+	if expr == "" {
+		return time.Time{}, fmt.Errorf("%w: empty expression", ErrUnrecognized)
+	}
+
+	now := r.Now()
+	if expr == "now" || expr == "right now" {
+		return now, nil
+	}
+	if t, ok := resolveRelative(expr, now); ok {
+		return t, nil
+	}
+
+	p := parser{rest: expr, now: now}
+	p.parseDate()
+	p.parseTime()
+
+	if leftover := strings.TrimSpace(p.rest); leftover != "" {
+		return time.Time{}, fmt.Errorf("%w: %q (could not understand %q)", ErrUnrecognized, expression, leftover)
+	}
+	if p.err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q: %v", ErrUnrecognized, expression, p.err)
+	}
+	if !p.hasDate && !p.hasTime {
+		return time.Time{}, fmt.Errorf("%w: %q", ErrUnrecognized, expression)
+	}
+
+	date := p.date
+	if !p.hasDate {
+		date = now
+		if !ref.IsZero() {
+			date = ref
+		}
+	}
+	hour, minute := workdayStartHour, 0
+	switch {
+	case p.hasTime && p.evening && p.hour < 12:
+		hour, minute = p.hour+12, p.minute
+	case p.hasTime:
+		hour, minute = p.hour, p.minute
+	case p.evening:
+		hour = namedTimes["evening"]
+	case !ref.IsZero():
+		hour, minute = ref.Hour(), ref.Minute()
+	}
+
+	result := time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, r.loc)
+	if result.Before(now) && (p.hasDate || ref.IsZero()) {
+		switch {
+		case !p.hasDate:
+			result = time.Date(date.Year(), date.Month(), date.Day()+1, hour, minute, 0, 0, r.loc)
+		case p.weekly:
+			result = time.Date(date.Year(), date.Month(), date.Day()+7, hour, minute, 0, 0, r.loc)
+		}
+	}
+	return result, nil
+}
+
+func normalize(expression string) string {
+	expr := strings.ToLower(expression)
+	expr = strings.NewReplacer(",", " ", "a.m.", "am", "p.m.", "pm").Replace(expr)
+	return strings.TrimSpace(spaceRe.ReplaceAllString(expr, " "))
+}
+
+// resolveRelative handles offsets from now such as "in 2 hours" or "3 days from now".
+func resolveRelative(expr string, now time.Time) (time.Time, bool) {
+	m := relativeRe.FindStringSubmatch(expr)
+	if m == nil || (!strings.HasPrefix(expr, "in ") && m[3] == "") {
+		return time.Time{}, false
+	}
+
+	var amount float64
+	switch m[1] {
+	case "a", "an":
+		amount = 1
+	case "half an":
+		amount = 0.5
+	default:
+		n, _ := strconv.Atoi(m[1])
+		amount = float64(n)
+	}
+
+	switch m[2] {
+	case "minute", "min":
+		return now.Add(time.Duration(amount * float64(time.Minute))), true
+	case "hour", "hr":
+		return now.Add(time.Duration(amount * float64(time.Hour))), true
+	case "day":
+		return now.AddDate(0, 0, int(amount)), true
+	case "week":
+		return now.AddDate(0, 0, 7*int(amount)), true
+	}
+	return time.Time{}, false
+}
+
+// parser consumes the date and time parts of an expression from rest.
+type parser struct {
+	rest string
+	now  time.Time
+	err  error
+
+	hasDate bool
+	date    time.Time
+
+	hasTime      bool
+	hour, minute int
+
+	// evening marks "tonight", which moves the default and bare hours to the evening.
+	evening bool
+	// weekly marks weekday dates, which roll over to next week once they have passed.
+	weekly bool
+}
+
+func (p *parser) consume(re *regexp.Regexp) []string {
+	loc := re.FindStringSubmatchIndex(p.rest)
+	if loc == nil {
+		return nil
+	}
+	match := make([]string, len(loc)/2)
+	for i := range match {
+		if loc[2*i] >= 0 {
+			match[i] = p.rest[loc[2*i]:loc[2*i+1]]
+		}
+	}
+	p.rest = p.rest[:loc[0]] + " " + p.rest[loc[1]:]
+	return match
+}
+
+func (p *parser) setDate(date time.Time) {
+	if p.hasDate {
+		p.err = errors.New("more than one date")
+		return
+	}
+	p.hasDate = true
+	p.date = date
+}
+
+func (p *parser) setTime(hour, minute int) {
+	if p.hasTime {
+		p.err = errors.New("more than one time")
+		return
+	}
+	if hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		p.err = fmt.Errorf("invalid time %d:%02d", hour, minute)
+		return
+	}
+	p.hasTime = true
+	p.hour, p.minute = hour, minute
+}
+
+func (p *parser) parseDate() {
+	today := time.Date(p.now.Year(), p.now.Month(), p.now.Day(), 0, 0, 0, 0, p.now.Location())
+
+	if m := p.consume(isoDateRe); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		day, _ := strconv.Atoi(m[3])
+		p.setDate(p.checkedDate(year, time.Month(month), day))
+	}
+	if m := p.consume(dayAfterRe); m != nil {
+		p.setDate(today.AddDate(0, 0, 2))
+	}
+	if m := p.consume(relativeDayRe); m != nil {
+		switch m[1] {
+		case "today":
+			p.setDate(today)
+		case "tonight":
+			p.setDate(today)
+			p.evening = true
+		case "tomorrow":
+			p.setDate(today.AddDate(0, 0, 1))
+		case "yesterday":
+			p.setDate(today.AddDate(0, 0, -1))
+		}
+	}
+	if m := p.consume(endOfRe); m != nil {
+		if m[1] == "week" {
+			p.setDate(nextWeekday(today, time.Friday, false))
+			p.weekly = true
+		} else if p.now.Hour() >= workdayEndHour {
+			p.setDate(today.AddDate(0, 0, 1))
+		} else {
+			p.setDate(today)
+		}
+		p.setTime(workdayEndHour, 0)
+	}
+	if m := p.consume(weekdayRe); m != nil {
+		p.setDate(nextWeekday(today, weekdays[m[2]], m[1] == "next"))
+		p.weekly = true
+	}
+	if m := p.consume(monthDayRe); m != nil {
+		day, _ := strconv.Atoi(m[2])
+		p.setDate(p.upcomingDate(months[m[1]], day))
+	}
+	if m := p.consume(dayMonthRe); m != nil {
+		day, _ := strconv.Atoi(m[1])
+		p.setDate(p.upcomingDate(months[m[2]], day))
+	}
+}
+
+func (p *parser) parseTime() {
+	if m := p.consume(clockRe); m != nil {
+		hour, _ := strconv.Atoi(m[1])
+		minute, _ := strconv.Atoi(m[2])
+		p.setTime(applyMeridiem(hour, m[3], &p.err), minute)
+	}
+	if m := p.consume(meridiemRe); m != nil {
+		hour, _ := strconv.Atoi(m[1])
+		p.setTime(applyMeridiem(hour, m[2], &p.err), 0)
+	}
+	if m := p.consume(namedTimeRe); m != nil {
+		p.setTime(namedTimes[m[1]], 0)
+	}
+	if !p.hasTime {
+		if m := p.consume(bareHourRe); m != nil {
+			hour, _ := strconv.Atoi(m[1])
+			if hour >= 1 && hour <= 7 && !p.evening {
+				hour += 12
+			}
+			p.setTime(hour, 0)
+		}
+	}
+
+	var leftover []string
+	for _, word := range strings.Fields(p.rest) {
+		if !fillerWords[word] {
+			leftover = append(leftover, word)
+		}
+	}
+	p.rest = strings.Join(leftover, " ")
+}
+
+// checkedDate builds a date, rejecting out-of-range days instead of normalizing them.
+func (p *parser) checkedDate(year int, month time.Month, day int) time.Time {
+	date := time.Date(year, month, day, 0, 0, 0, 0, p.now.Location())
+	if date.Month() != month || date.Day() != day {
+		p.err = fmt.Errorf("invalid date %04d-%02d-%02d", year, month, day)
+	}
+	return date
+}
+
+// upcomingDate returns the next occurrence of month/day, today included.
+func (p *parser) upcomingDate(month time.Month, day int) time.Time {
+	date := p.checkedDate(p.now.Year(), month, day)
+	today := time.Date(p.now.Year(), p.now.Month(), p.now.Day(), 0, 0, 0, 0, p.now.Location())
+	if date.Before(today) {
+		date = p.checkedDate(p.now.Year()+1, month, day)
+	}
+	return date
+}
+
+// nextWeekday returns the nearest matching weekday on or after today, or the
+// one in the following week when next is set.
+func nextWeekday(today time.Time, weekday time.Weekday, next bool) time.Time {
+	if next {
+		daysToMonday := (int(time.Monday) - int(today.Weekday()) + 7) % 7
+		if daysToMonday == 0 {
+			daysToMonday = 7
+		}
+		monday := today.AddDate(0, 0, daysToMonday)
+		return monday.AddDate(0, 0, (int(weekday)-int(time.Monday)+7)%7)
+	}
+	return today.AddDate(0, 0, (int(weekday)-int(today.Weekday())+7)%7)
+}
+
+func applyMeridiem(hour int, meridiem string, err *error) int {
+	switch meridiem {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			*err = fmt.Errorf("invalid hour %d%s", hour, meridiem)
+			return hour
+		}
+		if meridiem == "am" && hour == 12 {
+			return 0
+		}
+		if meridiem == "pm" && hour != 12 {
+			return hour + 12
+		}
+	}
+	return hour
+}
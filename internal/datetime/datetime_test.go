@@ -0,0 +1,141 @@
+package datetime
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// testResolver resolves against Tuesday, October 27, 2026 at 10:30 in New
+// York, the week before clocks go back on Sunday, November 1.
+func testResolver(t *testing.T) *Resolver {
+	t.Helper()
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	now := time.Date(2026, time.October, 27, 10, 30, 0, 0, loc)
+	return NewResolver(loc, func() time.Time { return now })
+}
+
+func TestResolve(t *testing.T) {
+	resolver := testResolver(t)
+
+	tests := []struct {
+		name       string
+		expression string
+		want       string
+	}{
+		{"now", "now", "2026-10-27T10:30:00-04:00"},
+		{"today with time", "today at 3pm", "2026-10-27T15:00:00-04:00"},
+		{"tomorrow", "tomorrow", "2026-10-28T09:00:00-04:00"},
+		{"tomorrow with clock", "Tomorrow at 4:15 p.m.", "2026-10-28T16:15:00-04:00"},
+		{"day after tomorrow", "day after tomorrow at noon", "2026-10-29T12:00:00-04:00"},
+		{"yesterday stays in the past", "yesterday at 9am", "2026-10-26T09:00:00-04:00"},
+		{"tonight", "tonight at 8", "2026-10-27T20:00:00-04:00"},
+		{"in minutes", "in 45 minutes", "2026-10-27T11:15:00-04:00"},
+		{"in half an hour", "in half an hour", "2026-10-27T11:00:00-04:00"},
+		{"days from now", "2 days from now", "2026-10-29T10:30:00-04:00"},
+
+		{"weekday", "friday", "2026-10-30T09:00:00-04:00"},
+		{"abbreviated weekday", "thu at 2pm", "2026-10-29T14:00:00-04:00"},
+		{"this weekday", "this friday at 11am", "2026-10-30T11:00:00-04:00"},
+		{"next weekday", "next friday", "2026-11-06T09:00:00-05:00"},
+		{"next weekday early in the week", "next monday at 10am", "2026-11-02T10:00:00-05:00"},
+		{"today's weekday later today", "tuesday at 3pm", "2026-10-27T15:00:00-04:00"},
+		{"today's weekday already passed", "tuesday", "2026-11-03T09:00:00-05:00"},
+		{"end of day", "end of day", "2026-10-27T17:00:00-04:00"},
+		{"end of week", "end of the week", "2026-10-30T17:00:00-04:00"},
+
+		{"time later today", "3pm", "2026-10-27T15:00:00-04:00"},
+		{"time already passed", "9am", "2026-10-28T09:00:00-04:00"},
+		{"24 hour clock", "at 17:45", "2026-10-27T17:45:00-04:00"},
+		{"bare afternoon hour", "at 3", "2026-10-27T15:00:00-04:00"},
+		{"named time", "noon", "2026-10-27T12:00:00-04:00"},
+		{"midnight rolls to tomorrow", "midnight", "2026-10-28T00:00:00-04:00"},
+
+		{"end of month", "oct 31", "2026-10-31T09:00:00-04:00"},
+		{"into next month", "in 5 days", "2026-11-01T10:30:00-05:00"},
+		{"day before month", "3rd of november at 9:30am", "2026-11-03T09:30:00-05:00"},
+		{"month day already passed", "october 1", "2027-10-01T09:00:00-04:00"},
+		{"month day today", "october 27 at 4pm", "2026-10-27T16:00:00-04:00"},
+		{"into next year", "december 31st at 11pm", "2026-12-31T23:00:00-05:00"},
+		{"iso date", "2027-01-05 at 10am", "2027-01-05T10:00:00-05:00"},
+
+		{"on the transition day", "sunday at 10am", "2026-11-01T10:00:00-05:00"},
+		{"repeated hour on the transition", "november 1 at 1:30am", "2026-11-01T01:30:00-04:00"},
+		{"after the repeated hour", "november 1 at 3am", "2026-11-01T03:00:00-05:00"},
+		{"hours across the transition", "in 120 hours", "2026-11-01T09:30:00-05:00"},
+		{"days across the transition", "in 1 week", "2026-11-03T10:30:00-05:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver.Resolve(tt.expression)
+			if err != nil {
+				t.Fatalf("Resolve(%q) failed: %v", tt.expression, err)
+			}
+			if got.Format(time.RFC3339) != tt.want {
+				t.Errorf("Resolve(%q) = %s, want %s", tt.expression, got.Format(time.RFC3339), tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveRejectsUnparseable(t *testing.T) {
+	resolver := testResolver(t)
+
+	tests := []struct {
+		name       string
+		expression string
+	}{
+		{"empty", "   "},
+		{"unknown words", "someday soon"},
+		{"extra words", "tomorrow after lunch"},
+		{"two dates", "tomorrow friday"},
+		{"two times", "3pm at 4pm"},
+		{"impossible day", "february 30"},
+		{"impossible iso date", "2026-02-29"},
+		{"hour out of range", "at 25:00"},
+		{"minute out of range", "at 10:75"},
+		{"meridiem out of range", "13pm"},
+		{"relative without in", "2 hours"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver.Resolve(tt.expression)
+			if !errors.Is(err, ErrUnrecognized) {
+				t.Errorf("Resolve(%q) = %v, %v; want ErrUnrecognized", tt.expression, got, err)
+			}
+		})
+	}
+}
+
+func TestResolveFrom(t *testing.T) {
+	resolver := testResolver(t)
+	ref := time.Date(2026, time.October, 28, 15, 0, 0, 0, resolver.Location())
+
+	tests := []struct {
+		name       string
+		expression string
+		want       string
+	}{
+		{"date keeps the time of day", "friday", "2026-10-30T15:00:00-04:00"},
+		{"time keeps the date", "4:30pm", "2026-10-28T16:30:00-04:00"},
+		{"both replaced", "tomorrow at 10am", "2026-10-28T10:00:00-04:00"},
+		{"keeps the time across the transition", "monday", "2026-11-02T15:00:00-05:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver.ResolveFrom(tt.expression, ref)
+			if err != nil {
+				t.Fatalf("ResolveFrom(%q) failed: %v", tt.expression, err)
+			}
+			if got.Format(time.RFC3339) != tt.want {
+				t.Errorf("ResolveFrom(%q) = %s, want %s", tt.expression, got.Format(time.RFC3339), tt.want)
+			}
+		})
+	}
+}
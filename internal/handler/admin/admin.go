@@ -0,0 +1,115 @@
+package admin
+
+import (
+	"ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/constants/model/response"
+	"ai_agent/internal/handler"
+	"ai_agent/internal/worker"
+	"ai_agent/platform/logger"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+type adminHandler struct {
+	scheduler worker.Scheduler
+	outbox    worker.Outbox
+	// token must be sent as a bearer token; when empty the API is disabled
+	token  string
+	logger logger.Logger
+}
+
+func NewHandler(scheduler worker.Scheduler, outbox worker.Outbox, token string, logger logger.Logger) handler.Admin {
+	return &adminHandler{
+		scheduler: scheduler,
+		outbox:    outbox,
+		token:     token,
+		logger:    logger,
+	}
+}
+
+type JobsResponse struct {
+	Jobs []dto.Job `json:"jobs"`
+}
+
+type JobRunResponse struct {
+	Result string     `json:"result"`
+	Run    dto.JobRun `json:"run"`
+}
+
+// ListJobs lists the scheduled jobs with their last and next runs
+func (h *adminHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(JobsResponse{Jobs: h.scheduler.Jobs(r.Context())})
+}
+
+// RunJob runs a job now and reports the run
+func (h *adminHandler) RunJob(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	name := r.PathValue("name")
+	run, err := h.scheduler.Run(r.Context(), name)
+	if err != nil {
+		h.logger.Error(r.Context(), "Failed to run job", zap.String("job", name), zap.Error(err))
+		response.SendErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(JobRunResponse{Result: fmt.Sprintf("Job %s ran successfully!", name), Run: run})
+}
+
+type OutboxResponse struct {
+	Messages []dto.OutboxMessage `json:"messages"`
+}
+
+// ListOutbox lists queued and recently delivered email with its delivery
+// status, optionally only messages with the status query parameter
+func (h *adminHandler) ListOutbox(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", dto.OutboxQueued, dto.OutboxSending, dto.OutboxRetrying, dto.OutboxSent, dto.OutboxDead:
+	default:
+		response.SendErrorResponse(w, fmt.Errorf("%w: unknown status %q", errors.ErrInvalidInput, status))
+		return
+	}
+
+	messages, err := h.outbox.Messages(r.Context(), status)
+	if err != nil {
+		h.logger.Error(r.Context(), "Failed to list outbox", zap.Error(err))
+		response.SendErrorResponse(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(OutboxResponse{Messages: messages})
+}
+
+// authorize checks the bearer token, answering the request if it is wrong.
+// Without a configured token every request is refused.
+func (h *adminHandler) authorize(w http.ResponseWriter, r *http.Request) bool {
+	if h.token == "" {
+		h.logger.Warn(r.Context(), "Rejected admin request, ADMIN_TOKEN is not set", zap.String("path", r.URL.Path))
+		response.SendErrorResponse(w, fmt.Errorf("%w: the admin API is disabled until ADMIN_TOKEN is set", errors.ErrUnauthorized))
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+h.token)) == 1 {
+		return true
+	}
+	h.logger.Warn(r.Context(), "Rejected admin request", zap.String("path", r.URL.Path))
+	response.SendErrorResponse(w, fmt.Errorf("%w: admin token required", errors.ErrUnauthorized))
+	return false
+}
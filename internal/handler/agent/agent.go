@@ -1,12 +1,19 @@
 package agent
 
 import (
+	"ai_agent/internal/constants/errors"
 	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/constants/model/response"
 	"ai_agent/internal/handler"
 	"ai_agent/internal/service"
 	"ai_agent/platform/logger"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -25,30 +32,94 @@ func NewHandler(service service.AgentService, logger logger.Logger) handler.Agen
 }
 
 type CommandRequest struct {
-	Command string `json:"command"`
+	Command   string `json:"command"`
+	SessionID string `json:"session_id,omitempty"`
+	DryRun    bool   `json:"dry_run,omitempty"`
 }
 
 type CommandResponse struct {
-	Result string `json:"result"`
-	Error  string `json:"error,omitempty"`
+	Result                string          `json:"result"`
+	SessionID             string          `json:"session_id,omitempty"`
+	NeedsClarification    bool            `json:"needs_clarification,omitempty"`
+	Plan                  *dto.ActionPlan `json:"plan,omitempty"`
+	ConfirmationToken     string          `json:"confirmation_token,omitempty"`
+	ConfirmationExpiresAt string          `json:"confirmation_expires_at,omitempty"`
+	Event                 *dto.Event      `json:"event,omitempty"`
+	Outcome               string          `json:"outcome,omitempty"`
+	Conflicts             []dto.Conflict  `json:"conflicts,omitempty"`
+}
+
+type ConfirmRequest struct {
+	ConfirmationToken string `json:"confirmation_token"`
+	// SessionID is the session the action was planned in; empty for actions
+	// planned by /api/schedule and /api/email
+	SessionID string `json:"session_id,omitempty"`
 }
 
 type MeetingRequest struct {
-	Attendees []string `json:"attendees"`
-	StartTime string   `json:"start_time"`
-	Duration  int      `json:"duration_minutes"`
-	Title     string   `json:"title"`
+	Attendees      []string `json:"attendees"`
+	StartTime      string   `json:"start_time"`
+	Duration       int      `json:"duration_minutes"`
+	Title          string   `json:"title"`
+	Description    string   `json:"description,omitempty"`
+	Location       string   `json:"location,omitempty"`
+	Recurrence     []string `json:"recurrence,omitempty"`
+	ConflictPolicy string   `json:"conflict_policy,omitempty"`
+	// Calendar names the calendar to book on; empty uses the default.
+	Calendar string `json:"calendar,omitempty"`
+}
+
+// UpdateEventRequest changes an event. Omitted fields are left unchanged.
+type UpdateEventRequest struct {
+	Title     string   `json:"title,omitempty"`
+	Attendees []string `json:"attendees,omitempty"`
+	StartTime string   `json:"start_time,omitempty"`
+	Duration  int      `json:"duration_minutes,omitempty"`
+}
+
+// RescheduleRequest moves an event. Without duration_minutes it keeps its length.
+type RescheduleRequest struct {
+	StartTime string `json:"start_time"`
+	Duration  int    `json:"duration_minutes,omitempty"`
+}
+
+type EventResponse struct {
+	Event dto.Event `json:"event"`
 }
 
 type EmailRequest struct {
-	ToEmail string `json:"to_email"`
-	Subject string `json:"subject"`
-	Body    string `json:"body,omitempty"`
+	// ToEmail is a single recipient, added to To
+	ToEmail string   `json:"to_email,omitempty"`
+	To      []string `json:"to,omitempty"`
+	Cc      []string `json:"cc,omitempty"`
+	Bcc     []string `json:"bcc,omitempty"`
+	ReplyTo string   `json:"reply_to,omitempty"`
+	Subject string   `json:"subject"`
+	// Body is HTML and Text its plain alternative; either can be sent alone
+	Body        string                `json:"body,omitempty"`
+	Text        string                `json:"text,omitempty"`
+	Headers     map[string]string     `json:"headers,omitempty"`
+	Attachments []dto.EmailAttachment `json:"attachments,omitempty"`
 }
 
 type EventsResponse struct {
-	Events []dto.Event `json:"events"`
-	Error  string      `json:"error,omitempty"`
+	Events     []dto.Event `json:"events"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+type CalendarsResponse struct {
+	Calendars []dto.Calendar `json:"calendars"`
+}
+
+type ImportResponse struct {
+	Result   string             `json:"result"`
+	Imported []dto.Event        `json:"imported"`
+	Skipped  []dto.SkippedEvent `json:"skipped,omitempty"`
+}
+
+type AvailabilityResponse struct {
+	Slots    []dto.Slot `json:"slots"`
+	TimeZone string     `json:"time_zone,omitempty"`
 }
 
 // ProcessCommand handles natural language commands
@@ -56,22 +127,64 @@ func (h *agentHandler) ProcessCommand(w http.ResponseWriter, r *http.Request) {
 	var req CommandRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.logger.Error(r.Context(), "Failed to decode command request", zap.Error(err))
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		response.SendErrorResponse(w, fmt.Errorf("%w: invalid request body", errors.ErrBadRequest))
+		return
+	}
+
+	result, err := h.service.ProcessNaturalLanguageCommand(r.Context(), dto.NaturalLanguageRequest{
+		Command:   req.Command,
+		SessionID: req.SessionID,
+		DryRun:    req.DryRun,
+	})
+	if err != nil {
+		h.logger.Error(r.Context(), "Failed to process command", zap.Error(err))
+		response.SendErrorResponse(w, err)
 		return
 	}
 
-	result, err := h.service.ProcessNaturalLanguageCommand(r.Context(), req.Command)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newCommandResponse(result))
+}
 
-	response := CommandResponse{
-		Result: result,
+// ConfirmCommand runs an action previously returned as a plan
+func (h *agentHandler) ConfirmCommand(w http.ResponseWriter, r *http.Request) {
+	var req ConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error(r.Context(), "Failed to decode confirm request", zap.Error(err))
+		response.SendErrorResponse(w, fmt.Errorf("%w: invalid request body", errors.ErrBadRequest))
+		return
 	}
+
+	result, err := h.service.ConfirmCommand(r.Context(), req.ConfirmationToken, req.SessionID)
 	if err != nil {
-		response.Error = err.Error()
-		h.logger.Error(r.Context(), "Failed to process command", zap.Error(err))
+		h.logger.Error(r.Context(), "Failed to confirm command", zap.Error(err))
+		response.SendErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newCommandResponse(result))
+}
+
+func newCommandResponse(result dto.CommandResult) CommandResponse {
+	resp := CommandResponse{
+		Result:             result.Result,
+		SessionID:          result.SessionID,
+		NeedsClarification: result.NeedsClarification,
+		Plan:               result.Plan,
+		ConfirmationToken:  result.ConfirmationToken,
+	}
+	if !result.ExpiresAt.IsZero() {
+		resp.ConfirmationExpiresAt = result.ExpiresAt.Format(time.RFC3339)
 	}
+	return resp
+}
 
+// sendPlan answers 202 Accepted with an action held for confirmation.
+func sendPlan(w http.ResponseWriter, plan dto.CommandResult) {
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(newCommandResponse(plan))
 }
 
 // ScheduleMeeting handles meeting scheduling requests
@@ -79,83 +192,466 @@ func (h *agentHandler) ScheduleMeeting(w http.ResponseWriter, r *http.Request) {
 	var req MeetingRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.logger.Error(r.Context(), "Failed to decode meeting request", zap.Error(err))
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		response.SendErrorResponse(w, fmt.Errorf("%w: invalid request body", errors.ErrBadRequest))
 		return
 	}
 
 	startTime, err := time.Parse(time.RFC3339, req.StartTime)
 	if err != nil {
 		h.logger.Error(r.Context(), "Failed to parse start time", zap.Error(err))
-		http.Error(w, "Invalid start time format", http.StatusBadRequest)
+		response.SendErrorResponse(w, fmt.Errorf("%w: start_time must be RFC3339", errors.ErrInvalidInput))
+		return
+	}
+	if req.Duration <= 0 {
+		response.SendErrorResponse(w, fmt.Errorf("%w: duration_minutes must be positive", errors.ErrInvalidInput))
 		return
 	}
 
-	duration := time.Duration(req.Duration) * time.Minute
-	err = h.service.ScheduleMeeting(r.Context(), req.Attendees, startTime, duration, req.Title)
+	meeting := dto.Meeting{
+		Title:          req.Title,
+		Description:    req.Description,
+		Location:       req.Location,
+		Attendees:      req.Attendees,
+		StartTime:      startTime,
+		Duration:       time.Duration(req.Duration) * time.Minute,
+		Recurrence:     req.Recurrence,
+		ConflictPolicy: req.ConflictPolicy,
+		Calendar:       req.Calendar,
+	}
 
-	response := CommandResponse{}
+	// Meetings that must be confirmed are only planned here
+	plan, planned, err := h.service.PlanMeeting(r.Context(), meeting)
+	if err != nil {
+		h.logger.Error(r.Context(), "Failed to plan meeting", zap.Error(err))
+		response.SendErrorResponse(w, err)
+		return
+	}
+	if planned {
+		sendPlan(w, plan)
+		return
+	}
+
+	result, err := h.service.ScheduleMeeting(r.Context(), meeting)
 	if err != nil {
-		response.Error = err.Error()
 		h.logger.Error(r.Context(), "Failed to schedule meeting", zap.Error(err))
-	} else {
-		response.Result = "Meeting scheduled successfully!"
+		response.SendErrorResponse(w, err)
+		return
+	}
+
+	message := "Meeting scheduled successfully!"
+	if result.Outcome == dto.OutcomeScheduledWithConflicts {
+		message = "Meeting scheduled, but it conflicts with existing events."
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CommandResponse{
+		Result:    message,
+		Event:     &result.Event,
+		Outcome:   result.Outcome,
+		Conflicts: result.Conflicts,
+	})
+}
+
+// GetEvent retrieves a single event
+func (h *agentHandler) GetEvent(w http.ResponseWriter, r *http.Request) {
+	event, err := h.service.GetEvent(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.logger.Error(r.Context(), "Failed to get event", zap.Error(err))
+		response.SendErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(EventResponse{Event: event})
+}
+
+// UpdateEvent changes an event's title, attendees or time
+func (h *agentHandler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
+	var req UpdateEventRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error(r.Context(), "Failed to decode update event request", zap.Error(err))
+		response.SendErrorResponse(w, fmt.Errorf("%w: invalid request body", errors.ErrBadRequest))
+		return
+	}
+
+	update := dto.EventUpdate{
+		Title:     req.Title,
+		Attendees: req.Attendees,
+		Duration:  time.Duration(req.Duration) * time.Minute,
+	}
+	if req.StartTime != "" {
+		startTime, err := time.Parse(time.RFC3339, req.StartTime)
+		if err != nil {
+			h.logger.Error(r.Context(), "Failed to parse start time", zap.Error(err))
+			response.SendErrorResponse(w, fmt.Errorf("%w: start_time must be RFC3339", errors.ErrInvalidInput))
+			return
+		}
+		update.StartTime = startTime
+	}
+
+	// Updates that must be confirmed are only planned here
+	plan, planned, err := h.service.PlanUpdateEvent(r.Context(), r.PathValue("id"), update)
+	if err != nil {
+		h.logger.Error(r.Context(), "Failed to plan event update", zap.Error(err))
+		response.SendErrorResponse(w, err)
+		return
+	}
+	if planned {
+		sendPlan(w, plan)
+		return
+	}
+
+	event, err := h.service.UpdateEvent(r.Context(), r.PathValue("id"), update)
+	if err != nil {
+		h.logger.Error(r.Context(), "Failed to update event", zap.Error(err))
+		response.SendErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CommandResponse{Result: "Meeting updated successfully!", Event: &event})
+}
+
+// RescheduleMeeting moves an event to a new time
+func (h *agentHandler) RescheduleMeeting(w http.ResponseWriter, r *http.Request) {
+	var req RescheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error(r.Context(), "Failed to decode reschedule request", zap.Error(err))
+		response.SendErrorResponse(w, fmt.Errorf("%w: invalid request body", errors.ErrBadRequest))
+		return
+	}
+
+	startTime, err := time.Parse(time.RFC3339, req.StartTime)
+	if err != nil {
+		h.logger.Error(r.Context(), "Failed to parse start time", zap.Error(err))
+		response.SendErrorResponse(w, fmt.Errorf("%w: start_time must be RFC3339", errors.ErrInvalidInput))
+		return
+	}
+
+	duration := time.Duration(req.Duration) * time.Minute
+
+	// Moves that must be confirmed are only planned here
+	plan, planned, err := h.service.PlanRescheduleMeeting(r.Context(), r.PathValue("id"), startTime, duration)
+	if err != nil {
+		h.logger.Error(r.Context(), "Failed to plan reschedule", zap.Error(err))
+		response.SendErrorResponse(w, err)
+		return
+	}
+	if planned {
+		sendPlan(w, plan)
+		return
+	}
+
+	event, err := h.service.RescheduleMeeting(r.Context(), r.PathValue("id"), startTime, duration)
+	if err != nil {
+		h.logger.Error(r.Context(), "Failed to reschedule meeting", zap.Error(err))
+		response.SendErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CommandResponse{Result: "Meeting rescheduled successfully!", Event: &event})
+}
+
+// CancelMeeting cancels an event
+func (h *agentHandler) CancelMeeting(w http.ResponseWriter, r *http.Request) {
+	// Cancellations that must be confirmed are only planned here
+	plan, planned, err := h.service.PlanCancelMeeting(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.logger.Error(r.Context(), "Failed to plan cancellation", zap.Error(err))
+		response.SendErrorResponse(w, err)
+		return
+	}
+	if planned {
+		sendPlan(w, plan)
+		return
+	}
+
+	err = h.service.CancelMeeting(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.logger.Error(r.Context(), "Failed to cancel meeting", zap.Error(err))
+		response.SendErrorResponse(w, err)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(CommandResponse{Result: "Meeting cancelled successfully!"})
 }
 
+// maxEmailBytes limits the size of an email request, leaving room for the
+// base64 encoding of its attachments.
+const maxEmailBytes = dto.MaxAttachmentBytes/3*4 + 1<<20
+
 // SendEmail handles email sending requests
 func (h *agentHandler) SendEmail(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxEmailBytes)
+
 	var req EmailRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.logger.Error(r.Context(), "Failed to decode email request", zap.Error(err))
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		response.SendErrorResponse(w, fmt.Errorf("%w: invalid request body", errors.ErrBadRequest))
 		return
 	}
 
-	err := h.service.SendEmail(r.Context(), req.ToEmail, req.Subject, req.Body)
+	message := dto.EmailMessage{
+		To:          req.To,
+		Cc:          req.Cc,
+		Bcc:         req.Bcc,
+		ReplyTo:     req.ReplyTo,
+		Subject:     req.Subject,
+		Text:        req.Text,
+		HTML:        req.Body,
+		Headers:     req.Headers,
+		Attachments: req.Attachments,
+	}
+	if req.ToEmail != "" {
+		message.To = append([]string{req.ToEmail}, message.To...)
+	}
 
-	response := CommandResponse{}
+	// Email that must be confirmed is only planned here
+	plan, planned, err := h.service.PlanEmail(r.Context(), message)
+	if err != nil {
+		h.logger.Error(r.Context(), "Failed to plan email", zap.Error(err))
+		response.SendErrorResponse(w, err)
+		return
+	}
+	if planned {
+		sendPlan(w, plan)
+		return
+	}
+
+	err = h.service.SendEmail(r.Context(), message)
 	if err != nil {
-		response.Error = err.Error()
 		h.logger.Error(r.Context(), "Failed to send email", zap.Error(err))
-	} else {
-		response.Result = "Email sent successfully!"
+		response.SendErrorResponse(w, err)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(CommandResponse{Result: "Email queued for delivery!"})
 }
 
-// GetEvents retrieves upcoming events
+// GetEvents lists events, by default those of the next seven days
 func (h *agentHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
-	events, err := h.service.GetUpcomingEvents(r.Context())
-
-	response := EventsResponse{
-		Events: events,
+	query, err := parseEventQuery(r.URL.Query())
+	if err != nil {
+		h.logger.Error(r.Context(), "Failed to parse events query", zap.Error(err))
+		response.SendErrorResponse(w, err)
+		return
 	}
+
+	page, err := h.service.ListEvents(r.Context(), query)
 	if err != nil {
-		response.Error = err.Error()
 		h.logger.Error(r.Context(), "Failed to get events", zap.Error(err))
+		response.SendErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(EventsResponse{Events: page.Events, NextCursor: page.NextCursor})
+}
+
+// GetCalendars lists the configured calendars and their access
+func (h *agentHandler) GetCalendars(w http.ResponseWriter, r *http.Request) {
+	calendars := h.service.ListCalendars(r.Context())
+	if calendars == nil {
+		calendars = []dto.Calendar{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CalendarsResponse{Calendars: calendars})
+}
+
+func parseEventQuery(query url.Values) (dto.EventQuery, error) {
+	req := dto.EventQuery{
+		Query:    strings.TrimSpace(query.Get("q")),
+		Attendee: strings.TrimSpace(query.Get("attendee")),
+		Calendar: strings.TrimSpace(query.Get("calendar")),
+		Cursor:   query.Get("cursor"),
+	}
+
+	times := map[string]*time.Time{
+		"from": &req.From,
+		"to":   &req.To,
+	}
+	for name, target := range times {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return dto.EventQuery{}, fmt.Errorf("%w: %s must be RFC3339", errors.ErrInvalidInput, name)
+		}
+		*target = t
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return dto.EventQuery{}, fmt.Errorf("%w: limit must be a positive number", errors.ErrInvalidInput)
+		}
+		req.Limit = limit
+	}
+	return req, nil
+}
+
+// maxImportBytes limits the size of an uploaded iCalendar file.
+const maxImportBytes = 5 << 20
+
+// ExportEvents serves the upcoming events as an iCalendar feed that calendar
+// applications can subscribe to
+func (h *agentHandler) ExportEvents(w http.ResponseWriter, r *http.Request) {
+	data, err := h.service.ExportEvents(r.Context())
+	if err != nil {
+		h.logger.Error(r.Context(), "Failed to export events", zap.Error(err))
+		response.SendErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="calendar.ics"`)
+	w.Write(data)
+}
+
+// ImportEvents adds the events of an iCalendar file, sent either as the
+// request body or as the "file" field of a multipart form
+func (h *agentHandler) ImportEvents(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+
+	body := io.Reader(r.Body)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			h.logger.Error(r.Context(), "Failed to read uploaded calendar", zap.Error(err))
+			response.SendErrorResponse(w, fmt.Errorf("%w: expected an iCalendar file in the \"file\" field", errors.ErrBadRequest))
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	result, err := h.service.ImportEvents(r.Context(), body)
+	if err != nil {
+		h.logger.Error(r.Context(), "Failed to import events", zap.Error(err))
+		response.SendErrorResponse(w, err)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(ImportResponse{
+		Result:   fmt.Sprintf("Imported %d events, skipped %d.", len(result.Imported), len(result.Skipped)),
+		Imported: result.Imported,
+		Skipped:  result.Skipped,
+	})
+}
+
+// GetAvailability finds times when the user and the given attendees are free
+func (h *agentHandler) GetAvailability(w http.ResponseWriter, r *http.Request) {
+	req, err := parseAvailabilityQuery(r.URL.Query())
+	if err != nil {
+		h.logger.Error(r.Context(), "Failed to parse availability query", zap.Error(err))
+		response.SendErrorResponse(w, err)
+		return
+	}
+
+	slots, err := h.service.FindAvailability(r.Context(), req)
+	if err != nil {
+		h.logger.Error(r.Context(), "Failed to find availability", zap.Error(err))
+		response.SendErrorResponse(w, err)
+		return
+	}
+
+	resp := AvailabilityResponse{Slots: slots}
+	if len(slots) > 0 {
+		resp.TimeZone = slots[0].Start.Location().String()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// parseAvailabilityQuery reads an availability search from query parameters.
+// Attendees are comma separated, durations are in minutes and from/to are
+// RFC3339 timestamps.
+func parseAvailabilityQuery(query url.Values) (dto.AvailabilityRequest, error) {
+	req := dto.AvailabilityRequest{
+		WorkdayStart:    query.Get("workday_start"),
+		WorkdayEnd:      query.Get("workday_end"),
+		IncludeWeekends: query.Get("include_weekends") == "true",
+	}
+	for _, attendee := range strings.Split(query.Get("attendees"), ",") {
+		if attendee = strings.TrimSpace(attendee); attendee != "" {
+			req.Attendees = append(req.Attendees, attendee)
+		}
+	}
+
+	minutes := map[string]*time.Duration{
+		"duration_minutes":      &req.Duration,
+		"buffer_before_minutes": &req.BufferBefore,
+		"buffer_after_minutes":  &req.BufferAfter,
+	}
+	for name, target := range minutes {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return dto.AvailabilityRequest{}, fmt.Errorf("%w: %s must be a non-negative number of minutes", errors.ErrInvalidInput, name)
+		}
+		*target = time.Duration(n) * time.Minute
+	}
+	if req.Duration == 0 {
+		req.Duration = 30 * time.Minute
+	}
+
+	times := map[string]*time.Time{
+		"from": &req.WindowStart,
+		"to":   &req.WindowEnd,
+	}
+	for name, target := range times {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return dto.AvailabilityRequest{}, fmt.Errorf("%w: %s must be RFC3339", errors.ErrInvalidInput, name)
+		}
+		*target = t
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return dto.AvailabilityRequest{}, fmt.Errorf("%w: limit must be a positive number", errors.ErrInvalidInput)
+		}
+		req.Limit = limit
+	}
+	return req, nil
 }
 
 // SendDailyReminder triggers a daily reminder
 func (h *agentHandler) SendDailyReminder(w http.ResponseWriter, r *http.Request) {
-	err := h.service.SendDailyReminder(r.Context())
+	// Reminders that must be confirmed are only planned here
+	plan, planned, err := h.service.PlanDailyReminder(r.Context())
+	if err != nil {
+		h.logger.Error(r.Context(), "Failed to plan daily reminder", zap.Error(err))
+		response.SendErrorResponse(w, err)
+		return
+	}
+	if planned {
+		sendPlan(w, plan)
+		return
+	}
 
-	response := CommandResponse{}
+	err = h.service.SendDailyReminder(r.Context())
 	if err != nil {
-		response.Error = err.Error()
 		h.logger.Error(r.Context(), "Failed to send daily reminder", zap.Error(err))
-	} else {
-		response.Result = "Daily reminder sent successfully!"
+		response.SendErrorResponse(w, err)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(CommandResponse{Result: "Daily reminder sent successfully!"})
 }
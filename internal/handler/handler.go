@@ -4,8 +4,30 @@ import "net/http"
 
 type Agent interface {
 	ProcessCommand(w http.ResponseWriter, r *http.Request)
+	ConfirmCommand(w http.ResponseWriter, r *http.Request)
 	ScheduleMeeting(w http.ResponseWriter, r *http.Request)
 	SendEmail(w http.ResponseWriter, r *http.Request)
 	GetEvents(w http.ResponseWriter, r *http.Request)
+	GetCalendars(w http.ResponseWriter, r *http.Request)
+	ExportEvents(w http.ResponseWriter, r *http.Request)
+	ImportEvents(w http.ResponseWriter, r *http.Request)
+	GetAvailability(w http.ResponseWriter, r *http.Request)
+	GetEvent(w http.ResponseWriter, r *http.Request)
+	UpdateEvent(w http.ResponseWriter, r *http.Request)
+	RescheduleMeeting(w http.ResponseWriter, r *http.Request)
+	CancelMeeting(w http.ResponseWriter, r *http.Request)
 	SendDailyReminder(w http.ResponseWriter, r *http.Request)
 }
+
+// OAuth handles the consent flow that connects a Google account.
+type OAuth interface {
+	Start(w http.ResponseWriter, r *http.Request)
+	Callback(w http.ResponseWriter, r *http.Request)
+}
+
+// Admin inspects and triggers the scheduled jobs and reports email delivery.
+type Admin interface {
+	ListJobs(w http.ResponseWriter, r *http.Request)
+	RunJob(w http.ResponseWriter, r *http.Request)
+	ListOutbox(w http.ResponseWriter, r *http.Request)
+}
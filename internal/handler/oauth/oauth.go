@@ -0,0 +1,100 @@
+package oauth
+
+import (
+	"ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/response"
+	"ai_agent/internal/handler"
+	"ai_agent/platform"
+	"ai_agent/platform/logger"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// stateTTL is how long the user has to complete the consent page.
+const stateTTL = 10 * time.Minute
+
+type oauthHandler struct {
+	oauth  platform.OAuth
+	logger logger.Logger
+
+	mu sync.Mutex
+	// states holds the expiry of each state issued by Start, which guards
+	// the callback against forged redirects
+	states map[string]time.Time
+}
+
+func NewHandler(oauth platform.OAuth, logger logger.Logger) handler.OAuth {
+	return &oauthHandler{
+		oauth:  oauth,
+		logger: logger,
+		states: make(map[string]time.Time),
+	}
+}
+
+// Start redirects to the consent page.
+func (h *oauthHandler) Start(w http.ResponseWriter, r *http.Request) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		response.SendErrorResponse(w, fmt.Errorf("%w: failed to generate state", errors.ErrUnexpected))
+		return
+	}
+	state := hex.EncodeToString(buf)
+
+	h.mu.Lock()
+	now := time.Now()
+	for s, expiry := range h.states {
+		if now.After(expiry) {
+			delete(h.states, s)
+		}
+	}
+	h.states[state] = now.Add(stateTTL)
+	h.mu.Unlock()
+
+	http.Redirect(w, r, h.oauth.AuthCodeURL(state), http.StatusFound)
+}
+
+// Callback completes the consent flow with the code from the redirect.
+func (h *oauthHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if !h.consumeState(query.Get("state")) {
+		response.SendErrorResponse(w, fmt.Errorf("%w: unknown or expired OAuth state, start again at /oauth/google/start", errors.ErrBadRequest))
+		return
+	}
+	if reason := query.Get("error"); reason != "" {
+		h.logger.Warn(r.Context(), "Google consent was not granted", zap.String("error", reason))
+		response.SendErrorResponse(w, fmt.Errorf("%w: consent was not granted: %s", errors.ErrUnauthorized, reason))
+		return
+	}
+	code := query.Get("code")
+	if code == "" {
+		response.SendErrorResponse(w, fmt.Errorf("%w: missing authorization code", errors.ErrBadRequest))
+		return
+	}
+
+	if err := h.oauth.Exchange(r.Context(), code); err != nil {
+		response.SendErrorResponse(w, err)
+		return
+	}
+	response.SendSuccessResponse(w, http.StatusOK, "Google account connected")
+}
+
+// consumeState reports whether state was issued and has not expired, and
+// forgets it so it cannot be replayed.
+func (h *oauthHandler) consumeState(state string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	expiry, ok := h.states[state]
+	if !ok {
+		return false
+	}
+	delete(h.states, state)
+	return time.Now().Before(expiry)
+}
@@ -0,0 +1,382 @@
+package ical
+
+import (
+	"ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCalendar is returned for data that is not an iCalendar file.
+var ErrInvalidCalendar = fmt.Errorf("%w: invalid iCalendar data", errors.ErrInvalidInput)
+
+// property is a content line: NAME;PARAM=value:VALUE.
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// component is a BEGIN/END block such as VEVENT, with its nested blocks.
+type component struct {
+	name       string
+	properties []property
+	children   []*component
+}
+
+func (c *component) get(name string) (property, bool) {
+	for _, p := range c.properties {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return property{}, false
+}
+
+func (c *component) all(name string) []property {
+	var props []property
+	for _, p := range c.properties {
+		if p.name == name {
+			props = append(props, p)
+		}
+	}
+	return props
+}
+
+// Decode reads the VEVENTs of an iCalendar file. Floating and date-only times
+// are read in loc. Modified occurrences of a recurring event (those with a
+// RECURRENCE-ID) become separate events with their OriginalStartTime set and
+// are excluded from their series. Cancelled events are left out, and a
+// cancelled occurrence is excluded from its series.
+func Decode(r io.Reader, loc *time.Location) ([]dto.Event, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	root, err := parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []dto.Event
+	for _, cal := range root.children {
+		if cal.name != "VCALENDAR" {
+			continue
+		}
+		zones := map[string]*vtimezone{}
+		for _, child := range cal.children {
+			if child.name == "VTIMEZONE" {
+				if tz, ok := parseTimezone(child); ok {
+					zones[tz.id] = tz
+				}
+			}
+		}
+		decoded, err := decodeEvents(cal, zones, loc)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, decoded...)
+	}
+	return events, nil
+}
+
+func decodeEvents(cal *component, zones map[string]*vtimezone, loc *time.Location) ([]dto.Event, error) {
+	var events []dto.Event
+	var overrides []dto.Event
+	index := map[string]int{}
+
+	for _, c := range cal.children {
+		if c.name != "VEVENT" {
+			continue
+		}
+
+		startProp, ok := c.get("DTSTART")
+		if !ok {
+			continue
+		}
+		start, isDate, err := resolveTime(startProp, zones, loc)
+		if err != nil {
+			return nil, err
+		}
+
+		end := start.Add(time.Hour)
+		if isDate {
+			end = start.AddDate(0, 0, 1)
+		}
+		if endProp, ok := c.get("DTEND"); ok {
+			if end, _, err = resolveTime(endProp, zones, loc); err != nil {
+				return nil, err
+			}
+		} else if durationProp, ok := c.get("DURATION"); ok {
+			duration, err := parseDuration(durationProp.value)
+			if err != nil {
+				return nil, err
+			}
+			end = start.Add(duration)
+			if isDate && duration%(24*time.Hour) == 0 {
+				// Days are calendar days, which DST can shorten or lengthen
+				end = start.AddDate(0, 0, int(duration/(24*time.Hour)))
+			}
+		}
+
+		uid, _ := c.get("UID")
+		summary, _ := c.get("SUMMARY")
+		description, _ := c.get("DESCRIPTION")
+		location, _ := c.get("LOCATION")
+		event := dto.Event{
+			ID:          unescapeText(uid.value),
+			Title:       unescapeText(summary.value),
+			Description: unescapeText(description.value),
+			Location:    unescapeText(location.value),
+			StartTime:   start,
+			EndTime:     end,
+			AllDay:      isDate,
+		}
+		if status, ok := c.get("STATUS"); ok {
+			for name, value := range statuses {
+				if strings.EqualFold(status.value, value) {
+					event.Status = name
+				}
+			}
+		}
+		for _, name := range []string{"CONFERENCE", "X-GOOGLE-CONFERENCE"} {
+			if conference, ok := c.get(name); ok && event.ConferenceURL == "" {
+				event.ConferenceURL = strings.TrimSpace(conference.value)
+			}
+		}
+		if organizer, ok := c.get("ORGANIZER"); ok {
+			event.Organizer = mailto(organizer.value)
+		}
+		for _, attendee := range c.all("ATTENDEE") {
+			email := mailto(attendee.value)
+			event.Attendees = append(event.Attendees, email)
+			for response, partStat := range partStats {
+				if strings.EqualFold(attendee.params["PARTSTAT"], partStat) {
+					if event.Responses == nil {
+						event.Responses = map[string]string{}
+					}
+					event.Responses[email] = response
+				}
+			}
+		}
+		for _, rule := range c.all("RRULE") {
+			event.Recurrence = append(event.Recurrence, "RRULE:"+rule.value)
+		}
+		for _, exdate := range c.all("EXDATE") {
+			lines, err := normalizeExDates(exdate, zones, loc)
+			if err != nil {
+				return nil, err
+			}
+			event.Recurrence = append(event.Recurrence, lines...)
+		}
+
+		if recurrenceID, ok := c.get("RECURRENCE-ID"); ok {
+			original, _, err := resolveTime(recurrenceID, zones, loc)
+			if err != nil {
+				return nil, err
+			}
+			event.RecurringEventID = event.ID
+			event.ID += "_" + original.UTC().Format(utcDateTimeFormat)
+			event.OriginalStartTime = original
+			event.Recurrence = nil
+			overrides = append(overrides, event)
+			continue
+		}
+		if event.Status == dto.EventCancelled {
+			continue
+		}
+
+		index[event.ID] = len(events)
+		events = append(events, event)
+	}
+
+	// A modified occurrence replaces the one its series would have generated
+	for _, override := range overrides {
+		if master, ok := index[override.RecurringEventID]; ok {
+			events[master].Recurrence = append(events[master].Recurrence, exDate(override.OriginalStartTime))
+		}
+		if override.Status != dto.EventCancelled {
+			events = append(events, override)
+		}
+	}
+	return events, nil
+}
+
+// parse reads the component tree of an iCalendar stream, unfolding lines.
+func parse(r io.Reader) (*component, error) {
+	root := &component{}
+	stack := []*component{root}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCalendar, err)
+	}
+
+	for _, line := range lines {
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+		current := stack[len(stack)-1]
+		switch prop.name {
+		case "BEGIN":
+			child := &component{name: strings.ToUpper(prop.value)}
+			current.children = append(current.children, child)
+			stack = append(stack, child)
+		case "END":
+			if len(stack) == 1 || current.name != strings.ToUpper(prop.value) {
+				return nil, fmt.Errorf("%w: unexpected END:%s", ErrInvalidCalendar, prop.value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			current.properties = append(current.properties, prop)
+		}
+	}
+
+	if len(stack) != 1 {
+		return nil, fmt.Errorf("%w: %s is not closed", ErrInvalidCalendar, stack[len(stack)-1].name)
+	}
+	if len(root.children) == 0 || root.children[0].name != "VCALENDAR" {
+		return nil, fmt.Errorf("%w: missing VCALENDAR", ErrInvalidCalendar)
+	}
+	return root, nil
+}
+
+// parseLine splits a content line into its name, parameters and value,
+// honouring quoted parameter values that contain ':' or ';'.
+func parseLine(line string) (property, error) {
+	var parts []string
+	quoted := false
+	valueAt := -1
+	partStart := 0
+	for i := 0; i < len(line) && valueAt < 0; i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ';', ':':
+			if quoted {
+				continue
+			}
+			parts = append(parts, line[partStart:i])
+			partStart = i + 1
+			if line[i] == ':' {
+				valueAt = i + 1
+			}
+		}
+	}
+	if valueAt < 0 || parts[0] == "" {
+		return property{}, fmt.Errorf("%w: malformed line %q", ErrInvalidCalendar, line)
+	}
+
+	prop := property{name: strings.ToUpper(parts[0]), params: map[string]string{}, value: line[valueAt:]}
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	return prop, nil
+}
+
+// resolveTime reads a DATE or DATE-TIME property. Zoned times use the IANA
+// zone named by TZID or else the file's VTIMEZONE of that name; floating and
+// date-only times are read in loc.
+func resolveTime(prop property, zones map[string]*vtimezone, loc *time.Location) (time.Time, bool, error) {
+	value := strings.TrimSpace(prop.value)
+	invalid := fmt.Errorf("%w: invalid %s %q", ErrInvalidCalendar, prop.name, value)
+
+	if strings.EqualFold(prop.params["VALUE"], "DATE") || len(value) == len(dateFormat) {
+		t, err := time.ParseInLocation(dateFormat, value, loc)
+		if err != nil {
+			return time.Time{}, false, invalid
+		}
+		return t, true, nil
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(utcDateTimeFormat, value)
+		if err != nil {
+			return time.Time{}, false, invalid
+		}
+		return t, false, nil
+	}
+
+	if tzid := prop.params["TZID"]; tzid != "" {
+		if zoneLoc, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil {
+			loc = zoneLoc
+		} else if tz, ok := zones[tzid]; ok {
+			wall, err := time.Parse(dateTimeFormat, value)
+			if err != nil {
+				return time.Time{}, false, invalid
+			}
+			return tz.at(wall), false, nil
+		}
+	}
+	t, err := time.ParseInLocation(dateTimeFormat, value, loc)
+	if err != nil {
+		return time.Time{}, false, invalid
+	}
+	return t, false, nil
+}
+
+// normalizeExDates rewrites an EXDATE property as lines in UTC, or as dates,
+// so they can be read without the file's VTIMEZONEs.
+func normalizeExDates(prop property, zones map[string]*vtimezone, loc *time.Location) ([]string, error) {
+	var lines []string
+	for _, value := range strings.Split(prop.value, ",") {
+		t, isDate, err := resolveTime(property{name: prop.name, params: prop.params, value: value}, zones, loc)
+		if err != nil {
+			return nil, err
+		}
+		if isDate {
+			lines = append(lines, "EXDATE;VALUE=DATE:"+t.Format(dateFormat))
+		} else {
+			lines = append(lines, exDate(t))
+		}
+	}
+	return lines, nil
+}
+
+var durationRe = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseDuration parses an RFC 5545 DURATION such as PT1H30M or P1D.
+func parseDuration(value string) (time.Duration, error) {
+	m := durationRe.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil || value == "P" || strings.HasSuffix(value, "T") {
+		return 0, fmt.Errorf("%w: invalid DURATION %q", ErrInvalidCalendar, value)
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+2] != "" {
+			n, _ := strconv.Atoi(m[i+2])
+			d += time.Duration(n) * unit
+		}
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+// mailto strips the scheme from a CAL-ADDRESS.
+func mailto(value string) string {
+	if len(value) >= 7 && strings.EqualFold(value[:7], "mailto:") {
+		return value[7:]
+	}
+	return value
+}
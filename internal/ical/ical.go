@@ -0,0 +1,282 @@
+// Package ical reads and writes iCalendar (RFC 5545) files, so events can be
+// exchanged with other calendar applications and subscribed to as a feed.
+//
+// Events are written as VEVENTs with their attendees and their responses,
+// organizer, recurrence, location and video call link, and times are given in the configured zone with a matching
+// VTIMEZONE. When reading, times in zones Go does not know by name are
+// converted using the file's own VTIMEZONE definitions.
+package ical
+
+import (
+	"ai_agent/internal/constants/model/dto"
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	prodID = "-//AI Executive Assistant//EN"
+
+	dateTimeFormat    = "20060102T150405"
+	utcDateTimeFormat = "20060102T150405Z"
+	dateFormat        = "20060102"
+
+	// maxLineOctets is the longest a content line may be before folding.
+	maxLineOctets = 75
+)
+
+// Options control how a calendar is written.
+type Options struct {
+	// Name is shown by clients subscribing to the feed.
+	Name string
+	// Method is the METHOD property, such as PUBLISH for a feed. Objects
+	// stored on a CalDAV server must not have one.
+	Method string
+	// Location is the zone times are written in. Nil or UTC writes UTC times
+	// without a VTIMEZONE.
+	Location *time.Location
+	// Now stamps the events; zero uses time.Now.
+	Now time.Time
+}
+
+// Encode writes events as an iCalendar file. An occurrence of a recurring
+// event in events, with its OriginalStartTime set, is written as an override
+// of that occurrence rather than as an event of its own.
+func Encode(w io.Writer, events []dto.Event, opts Options) error {
+	loc := opts.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeFolded(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", prodID)
+	line("CALSCALE", "GREGORIAN")
+	if opts.Method != "" {
+		line("METHOD", opts.Method)
+	}
+	if opts.Name != "" {
+		line("X-WR-CALNAME", escapeText(opts.Name))
+	}
+	if loc != time.UTC {
+		line("X-WR-TIMEZONE", loc.String())
+		writeTimezone(bw, loc, events)
+	}
+
+	// An override replaces its occurrence, so the EXDATE that excluded the
+	// occurrence from the series is left out
+	series := map[string]bool{}
+	for _, event := range events {
+		series[event.ID] = len(event.Recurrence) > 0
+	}
+	replaced := map[string]bool{}
+	for _, event := range events {
+		if isOverride(event, series) {
+			replaced[event.RecurringEventID+" "+exDate(event.OriginalStartTime)] = true
+		}
+	}
+
+	for _, event := range events {
+		line("BEGIN", "VEVENT")
+		if isOverride(event, series) {
+			line("UID", escapeText(event.RecurringEventID))
+			writeFolded(bw, "RECURRENCE-ID"+formatTime(event.OriginalStartTime, loc, event.AllDay))
+		} else {
+			line("UID", escapeText(event.ID))
+		}
+		line("DTSTAMP", now.UTC().Format(utcDateTimeFormat))
+		writeFolded(bw, "DTSTART"+formatTime(event.StartTime, loc, event.AllDay))
+		writeFolded(bw, "DTEND"+formatTime(event.EndTime, loc, event.AllDay))
+		line("SUMMARY", escapeText(event.Title))
+		if event.Description != "" {
+			line("DESCRIPTION", escapeText(event.Description))
+		}
+		if event.Location != "" {
+			line("LOCATION", escapeText(event.Location))
+		}
+		if status := statuses[event.Status]; status != "" {
+			line("STATUS", status)
+		}
+		if event.ConferenceURL != "" {
+			writeFolded(bw, "CONFERENCE;VALUE=URI;FEATURE=VIDEO:"+event.ConferenceURL)
+		}
+		if event.Organizer != "" {
+			line("ORGANIZER", "mailto:"+event.Organizer)
+		}
+		for _, attendee := range event.Attendees {
+			partStat := "NEEDS-ACTION"
+			if response, ok := partStats[event.Responses[attendee]]; ok {
+				partStat = response
+			}
+			writeFolded(bw, "ATTENDEE;ROLE=REQ-PARTICIPANT;PARTSTAT="+partStat+";RSVP=TRUE:mailto:"+attendee)
+		}
+		// Recurrence lines are already in iCalendar form
+		for _, rule := range event.Recurrence {
+			if !replaced[event.ID+" "+rule] {
+				writeFolded(bw, rule)
+			}
+		}
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+func isOverride(event dto.Event, series map[string]bool) bool {
+	return series[event.RecurringEventID] && !event.OriginalStartTime.IsZero()
+}
+
+// exDate is the EXDATE line excluding the occurrence at t, in the form
+// recurrence.Exclude and Decode write.
+func exDate(t time.Time) string {
+	return "EXDATE:" + t.UTC().Format(utcDateTimeFormat)
+}
+
+// statuses maps event statuses to STATUS values.
+var statuses = map[string]string{
+	dto.EventConfirmed: "CONFIRMED",
+	dto.EventTentative: "TENTATIVE",
+	dto.EventCancelled: "CANCELLED",
+}
+
+// partStats maps attendee responses to PARTSTAT values.
+var partStats = map[string]string{
+	dto.ResponseNeedsAction: "NEEDS-ACTION",
+	dto.ResponseAccepted:    "ACCEPTED",
+	dto.ResponseDeclined:    "DECLINED",
+	dto.ResponseTentative:   "TENTATIVE",
+}
+
+// formatTime renders the parameters and value of a DTSTART or DTEND property,
+// such as ";TZID=Europe/London:20240115T100000", ":20240115T100000Z" or, for
+// all-day events, ";VALUE=DATE:20240115".
+func formatTime(t time.Time, loc *time.Location, allDay bool) string {
+	if allDay {
+		return ";VALUE=DATE:" + t.In(loc).Format(dateFormat)
+	}
+	if loc == time.UTC {
+		return ":" + t.UTC().Format(utcDateTimeFormat)
+	}
+	return ";TZID=" + loc.String() + ":" + t.In(loc).Format(dateTimeFormat)
+}
+
+// writeFolded writes a content line, folding it after 75 octets without
+// splitting a UTF-8 sequence.
+func writeFolded(w *bufio.Writer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts
+		limit = maxLineOctets - 1
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escapeText escapes a TEXT value.
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// unescapeText reverses escapeText.
+func unescapeText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// writeTimezone writes a VTIMEZONE for loc with every transition between the
+// year before the first event and the year after the last.
+func writeTimezone(w *bufio.Writer, loc *time.Location, events []dto.Event) {
+	from, to := time.Now(), time.Now()
+	for _, event := range events {
+		if event.StartTime.Before(from) {
+			from = event.StartTime
+		}
+		if event.EndTime.After(to) {
+			to = event.EndTime
+		}
+	}
+	from = time.Date(from.Year()-1, 1, 1, 0, 0, 0, 0, loc)
+	to = time.Date(to.Year()+2, 1, 1, 0, 0, 0, 0, loc)
+
+	writeFolded(w, "BEGIN:VTIMEZONE")
+	writeFolded(w, "TZID:"+loc.String())
+
+	name, offset := from.Zone()
+	transitions := 0
+	for t := from; t.Before(to); {
+		_, end := t.ZoneBounds()
+		if end.IsZero() || !end.Before(to) {
+			break
+		}
+		nextName, nextOffset := end.Zone()
+		kind := "STANDARD"
+		if end.IsDST() {
+			kind = "DAYLIGHT"
+		}
+		writeObservance(w, kind, end.In(time.FixedZone("", offset)), offset, nextOffset, nextName)
+		transitions++
+		name, offset, t = nextName, nextOffset, end
+	}
+	if transitions == 0 {
+		// A zone without transitions in range still needs one observance
+		writeObservance(w, "STANDARD", time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), offset, offset, name)
+	}
+
+	writeFolded(w, "END:VTIMEZONE")
+}
+
+// writeObservance writes a STANDARD or DAYLIGHT component. DTSTART is the
+// local time of the transition in the offset it changes from.
+func writeObservance(w *bufio.Writer, kind string, start time.Time, from, to int, name string) {
+	writeFolded(w, "BEGIN:"+kind)
+	writeFolded(w, "DTSTART:"+start.Format(dateTimeFormat))
+	writeFolded(w, "TZOFFSETFROM:"+formatOffset(from))
+	writeFolded(w, "TZOFFSETTO:"+formatOffset(to))
+	if name != "" {
+		writeFolded(w, "TZNAME:"+name)
+	}
+	writeFolded(w, "END:"+kind)
+}
+
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds%3600/60)
+}
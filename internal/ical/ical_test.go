@@ -0,0 +1,230 @@
+package ical
+
+import (
+	"ai_agent/internal/constants/model/dto"
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		file       string
+		wantEvents int
+		// wantRecurrence is the recurrence of the event with wantID
+		wantID         string
+		wantRecurrence []string
+	}{
+		{
+			file:       "recurring.ics",
+			wantEvents: 3,
+			wantID:     "standup-2026@example.com",
+			wantRecurrence: []string{
+				"RRULE:FREQ=WEEKLY;BYDAY=TU,TH;UNTIL=20261231T235959Z",
+				"EXDATE:20261103T143000Z",
+				"EXDATE:20261126T143000Z",
+				"EXDATE:20261013T133000Z",
+				"EXDATE:20261020T133000Z",
+			},
+		},
+		{
+			file:       "allday.ics",
+			wantEvents: 3,
+			wantID:     "birthday@example.com",
+			wantRecurrence: []string{
+				"RRULE:FREQ=YEARLY",
+				"EXDATE;VALUE=DATE:20270314",
+			},
+		},
+		{
+			file:       "outlook.ics",
+			wantEvents: 2,
+			wantID:     "040000008200E00074C5B7101A82E00800000001",
+			wantRecurrence: []string{
+				"RRULE:FREQ=DAILY;COUNT=5",
+				"EXDATE:20261104T150000Z",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := Decode(bytes.NewReader(data), loc)
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if len(decoded) != tt.wantEvents {
+				t.Fatalf("Decode returned %d events, want %d", len(decoded), tt.wantEvents)
+			}
+			i := slices.IndexFunc(decoded, func(event dto.Event) bool { return event.ID == tt.wantID })
+			if i < 0 {
+				t.Fatalf("Decode did not return event %s", tt.wantID)
+			}
+			if !slices.Equal(decoded[i].Recurrence, tt.wantRecurrence) {
+				t.Errorf("recurrence = %q, want %q", decoded[i].Recurrence, tt.wantRecurrence)
+			}
+
+			var encoded bytes.Buffer
+			if err := Encode(&encoded, decoded, Options{Location: loc}); err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+			again, err := Decode(&encoded, loc)
+			if err != nil {
+				t.Fatalf("Decode of encoded calendar failed: %v\n%s", err, encoded.String())
+			}
+
+			if got, want := normalized(again), normalized(decoded); !reflect.DeepEqual(got, want) {
+				t.Errorf("events changed in the round trip\ngot:  %+v\nwant: %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeSamples(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	events := map[string]dto.Event{}
+	for _, file := range []string{"recurring.ics", "allday.ics", "outlook.ics"} {
+		f, err := os.Open(filepath.Join("testdata", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := Decode(f, loc)
+		f.Close()
+		if err != nil {
+			t.Fatalf("Decode(%s) failed: %v", file, err)
+		}
+		for _, event := range decoded {
+			events[event.ID] = event
+		}
+	}
+
+	tests := []struct {
+		id        string
+		start     time.Time
+		end       time.Time
+		allDay    bool
+		title     string
+		attendees []string
+	}{
+		{
+			id:        "standup-2026@example.com",
+			start:     time.Date(2026, 9, 8, 9, 30, 0, 0, loc),
+			end:       time.Date(2026, 9, 8, 9, 45, 0, 0, loc),
+			title:     "Team standup",
+			attendees: []string{"jane@example.com", "john@example.com", "sam@example.com"},
+		},
+		{
+			id:        "standup-2026@example.com_20261013T133000Z",
+			start:     time.Date(2026, 10, 13, 11, 0, 0, 0, loc),
+			end:       time.Date(2026, 10, 13, 11, 15, 0, 0, loc),
+			title:     "Team standup (moved for the offsite)",
+			attendees: []string{"jane@example.com", "john@example.com"},
+		},
+		{
+			id:    "review-2026@example.com",
+			start: time.Date(2026, 10, 29, 15, 0, 0, 0, london),
+			end:   time.Date(2026, 10, 29, 16, 30, 0, 0, london),
+			title: "Quarterly review",
+		},
+		{
+			id:        "offsite@example.com",
+			start:     time.Date(2026, 10, 31, 0, 0, 0, 0, loc),
+			end:       time.Date(2026, 11, 2, 0, 0, 0, 0, loc),
+			allDay:    true,
+			title:     "Team offsite",
+			attendees: []string{"jane@example.com"},
+		},
+		{
+			id:     "holiday@example.com",
+			start:  time.Date(2026, 11, 26, 0, 0, 0, 0, loc),
+			end:    time.Date(2026, 11, 28, 0, 0, 0, 0, loc),
+			allDay: true,
+			title:  "Thanksgiving break",
+		},
+		{
+			id:        "040000008200E00074C5B7101A82E00800000000",
+			start:     time.Date(2026, 10, 30, 14, 0, 0, 0, loc),
+			end:       time.Date(2026, 10, 30, 15, 0, 0, 0, loc),
+			title:     "Budget sync",
+			attendees: []string{"jane@example.com"},
+		},
+		{
+			id:    "040000008200E00074C5B7101A82E00800000001",
+			start: time.Date(2026, 11, 2, 10, 0, 0, 0, loc),
+			end:   time.Date(2026, 11, 2, 10, 30, 0, 0, loc),
+			title: "Vendor call",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			event, ok := events[tt.id]
+			if !ok {
+				t.Fatalf("event %s not decoded", tt.id)
+			}
+			if !event.StartTime.Equal(tt.start) || !event.EndTime.Equal(tt.end) {
+				t.Errorf("times = %s - %s, want %s - %s", event.StartTime, event.EndTime, tt.start, tt.end)
+			}
+			if event.AllDay != tt.allDay {
+				t.Errorf("AllDay = %v, want %v", event.AllDay, tt.allDay)
+			}
+			if event.Title != tt.title {
+				t.Errorf("Title = %q, want %q", event.Title, tt.title)
+			}
+			if !slices.Equal(event.Attendees, tt.attendees) {
+				t.Errorf("Attendees = %q, want %q", event.Attendees, tt.attendees)
+			}
+		})
+	}
+
+	if _, ok := events["dropped@example.com"]; ok {
+		t.Error("cancelled event was decoded")
+	}
+	standup := events["standup-2026@example.com"]
+	if want := "Yesterday, today, blockers.\nKeep it short; fifteen minutes at most."; standup.Description != want {
+		t.Errorf("Description = %q, want %q", standup.Description, want)
+	}
+	if want := map[string]string{"jane@example.com": dto.ResponseAccepted, "john@example.com": dto.ResponseTentative, "sam@example.com": dto.ResponseNeedsAction}; !reflect.DeepEqual(standup.Responses, want) {
+		t.Errorf("Responses = %v, want %v", standup.Responses, want)
+	}
+}
+
+// normalized returns events with their times in UTC, since equal instants in
+// different locations are not deeply equal, and their recurrence lines sorted,
+// since the order of EXDATEs carries no meaning.
+func normalized(events []dto.Event) []dto.Event {
+	out := make([]dto.Event, len(events))
+	for i, event := range events {
+		event.StartTime = event.StartTime.UTC()
+		event.EndTime = event.EndTime.UTC()
+		if !event.OriginalStartTime.IsZero() {
+			event.OriginalStartTime = event.OriginalStartTime.UTC()
+		}
+		event.Recurrence = slices.Sorted(slices.Values(event.Recurrence))
+		out[i] = event
+	}
+	slices.SortFunc(out, func(a, b dto.Event) int { return strings.Compare(a.ID, b.ID) })
+	return out
+}
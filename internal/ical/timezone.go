@@ -0,0 +1,131 @@
+package ical
+
+import (
+	"ai_agent/internal/recurrence"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// vtimezone is a VTIMEZONE definition, used for TZIDs Go cannot load by name
+// such as the Windows zone names some clients write.
+type vtimezone struct {
+	id          string
+	observances []observance
+}
+
+// observance is a STANDARD or DAYLIGHT block. Onsets are local wall clock
+// times held in UTC.
+type observance struct {
+	name   string
+	start  time.Time
+	rules  []string
+	dates  []time.Time
+	from   int
+	offset int
+}
+
+func parseTimezone(c *component) (*vtimezone, bool) {
+	id, ok := c.get("TZID")
+	if !ok {
+		return nil, false
+	}
+	tz := &vtimezone{id: id.value}
+	for _, child := range c.children {
+		if child.name != "STANDARD" && child.name != "DAYLIGHT" {
+			continue
+		}
+		start, _ := child.get("DTSTART")
+		from, _ := child.get("TZOFFSETFROM")
+		to, _ := child.get("TZOFFSETTO")
+		name, _ := child.get("TZNAME")
+
+		obs := observance{name: name.value}
+		var err error
+		if obs.start, err = time.Parse(dateTimeFormat, start.value); err != nil {
+			continue
+		}
+		if obs.from, err = parseOffset(from.value); err != nil {
+			continue
+		}
+		if obs.offset, err = parseOffset(to.value); err != nil {
+			continue
+		}
+		for _, rule := range child.all("RRULE") {
+			obs.rules = append(obs.rules, "RRULE:"+rule.value)
+		}
+		for _, rdate := range child.all("RDATE") {
+			for _, value := range strings.Split(rdate.value, ",") {
+				if t, err := time.Parse(dateTimeFormat, value); err == nil {
+					obs.dates = append(obs.dates, t)
+				}
+			}
+		}
+		tz.observances = append(tz.observances, obs)
+	}
+	return tz, len(tz.observances) > 0
+}
+
+// at returns the instant of a wall clock time in the zone, which is held in
+// UTC. The offset is that of the observance with the latest onset at or
+// before it.
+func (tz *vtimezone) at(wall time.Time) time.Time {
+	var latest time.Time
+	earliest := tz.observances[0]
+	current := observance{}
+	found := false
+
+	for _, obs := range tz.observances {
+		if obs.start.Before(earliest.start) {
+			earliest = obs
+		}
+		onsets := append([]time.Time{obs.start}, obs.dates...)
+		if len(obs.rules) > 0 {
+			if expanded, err := recurrence.Expand(obs.rules, obs.start, 0, time.Time{}, wall.Add(time.Second)); err == nil {
+				onsets = append(onsets, expanded...)
+			}
+		}
+		for _, onset := range onsets {
+			if !onset.After(wall) && (!found || onset.After(latest)) {
+				latest, current, found = onset, obs, true
+			}
+		}
+	}
+
+	name, offset := current.name, current.offset
+	if !found {
+		// Before the first onset the zone keeps the offset it changed from
+		name, offset = earliest.name, earliest.from
+	}
+	return time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), 0,
+		time.FixedZone(name, offset))
+}
+
+// parseOffset parses a UTC offset such as -0500 or +053000 into seconds.
+func parseOffset(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if len(value) != 5 && len(value) != 7 {
+		return 0, strconv.ErrSyntax
+	}
+	sign := 1
+	switch value[0] {
+	case '-':
+		sign = -1
+	case '+':
+	default:
+		return 0, strconv.ErrSyntax
+	}
+
+	seconds := 0
+	for i, unit := range []int{3600, 60, 1} {
+		if 1+2*i >= len(value) {
+			break
+		}
+		n, err := strconv.Atoi(value[1+2*i : 3+2*i])
+		if err != nil {
+			return 0, err
+		}
+		seconds += n * unit
+	}
+	return sign * seconds, nil
+}
@@ -0,0 +1,258 @@
+// Package intent recognises the common command shapes without a language
+// model and turns them into the same structured actions the model returns:
+//
+//	schedule <title> with <emails> <time> [for <n> minutes|hours] [about <title>]
+//	    [daily|weekly|monthly|every <weekday>|every other week|...]
+//	    [on the <name> calendar]
+//	move|reschedule <event> to <time>
+//	cancel <event>
+//	email <addresses> [cc <addresses>] about <subject> [saying <body>]
+//	what's on my [<name>] calendar
+package intent
+
+import (
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/datetime"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Confidence levels returned with a match.
+const (
+	// ConfidenceExact means every parameter was stated in the command.
+	ConfidenceExact = 1.0
+	// ConfidenceDefaulted means the shape matched but a title or duration was defaulted.
+	ConfidenceDefaulted = 0.8
+	// ConfidenceConfirm means the shape matched a command that moves or
+	// cancels an existing event, which the model or the user should check
+	// before it runs.
+	ConfidenceConfirm = 0.6
+)
+
+const defaultDurationMinutes = 30
+
+var (
+	emailPattern = `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`
+	emailRe      = regexp.MustCompile(emailPattern)
+
+	scheduleRe = regexp.MustCompile(`(?i)^(?:please\s+)?(?:schedule|book|set up|arrange|organi[sz]e)\s+(.*?)\s*\bwith\s+(` +
+		emailPattern + `(?:\s*(?:,|and|&)\s*` + emailPattern + `)*)(.*)$`)
+	durationRe = regexp.MustCompile(`(?i)\s*\bfor\s+(\d+|an?|half an)\s+(minutes?|mins?|hours?|hrs?)\b`)
+	topicRe    = regexp.MustCompile(`(?i)\s*\b(?:about|to discuss|regarding|titled|called)\s+(.+)$`)
+	repeatRe   = regexp.MustCompile(`(?i)\b(?:every\s+(other\s+)?(day|weekday|week|month|year|monday|tuesday|wednesday|thursday|friday|saturday|sunday)s?|(daily|weekly|biweekly|fortnightly|monthly|yearly|annually))\b`)
+
+	emailListPattern = emailPattern + `(?:\s*(?:,|and|&)\s*` + emailPattern + `)*`
+	emailCommandRe   = regexp.MustCompile(`(?i)^(?:please\s+)?(?:send\s+(?:an?\s+)?)?e-?mail\s+(?:to\s+)?(` + emailListPattern +
+		`)(?:\s*,?\s+(?:and\s+)?cc:?\s+(` + emailListPattern + `))?\s+(?:about|regarding|re:?|with (?:the )?subject:?|subject:?)\s+(.+?)(?:\s+saying\s+(.+))?$`)
+
+	rescheduleRe = regexp.MustCompile(`(?i)^(?:please\s+)?(?:move|reschedule|push|shift|bump)\s+(.+?)\s+to\s+(.+)$`)
+	cancelRe     = regexp.MustCompile(`(?i)^(?:please\s+)?(?:cancel|call off|delete)\s+(.+)$`)
+	// compoundRe finds a second request in a move or cancel command, such as
+	// "cancel my 3pm and email bob an apology"
+	compoundRe = regexp.MustCompile(`(?i)[,;&]|\b(?:and|then|also|plus|afterwards)\b|` +
+		`\b(?:e-?mail|send|tell|notify|invite|schedule|book|move|reschedule|cancel|delete)\b`)
+
+	calendarRe       = regexp.MustCompile(`(?i)\s*\b(?:on|to|in|into)\s+(?:my|the|our)\s+([a-z0-9_-]+)\s+calendar\b`)
+	calendarEventsRe = regexp.MustCompile(`(?i)^(?:what'?s|what is|what do i have)\s+on\s+(?:my|the|our)\s+([a-z0-9_-]+)\s+calendar\b`)
+
+	eventsRe = regexp.MustCompile(`(?i)^(?:what'?s|what is|what do i have)\s+on\s+my\s+(?:calendar|schedule|agenda)\b|` +
+		`^(?:show|list|get)(?:\s+me)?\s+my\s+(?:calendar|events|meetings|schedule|agenda)\b|` +
+		`^what\s+(?:meetings|events)\s+do\s+i\s+have\b`)
+
+	repeatRules = map[string]string{
+		"day":         "FREQ=DAILY",
+		"daily":       "FREQ=DAILY",
+		"weekday":     "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+		"week":        "FREQ=WEEKLY",
+		"weekly":      "FREQ=WEEKLY",
+		"biweekly":    "FREQ=WEEKLY;INTERVAL=2",
+		"fortnightly": "FREQ=WEEKLY;INTERVAL=2",
+		"month":       "FREQ=MONTHLY",
+		"monthly":     "FREQ=MONTHLY",
+		"year":        "FREQ=YEARLY",
+		"yearly":      "FREQ=YEARLY",
+		"annually":    "FREQ=YEARLY",
+	}
+
+	genericTitles = map[string]bool{"": true, "a meeting": true, "meeting": true, "a call": true, "call": true, "a sync": true}
+)
+
+// Parse matches a command against the known shapes. It reports false when no
+// shape matches or a time in the command cannot be resolved.
+func Parse(command string, resolver *datetime.Resolver) (dto.AgentAction, float64, bool) {
+	command = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(command), ".!?"))
+
+	if m := calendarEventsRe.FindStringSubmatch(command); m != nil {
+		return dto.AgentAction{
+			Action:     dto.ActionGetEvents,
+			Parameters: dto.ActionParameters{Calendar: strings.ToLower(m[1])},
+		}, ConfidenceExact, true
+	}
+	if eventsRe.MatchString(command) {
+		return dto.AgentAction{Action: dto.ActionGetEvents}, ConfidenceExact, true
+	}
+	if action, confidence, ok := parseEmail(command); ok {
+		return action, confidence, true
+	}
+	if action, confidence, ok := parseReschedule(command, resolver); ok {
+		return action, confidence, true
+	}
+	if m := cancelRe.FindStringSubmatch(command); m != nil {
+		if compoundRe.MatchString(m[1]) {
+			return dto.AgentAction{}, 0, false
+		}
+		return dto.AgentAction{
+			Action:     dto.ActionCancelMeeting,
+			Parameters: dto.ActionParameters{Event: strings.TrimSpace(m[1])},
+		}, ConfidenceConfirm, true
+	}
+	return parseSchedule(command, resolver)
+}
+
+// parseReschedule matches "move my 3pm to tomorrow". The event is looked up
+// when the action runs, so only the new time is checked here.
+func parseReschedule(command string, resolver *datetime.Resolver) (dto.AgentAction, float64, bool) {
+	m := rescheduleRe.FindStringSubmatch(command)
+	if m == nil || compoundRe.MatchString(m[1]) || compoundRe.MatchString(m[2]) {
+		return dto.AgentAction{}, 0, false
+	}
+
+	timeExpression := strings.TrimSpace(m[2])
+	if _, err := resolver.Resolve(timeExpression); err != nil {
+		return dto.AgentAction{}, 0, false
+	}
+
+	return dto.AgentAction{
+		Action: dto.ActionRescheduleMeeting,
+		Parameters: dto.ActionParameters{
+			Event:          strings.TrimSpace(m[1]),
+			TimeExpression: timeExpression,
+		},
+	}, ConfidenceConfirm, true
+}
+
+func parseEmail(command string) (dto.AgentAction, float64, bool) {
+	m := emailCommandRe.FindStringSubmatch(command)
+	if m == nil {
+		return dto.AgentAction{}, 0, false
+	}
+
+	to := emailRe.FindAllString(m[1], -1)
+	return dto.AgentAction{
+		Action: dto.ActionSendEmail,
+		Parameters: dto.ActionParameters{
+			ToEmail: to[0],
+			To:      to[1:],
+			Cc:      emailRe.FindAllString(m[2], -1),
+			Subject: strings.TrimSpace(m[3]),
+			Body:    strings.TrimSpace(m[4]),
+		},
+	}, ConfidenceExact, true
+}
+
+func parseSchedule(command string, resolver *datetime.Resolver) (dto.AgentAction, float64, bool) {
+	m := scheduleRe.FindStringSubmatch(command)
+	if m == nil {
+		return dto.AgentAction{}, 0, false
+	}
+	confidence := ConfidenceExact
+
+	title := m[1]
+	rest := m[3]
+
+	// "on the team calendar" may come before or after the attendees
+	var calendar string
+	for _, part := range []*string{&title, &rest} {
+		if c := calendarRe.FindStringSubmatch(*part); c != nil {
+			calendar = strings.ToLower(c[1])
+			*part = calendarRe.ReplaceAllString(*part, " ")
+		}
+	}
+	title = strings.TrimSpace(title)
+
+	durationMinutes := defaultDurationMinutes
+	if d := durationRe.FindStringSubmatch(rest); d != nil {
+		durationMinutes = parseDuration(d[1], d[2])
+		rest = durationRe.ReplaceAllString(rest, " ")
+	} else {
+		confidence = ConfidenceDefaulted
+	}
+
+	if t := topicRe.FindStringSubmatch(rest); t != nil {
+		if genericTitles[strings.ToLower(title)] {
+			title = strings.TrimSpace(t[1])
+		}
+		rest = topicRe.ReplaceAllString(rest, " ")
+	}
+	if genericTitles[strings.ToLower(title)] {
+		title = "Meeting"
+		confidence = ConfidenceDefaulted
+	}
+
+	var recurrence []string
+	if r := repeatRe.FindStringSubmatch(rest); r != nil {
+		var day string
+		recurrence, day = parseRepeat(r[1] != "", strings.ToLower(r[2]+r[3]))
+		// "every tuesday at 10" starts on the next Tuesday
+		rest = repeatRe.ReplaceAllString(rest, day)
+	}
+
+	timeExpression := strings.Join(strings.Fields(rest), " ")
+	if timeExpression == "" {
+		return dto.AgentAction{}, 0, false
+	}
+	startTime, err := resolver.Resolve(timeExpression)
+	if err != nil {
+		return dto.AgentAction{}, 0, false
+	}
+
+	return dto.AgentAction{
+		Action: dto.ActionScheduleMeeting,
+		Parameters: dto.ActionParameters{
+			Attendees:       emailRe.FindAllString(m[2], -1),
+			StartTime:       startTime.Format(time.RFC3339),
+			TimeExpression:  timeExpression,
+			DurationMinutes: durationMinutes,
+			Title:           title,
+			Recurrence:      recurrence,
+			Calendar:        calendar,
+		},
+	}, confidence, true
+}
+
+// parseRepeat turns a repeat phrase such as "every other tuesday" or "monthly"
+// into an RRULE, and returns the weekday it names, if any, so the first
+// occurrence can be resolved from it.
+func parseRepeat(other bool, unit string) ([]string, string) {
+	rule := repeatRules[unit]
+
+	var day string
+	if rule == "" {
+		// A weekday name
+		day = unit
+		rule = "FREQ=WEEKLY;BYDAY=" + strings.ToUpper(unit[:2])
+	}
+	if other {
+		rule += ";INTERVAL=2"
+	}
+	return []string{"RRULE:" + rule}, day
+}
+
+func parseDuration(amount, unit string) int {
+	var minutes float64
+	switch strings.ToLower(amount) {
+	case "a", "an":
+		minutes = 1
+	case "half an":
+		minutes = 0.5
+	default:
+		n, _ := strconv.Atoi(amount)
+		minutes = float64(n)
+	}
+	if strings.HasPrefix(strings.ToLower(unit), "h") {
+		minutes *= 60
+	}
+	return int(minutes)
+}
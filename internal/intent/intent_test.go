@@ -0,0 +1,221 @@
+package intent
+
+import (
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/datetime"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testResolver resolves against Tuesday, October 27, 2026 at 10:30 in New York.
+func testResolver(t *testing.T) *datetime.Resolver {
+	t.Helper()
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	now := time.Date(2026, time.October, 27, 10, 30, 0, 0, loc)
+	return datetime.NewResolver(loc, func() time.Time { return now })
+}
+
+func TestParse(t *testing.T) {
+	resolver := testResolver(t)
+
+	tests := []struct {
+		name           string
+		command        string
+		want           dto.AgentAction
+		wantConfidence float64
+	}{
+		{
+			name:           "events",
+			command:        "What's on my calendar?",
+			want:           dto.AgentAction{Action: dto.ActionGetEvents},
+			wantConfidence: ConfidenceExact,
+		},
+		{
+			name:           "events listed",
+			command:        "show me my meetings",
+			want:           dto.AgentAction{Action: dto.ActionGetEvents},
+			wantConfidence: ConfidenceExact,
+		},
+		{
+			name:    "calendar events",
+			command: "what's on the Team calendar",
+			want: dto.AgentAction{
+				Action:     dto.ActionGetEvents,
+				Parameters: dto.ActionParameters{Calendar: "team"},
+			},
+			wantConfidence: ConfidenceExact,
+		},
+		{
+			name:    "email",
+			command: "email bob@example.com and carol@example.com cc dave@example.com about Budget saying The numbers are in.",
+			want: dto.AgentAction{
+				Action: dto.ActionSendEmail,
+				Parameters: dto.ActionParameters{
+					ToEmail: "bob@example.com",
+					To:      []string{"carol@example.com"},
+					Cc:      []string{"dave@example.com"},
+					Subject: "Budget",
+					Body:    "The numbers are in",
+				},
+			},
+			wantConfidence: ConfidenceExact,
+		},
+		{
+			name:    "email without body",
+			command: "send an email to bob@example.com with subject: Lunch",
+			want: dto.AgentAction{
+				Action:     dto.ActionSendEmail,
+				Parameters: dto.ActionParameters{ToEmail: "bob@example.com", To: []string{}, Subject: "Lunch"},
+			},
+			wantConfidence: ConfidenceExact,
+		},
+		{
+			name:    "reschedule",
+			command: "move my standup to tomorrow at 3pm",
+			want: dto.AgentAction{
+				Action:     dto.ActionRescheduleMeeting,
+				Parameters: dto.ActionParameters{Event: "my standup", TimeExpression: "tomorrow at 3pm"},
+			},
+			wantConfidence: ConfidenceConfirm,
+		},
+		{
+			name:    "cancel",
+			command: "please cancel the budget review",
+			want: dto.AgentAction{
+				Action:     dto.ActionCancelMeeting,
+				Parameters: dto.ActionParameters{Event: "the budget review"},
+			},
+			wantConfidence: ConfidenceConfirm,
+		},
+		{
+			name:    "schedule",
+			command: "schedule Budget review with bob@example.com tomorrow at 3pm for 1 hour",
+			want: dto.AgentAction{
+				Action: dto.ActionScheduleMeeting,
+				Parameters: dto.ActionParameters{
+					Attendees:       []string{"bob@example.com"},
+					StartTime:       "2026-10-28T15:00:00-04:00",
+					TimeExpression:  "tomorrow at 3pm",
+					DurationMinutes: 60,
+					Title:           "Budget review",
+				},
+			},
+			wantConfidence: ConfidenceExact,
+		},
+		{
+			name:    "schedule with topic",
+			command: "book a meeting with bob@example.com, carol@example.com friday at 10am for half an hour about Hiring",
+			want: dto.AgentAction{
+				Action: dto.ActionScheduleMeeting,
+				Parameters: dto.ActionParameters{
+					Attendees:       []string{"bob@example.com", "carol@example.com"},
+					StartTime:       "2026-10-30T10:00:00-04:00",
+					TimeExpression:  "friday at 10am",
+					DurationMinutes: 30,
+					Title:           "Hiring",
+				},
+			},
+			wantConfidence: ConfidenceExact,
+		},
+		{
+			name:    "schedule repeating on a calendar",
+			command: "schedule Sync on the team calendar with bob@example.com every other thursday at 9am for 45 minutes",
+			want: dto.AgentAction{
+				Action: dto.ActionScheduleMeeting,
+				Parameters: dto.ActionParameters{
+					Attendees:       []string{"bob@example.com"},
+					StartTime:       "2026-10-29T09:00:00-04:00",
+					TimeExpression:  "thursday at 9am",
+					DurationMinutes: 45,
+					Title:           "Sync",
+					Recurrence:      []string{"RRULE:FREQ=WEEKLY;BYDAY=TH;INTERVAL=2"},
+					Calendar:        "team",
+				},
+			},
+			wantConfidence: ConfidenceExact,
+		},
+		{
+			name:    "schedule with defaulted duration",
+			command: "schedule Budget review with bob@example.com tomorrow at 3pm",
+			want: dto.AgentAction{
+				Action: dto.ActionScheduleMeeting,
+				Parameters: dto.ActionParameters{
+					Attendees:       []string{"bob@example.com"},
+					StartTime:       "2026-10-28T15:00:00-04:00",
+					TimeExpression:  "tomorrow at 3pm",
+					DurationMinutes: defaultDurationMinutes,
+					Title:           "Budget review",
+				},
+			},
+			wantConfidence: ConfidenceDefaulted,
+		},
+		{
+			name:    "schedule with defaulted title",
+			command: "set up a meeting with bob@example.com tomorrow at 3pm for 30 minutes",
+			want: dto.AgentAction{
+				Action: dto.ActionScheduleMeeting,
+				Parameters: dto.ActionParameters{
+					Attendees:       []string{"bob@example.com"},
+					StartTime:       "2026-10-28T15:00:00-04:00",
+					TimeExpression:  "tomorrow at 3pm",
+					DurationMinutes: 30,
+					Title:           "Meeting",
+				},
+			},
+			wantConfidence: ConfidenceDefaulted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, confidence, ok := Parse(tt.command, resolver)
+			if !ok {
+				t.Fatalf("Parse(%q) did not match", tt.command)
+			}
+			if confidence != tt.wantConfidence {
+				t.Errorf("confidence = %v, want %v", confidence, tt.wantConfidence)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) =\n%+v\nwant\n%+v", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLeavesOthersToTheModel(t *testing.T) {
+	resolver := testResolver(t)
+
+	tests := []struct {
+		name    string
+		command string
+	}{
+		// A second request must not be dropped by matching only the first
+		{"cancel and email", "cancel my 3pm and email bob@example.com an apology"},
+		{"cancel then tell", "cancel standup, then tell the team"},
+		{"cancel with a second cancel", "cancel standup & the retro"},
+		{"move and notify", "move standup to tomorrow at 3pm and notify bob@example.com"},
+		{"move then email", "move standup to friday then email the team"},
+
+		{"schedule without attendees", "schedule a meeting tomorrow at 3pm"},
+		{"schedule without a time", "schedule lunch with bob@example.com"},
+		{"schedule at an unknown time", "schedule lunch with bob@example.com at the usual place"},
+		{"move to an unknown time", "move standup to the big room"},
+		{"email without an address", "email bob about the budget"},
+		{"email without a subject", "email bob@example.com"},
+		{"word starting with cancel", "cancellation policy"},
+		{"not a calendar", "what's on tv tonight"},
+		{"question", "how do I cancel?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, confidence, ok := Parse(tt.command, resolver); ok {
+				t.Errorf("Parse(%q) = %+v (%v), want no match", tt.command, got, confidence)
+			}
+		})
+	}
+}
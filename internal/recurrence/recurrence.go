@@ -0,0 +1,552 @@
+// Package recurrence parses and expands RFC 5545 recurrence rules, the same
+// RRULE and EXDATE lines Google Calendar stores in an event's recurrence.
+//
+// Occurrences are generated on the wall clock of the first occurrence's time
+// zone, so a weekly 09:00 meeting stays at 09:00 when daylight saving starts
+// or ends.
+//
+// Supported: FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL, COUNT, UNTIL,
+// BYDAY (with ordinals such as 1MO or -1FR for monthly and yearly rules),
+// BYMONTHDAY, BYMONTH and WKST, plus EXDATE exceptions. Other parts are
+// rejected rather than silently ignored.
+package recurrence
+
+import (
+	"ai_agent/internal/constants/errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRule is returned for recurrence lines that cannot be parsed or use
+// unsupported parts.
+var ErrInvalidRule = fmt.Errorf("%w: invalid recurrence", errors.ErrInvalidInput)
+
+// maxPeriods bounds expansion of rules that never match, such as the 30th of
+// February.
+const maxPeriods = 10000
+
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// Weekday is a BYDAY entry. N is its ordinal within the month or year, such as
+// 2 for the second Tuesday or -1 for the last, and 0 for every such day.
+type Weekday struct {
+	N   int
+	Day time.Weekday
+}
+
+// Rule is a parsed RRULE.
+type Rule struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []Weekday
+	ByMonthDay []int
+	ByMonth    []time.Month
+	// WeekStart is the day weeks start on, which decides the weeks a weekly
+	// rule with an INTERVAL skips. It is Monday unless WKST says otherwise.
+	WeekStart time.Weekday
+
+	// untilFloating is set when UNTIL has no zone and so is read on the
+	// wall clock of the first occurrence.
+	untilFloating bool
+	untilDate     bool
+}
+
+// exDate is an EXDATE value. Date-only values exclude every occurrence on
+// that day and floating ones are read on the first occurrence's wall clock.
+type exDate struct {
+	t        time.Time
+	date     bool
+	floating bool
+}
+
+// Recurrence is a rule with its exceptions.
+type Recurrence struct {
+	Rule    Rule
+	exDates []exDate
+}
+
+// Parse reads recurrence lines such as "RRULE:FREQ=WEEKLY;BYDAY=TU" and
+// "EXDATE;TZID=Europe/London:20240130T100000". Exactly one RRULE is required.
+func Parse(lines []string) (*Recurrence, error) {
+	var r Recurrence
+	var haveRule bool
+	for _, line := range lines {
+		name, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not a property line", ErrInvalidRule, line)
+		}
+		name, params, _ := strings.Cut(name, ";")
+
+		switch strings.ToUpper(name) {
+		case "RRULE":
+			if haveRule {
+				return nil, fmt.Errorf("%w: more than one RRULE", ErrInvalidRule)
+			}
+			rule, err := ParseRule(value)
+			if err != nil {
+				return nil, err
+			}
+			r.Rule, haveRule = rule, true
+		case "EXDATE":
+			for _, v := range strings.Split(value, ",") {
+				d, err := parseExDate(params, v)
+				if err != nil {
+					return nil, err
+				}
+				r.exDates = append(r.exDates, d)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unsupported property %s", ErrInvalidRule, name)
+		}
+	}
+	if !haveRule {
+		return nil, fmt.Errorf("%w: missing RRULE", ErrInvalidRule)
+	}
+	return &r, nil
+}
+
+// ParseRule parses the value of an RRULE line, with or without its "RRULE:"
+// prefix.
+func ParseRule(value string) (Rule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	rule := Rule{Interval: 1, WeekStart: time.Monday}
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return Rule{}, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(val)
+			if rule.Freq != Daily && rule.Freq != Weekly && rule.Freq != Monthly && rule.Freq != Yearly {
+				err = fmt.Errorf("unsupported frequency %s", val)
+			}
+		case "INTERVAL":
+			rule.Interval, err = positive(val)
+		case "COUNT":
+			rule.Count, err = positive(val)
+		case "UNTIL":
+			rule.Until, rule.untilDate, rule.untilFloating, err = parseDateTime(val, time.UTC)
+		case "BYDAY":
+			for _, v := range strings.Split(val, ",") {
+				var day Weekday
+				if day, err = parseWeekday(v); err != nil {
+					break
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		case "BYMONTHDAY":
+			for _, v := range strings.Split(val, ",") {
+				var n int
+				if n, err = strconv.Atoi(v); err != nil || n == 0 || n < -31 || n > 31 {
+					err = fmt.Errorf("invalid BYMONTHDAY %s", v)
+					break
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, n)
+			}
+		case "BYMONTH":
+			for _, v := range strings.Split(val, ",") {
+				var n int
+				if n, err = strconv.Atoi(v); err != nil || n < 1 || n > 12 {
+					err = fmt.Errorf("invalid BYMONTH %s", v)
+					break
+				}
+				rule.ByMonth = append(rule.ByMonth, time.Month(n))
+			}
+		case "WKST":
+			var ok bool
+			if rule.WeekStart, ok = weekdays[strings.ToUpper(val)]; !ok {
+				err = fmt.Errorf("invalid WKST %s", val)
+			}
+		default:
+			err = fmt.Errorf("unsupported part %s", key)
+		}
+		if err != nil {
+			return Rule{}, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+		}
+	}
+
+	switch {
+	case rule.Freq == "":
+		return Rule{}, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	case rule.Count > 0 && !rule.Until.IsZero():
+		return Rule{}, fmt.Errorf("%w: COUNT and UNTIL cannot both be set", ErrInvalidRule)
+	case rule.Freq == Yearly && len(rule.ByDay) > 0 && len(rule.ByMonth) == 0:
+		return Rule{}, fmt.Errorf("%w: yearly BYDAY needs BYMONTH", ErrInvalidRule)
+	}
+	for _, day := range rule.ByDay {
+		if day.N != 0 && rule.Freq != Monthly && rule.Freq != Yearly {
+			return Rule{}, fmt.Errorf("%w: BYDAY ordinals need a monthly or yearly rule", ErrInvalidRule)
+		}
+	}
+	return rule, nil
+}
+
+// Occurrences returns the start times of the occurrences of a series first
+// starting at start that overlap [from, to), given each lasts duration.
+// Times are in start's location.
+func (r *Recurrence) Occurrences(start time.Time, duration time.Duration, from, to time.Time) []time.Time {
+	loc := start.Location()
+	until := r.Rule.Until
+	if r.Rule.untilFloating {
+		until = floating(until, loc)
+	}
+	if r.Rule.untilDate {
+		// A date-only UNTIL includes the whole day
+		until = time.Date(until.Year(), until.Month(), until.Day()+1, 0, 0, 0, 0, loc).Add(-time.Nanosecond)
+	}
+
+	var occurrences []time.Time
+	count := 0
+	for period := 0; period < maxPeriods; period++ {
+		candidates := r.Rule.candidates(start, period)
+		if period == 0 && !containsTime(candidates, start) {
+			// The first occurrence is always part of the series
+			candidates = append([]time.Time{start}, candidates...)
+		}
+
+		for _, t := range candidates {
+			if t.Before(start) {
+				continue
+			}
+			if !until.IsZero() && t.After(until) {
+				return occurrences
+			}
+			if !t.Before(to) {
+				return occurrences
+			}
+			count++
+			if r.Rule.Count > 0 && count > r.Rule.Count {
+				return occurrences
+			}
+			if t.Add(duration).After(from) && !r.excluded(t) {
+				occurrences = append(occurrences, t)
+			}
+		}
+	}
+	return occurrences
+}
+
+// Expand parses lines and returns the occurrences of the series overlapping
+// [from, to). See Parse and Recurrence.Occurrences.
+func Expand(lines []string, start time.Time, duration time.Duration, from, to time.Time) ([]time.Time, error) {
+	r, err := Parse(lines)
+	if err != nil {
+		return nil, err
+	}
+	return r.Occurrences(start, duration, from, to), nil
+}
+
+// Exclude returns lines with an EXDATE added for the occurrence at t.
+func Exclude(lines []string, t time.Time) []string {
+	return append(append([]string(nil), lines...), "EXDATE:"+t.UTC().Format("20060102T150405Z"))
+}
+
+// Describe summarises a recurrence for people, such as "every 2 weeks on Mon
+// and Wed, 10 times". Lines that cannot be parsed are returned as they are.
+func Describe(lines []string) string {
+	r, err := Parse(lines)
+	if err != nil {
+		return strings.Join(lines, " ")
+	}
+	rule := r.Rule
+
+	units := map[string]string{Daily: "day", Weekly: "week", Monthly: "month", Yearly: "year"}
+	desc := "every " + units[rule.Freq]
+	if rule.Interval > 1 {
+		desc = fmt.Sprintf("every %d %ss", rule.Interval, units[rule.Freq])
+	}
+
+	var on []string
+	for _, day := range rule.ByDay {
+		name := day.Day.String()[:3]
+		if day.N != 0 {
+			name = ordinal(day.N) + " " + name
+		}
+		on = append(on, name)
+	}
+	for _, n := range rule.ByMonthDay {
+		on = append(on, "day "+strconv.Itoa(n))
+	}
+	if len(on) > 0 {
+		desc += " on " + joinAnd(on)
+	}
+	if len(rule.ByMonth) > 0 {
+		var months []string
+		for _, m := range rule.ByMonth {
+			months = append(months, m.String()[:3])
+		}
+		desc += " in " + joinAnd(months)
+	}
+
+	switch {
+	case rule.Count > 0:
+		desc += fmt.Sprintf(", %d times", rule.Count)
+	case !rule.Until.IsZero():
+		desc += ", until " + rule.Until.Format("Jan 2, 2006")
+	}
+	return desc
+}
+
+// candidates returns the times the rule generates in the period-th period
+// after start, sorted and before COUNT, UNTIL and EXDATE are applied.
+func (rule Rule) candidates(start time.Time, period int) []time.Time {
+	step := period * rule.Interval
+	var days []time.Time
+
+	switch rule.Freq {
+	case Daily:
+		day := date(start.Year(), start.Month(), start.Day()+step, start.Location())
+		if rule.matchesMonth(day) && rule.matchesMonthDay(day) && rule.matchesWeekday(day) {
+			days = append(days, day)
+		}
+	case Weekly:
+		offset := (int(start.Weekday()) - int(rule.WeekStart) + 7) % 7
+		first := date(start.Year(), start.Month(), start.Day()-offset+7*step, start.Location())
+		if len(rule.ByDay) == 0 {
+			days = append(days, first.AddDate(0, 0, offset))
+		}
+		for i := 0; i < 7 && len(rule.ByDay) > 0; i++ {
+			day := date(first.Year(), first.Month(), first.Day()+i, start.Location())
+			if rule.matchesWeekday(day) && rule.matchesMonth(day) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		month := date(start.Year(), start.Month()+time.Month(step), 1, start.Location())
+		if rule.matchesMonth(month) {
+			days = rule.daysInMonth(month, start.Day())
+		}
+	case Yearly:
+		months := rule.ByMonth
+		if len(months) == 0 {
+			months = []time.Month{start.Month()}
+		}
+		for _, m := range months {
+			days = append(days, rule.daysInMonth(date(start.Year()+step, m, 1, start.Location()), start.Day())...)
+		}
+	}
+
+	times := make([]time.Time, 0, len(days))
+	for _, day := range days {
+		times = append(times, time.Date(day.Year(), day.Month(), day.Day(),
+			start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location()))
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return times
+}
+
+// daysInMonth returns the days in the month starting at first that match
+// BYMONTHDAY and BYDAY, or the given day of the month when neither is set.
+func (rule Rule) daysInMonth(first time.Time, dayOfMonth int) []time.Time {
+	last := first.AddDate(0, 1, -1).Day()
+	var days []time.Time
+	if len(rule.ByMonthDay) == 0 && len(rule.ByDay) == 0 {
+		// Months without that day are skipped, as RFC 5545 requires
+		if dayOfMonth <= last {
+			days = append(days, date(first.Year(), first.Month(), dayOfMonth, first.Location()))
+		}
+		return days
+	}
+
+	for d := 1; d <= last; d++ {
+		day := date(first.Year(), first.Month(), d, first.Location())
+		if rule.matchesMonthDay(day) && rule.matchesWeekdayInMonth(day, last) {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+func (rule Rule) matchesMonth(day time.Time) bool {
+	if len(rule.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range rule.ByMonth {
+		if day.Month() == m {
+			return true
+		}
+	}
+	return false
+}
+
+func (rule Rule) matchesMonthDay(day time.Time) bool {
+	if len(rule.ByMonthDay) == 0 {
+		return true
+	}
+	last := date(day.Year(), day.Month()+1, 0, day.Location()).Day()
+	for _, n := range rule.ByMonthDay {
+		if n == day.Day() || (n < 0 && last+1+n == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rule Rule) matchesWeekday(day time.Time) bool {
+	return rule.matchesWeekdayInMonth(day, 0)
+}
+
+// matchesWeekdayInMonth checks BYDAY, including ordinals counted within a
+// month of last days.
+func (rule Rule) matchesWeekdayInMonth(day time.Time, last int) bool {
+	if len(rule.ByDay) == 0 {
+		return true
+	}
+	for _, w := range rule.ByDay {
+		if w.Day != day.Weekday() {
+			continue
+		}
+		switch {
+		case w.N == 0:
+			return true
+		case w.N > 0 && (day.Day()-1)/7+1 == w.N:
+			return true
+		case w.N < 0 && last > 0 && (last-day.Day())/7+1 == -w.N:
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Recurrence) excluded(t time.Time) bool {
+	for _, ex := range r.exDates {
+		exTime := ex.t
+		if ex.floating {
+			exTime = floating(exTime, t.Location())
+		}
+		if ex.date {
+			y1, m1, d1 := t.Date()
+			y2, m2, d2 := exTime.Date()
+			if y1 == y2 && m1 == m2 && d1 == d2 {
+				return true
+			}
+		} else if exTime.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseExDate parses one EXDATE value using the TZID and VALUE parameters of
+// its line.
+func parseExDate(params, value string) (exDate, error) {
+	loc := time.UTC
+	for _, param := range strings.Split(params, ";") {
+		if name, tzid, ok := strings.Cut(param, "="); ok && strings.EqualFold(name, "TZID") {
+			var err error
+			if loc, err = time.LoadLocation(tzid); err != nil {
+				return exDate{}, fmt.Errorf("%w: unknown TZID %s", ErrInvalidRule, tzid)
+			}
+		}
+	}
+
+	t, isDate, isFloating, err := parseDateTime(strings.TrimSpace(value), loc)
+	if err != nil {
+		return exDate{}, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+	// A TZID pins the wall clock to that zone
+	return exDate{t: t, date: isDate, floating: isFloating && !strings.Contains(strings.ToUpper(params), "TZID=")}, nil
+}
+
+// parseDateTime parses the DATE and DATE-TIME forms of RFC 5545. Values
+// without a trailing Z are read in loc and reported as floating.
+func parseDateTime(value string, loc *time.Location) (t time.Time, isDate, isFloating bool, err error) {
+	switch {
+	case len(value) == 8:
+		t, err = time.ParseInLocation("20060102", value, loc)
+		return t, true, true, err
+	case strings.HasSuffix(value, "Z"):
+		t, err = time.Parse("20060102T150405Z", value)
+		return t, false, false, err
+	default:
+		t, err = time.ParseInLocation("20060102T150405", value, loc)
+		return t, false, true, err
+	}
+}
+
+// floating moves the wall clock of t, which was parsed as UTC, into loc.
+func floating(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+func parseWeekday(value string) (Weekday, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if len(value) < 2 {
+		return Weekday{}, fmt.Errorf("invalid BYDAY %s", value)
+	}
+	day, ok := weekdays[value[len(value)-2:]]
+	if !ok {
+		return Weekday{}, fmt.Errorf("invalid BYDAY %s", value)
+	}
+	n := 0
+	if prefix := value[:len(value)-2]; prefix != "" {
+		var err error
+		if n, err = strconv.Atoi(strings.TrimPrefix(prefix, "+")); err != nil || n == 0 || n < -5 || n > 5 {
+			return Weekday{}, fmt.Errorf("invalid BYDAY %s", value)
+		}
+	}
+	return Weekday{N: n, Day: day}, nil
+}
+
+func containsTime(times []time.Time, t time.Time) bool {
+	for _, x := range times {
+		if x.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func positive(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%q must be a positive number", value)
+	}
+	return n, nil
+}
+
+func date(year int, month time.Month, day int, loc *time.Location) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+func ordinal(n int) string {
+	switch n {
+	case -1:
+		return "last"
+	case 1:
+		return "1st"
+	case 2:
+		return "2nd"
+	case 3:
+		return "3rd"
+	}
+	if n < 0 {
+		return ordinal(-n) + " to last"
+	}
+	return strconv.Itoa(n) + "th"
+}
+
+func joinAnd(items []string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
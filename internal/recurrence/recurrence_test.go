@@ -0,0 +1,260 @@
+package recurrence
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	return loc
+}
+
+func TestExpand(t *testing.T) {
+	newYork := loadLocation(t, "America/New_York")
+	london := loadLocation(t, "Europe/London")
+
+	tests := []struct {
+		name  string
+		lines []string
+		start time.Time
+		// The window is [from, to); a zero from is start
+		from time.Time
+		to   time.Time
+		want []string
+	}{
+		{
+			name:  "weekly on two days as clocks go back",
+			lines: []string{"RRULE:FREQ=WEEKLY;BYDAY=TU,TH"},
+			start: time.Date(2026, 10, 27, 9, 0, 0, 0, newYork),
+			to:    time.Date(2026, 11, 7, 0, 0, 0, 0, newYork),
+			want: []string{
+				"2026-10-27T09:00:00-04:00", "2026-10-29T09:00:00-04:00",
+				"2026-11-03T09:00:00-05:00", "2026-11-05T09:00:00-05:00",
+			},
+		},
+		{
+			name:  "weekly on two days as clocks go forward",
+			lines: []string{"RRULE:FREQ=WEEKLY;BYDAY=TU,TH"},
+			start: time.Date(2026, 3, 24, 9, 0, 0, 0, london),
+			to:    time.Date(2026, 4, 3, 0, 0, 0, 0, london),
+			want: []string{
+				"2026-03-24T09:00:00Z", "2026-03-26T09:00:00Z",
+				"2026-03-31T09:00:00+01:00", "2026-04-02T09:00:00+01:00",
+			},
+		},
+		{
+			name:  "window starting during an occurrence",
+			lines: []string{"RRULE:FREQ=WEEKLY;BYDAY=TU,TH"},
+			start: time.Date(2026, 10, 27, 9, 0, 0, 0, newYork),
+			from:  time.Date(2026, 11, 3, 9, 15, 0, 0, newYork),
+			to:    time.Date(2026, 11, 6, 0, 0, 0, 0, newYork),
+			want:  []string{"2026-11-03T09:00:00-05:00", "2026-11-05T09:00:00-05:00"},
+		},
+		{
+			name:  "last friday of the month",
+			lines: []string{"RRULE:FREQ=MONTHLY;BYDAY=-1FR;COUNT=4"},
+			start: time.Date(2026, 1, 30, 16, 0, 0, 0, newYork),
+			to:    time.Date(2027, 1, 1, 0, 0, 0, 0, newYork),
+			want: []string{
+				"2026-01-30T16:00:00-05:00", "2026-02-27T16:00:00-05:00",
+				"2026-03-27T16:00:00-04:00", "2026-04-24T16:00:00-04:00",
+			},
+		},
+		{
+			name:  "31st skips short months",
+			lines: []string{"RRULE:FREQ=MONTHLY;BYMONTHDAY=31;COUNT=4"},
+			start: time.Date(2026, 1, 31, 10, 0, 0, 0, newYork),
+			to:    time.Date(2027, 1, 1, 0, 0, 0, 0, newYork),
+			want: []string{
+				"2026-01-31T10:00:00-05:00", "2026-03-31T10:00:00-04:00",
+				"2026-05-31T10:00:00-04:00", "2026-07-31T10:00:00-04:00",
+			},
+		},
+		{
+			name:  "monthly from the 31st skips short months",
+			lines: []string{"RRULE:FREQ=MONTHLY;COUNT=3"},
+			start: time.Date(2026, 1, 31, 10, 0, 0, 0, newYork),
+			to:    time.Date(2027, 1, 1, 0, 0, 0, 0, newYork),
+			want: []string{
+				"2026-01-31T10:00:00-05:00", "2026-03-31T10:00:00-04:00", "2026-05-31T10:00:00-04:00",
+			},
+		},
+		{
+			name:  "count",
+			lines: []string{"RRULE:FREQ=DAILY;COUNT=3"},
+			start: time.Date(2026, 10, 31, 9, 0, 0, 0, newYork),
+			to:    time.Date(2026, 12, 1, 0, 0, 0, 0, newYork),
+			want: []string{
+				"2026-10-31T09:00:00-04:00", "2026-11-01T09:00:00-05:00", "2026-11-02T09:00:00-05:00",
+			},
+		},
+		{
+			name:  "count includes a start the rule does not match",
+			lines: []string{"RRULE:FREQ=WEEKLY;BYDAY=TU;COUNT=3"},
+			start: time.Date(2026, 10, 26, 9, 0, 0, 0, newYork),
+			to:    time.Date(2026, 12, 1, 0, 0, 0, 0, newYork),
+			want: []string{
+				"2026-10-26T09:00:00-04:00", "2026-10-27T09:00:00-04:00", "2026-11-03T09:00:00-05:00",
+			},
+		},
+		{
+			name:  "count counts occurrences before the window",
+			lines: []string{"RRULE:FREQ=DAILY;COUNT=3"},
+			start: time.Date(2026, 10, 31, 9, 0, 0, 0, newYork),
+			from:  time.Date(2026, 11, 2, 0, 0, 0, 0, newYork),
+			to:    time.Date(2026, 12, 1, 0, 0, 0, 0, newYork),
+			want:  []string{"2026-11-02T09:00:00-05:00"},
+		},
+		{
+			name:  "until in UTC includes an occurrence at that instant",
+			lines: []string{"RRULE:FREQ=DAILY;UNTIL=20261102T140000Z"},
+			start: time.Date(2026, 10, 31, 9, 0, 0, 0, newYork),
+			to:    time.Date(2026, 12, 1, 0, 0, 0, 0, newYork),
+			want: []string{
+				"2026-10-31T09:00:00-04:00", "2026-11-01T09:00:00-05:00", "2026-11-02T09:00:00-05:00",
+			},
+		},
+		{
+			name:  "floating until is on the wall clock",
+			lines: []string{"RRULE:FREQ=DAILY;UNTIL=20261101T085959"},
+			start: time.Date(2026, 10, 31, 9, 0, 0, 0, newYork),
+			to:    time.Date(2026, 12, 1, 0, 0, 0, 0, newYork),
+			want:  []string{"2026-10-31T09:00:00-04:00"},
+		},
+		{
+			name:  "until date includes the whole day",
+			lines: []string{"RRULE:FREQ=DAILY;UNTIL=20261101"},
+			start: time.Date(2026, 10, 31, 9, 0, 0, 0, newYork),
+			to:    time.Date(2026, 12, 1, 0, 0, 0, 0, newYork),
+			want:  []string{"2026-10-31T09:00:00-04:00", "2026-11-01T09:00:00-05:00"},
+		},
+		{
+			name: "exdates with TZID, in UTC and as dates",
+			lines: []string{
+				"RRULE:FREQ=WEEKLY;BYDAY=TU,TH;COUNT=6",
+				"EXDATE;TZID=America/New_York:20261029T090000",
+				"EXDATE:20261103T140000Z",
+				"EXDATE;VALUE=DATE:20261110",
+			},
+			start: time.Date(2026, 10, 27, 9, 0, 0, 0, newYork),
+			to:    time.Date(2026, 12, 1, 0, 0, 0, 0, newYork),
+			want: []string{
+				"2026-10-27T09:00:00-04:00", "2026-11-05T09:00:00-05:00", "2026-11-12T09:00:00-05:00",
+			},
+		},
+		{
+			name: "exdate with TZID of another zone",
+			lines: []string{
+				"RRULE:FREQ=DAILY;COUNT=3",
+				"EXDATE;TZID=Europe/London:20261101T140000",
+			},
+			start: time.Date(2026, 10, 31, 9, 0, 0, 0, newYork),
+			to:    time.Date(2026, 12, 1, 0, 0, 0, 0, newYork),
+			want:  []string{"2026-10-31T09:00:00-04:00", "2026-11-02T09:00:00-05:00"},
+		},
+		{
+			name:  "exdate at another time excludes nothing",
+			lines: []string{"RRULE:FREQ=DAILY;COUNT=2", "EXDATE:20261031T090000Z"},
+			start: time.Date(2026, 10, 31, 9, 0, 0, 0, newYork),
+			to:    time.Date(2026, 12, 1, 0, 0, 0, 0, newYork),
+			want:  []string{"2026-10-31T09:00:00-04:00", "2026-11-01T09:00:00-05:00"},
+		},
+		{
+			// RFC 5545 section 3.8.5.3, with WKST=MO
+			name:  "every other week with weeks starting monday",
+			lines: []string{"RRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=MO"},
+			start: time.Date(1997, 8, 5, 9, 0, 0, 0, newYork),
+			to:    time.Date(1998, 1, 1, 0, 0, 0, 0, newYork),
+			want: []string{
+				"1997-08-05T09:00:00-04:00", "1997-08-10T09:00:00-04:00",
+				"1997-08-19T09:00:00-04:00", "1997-08-24T09:00:00-04:00",
+			},
+		},
+		{
+			// The same with WKST=SU
+			name:  "every other week with weeks starting sunday",
+			lines: []string{"RRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=SU"},
+			start: time.Date(1997, 8, 5, 9, 0, 0, 0, newYork),
+			to:    time.Date(1998, 1, 1, 0, 0, 0, 0, newYork),
+			want: []string{
+				"1997-08-05T09:00:00-04:00", "1997-08-17T09:00:00-04:00",
+				"1997-08-19T09:00:00-04:00", "1997-08-31T09:00:00-04:00",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := tt.from
+			if from.IsZero() {
+				from = tt.start
+			}
+			occurrences, err := Expand(tt.lines, tt.start, 30*time.Minute, from, tt.to)
+			if err != nil {
+				t.Fatalf("Expand failed: %v", err)
+			}
+			var got []string
+			for _, occurrence := range occurrences {
+				got = append(got, occurrence.Format(time.RFC3339))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("occurrences =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+	}{
+		{"no rule", []string{"EXDATE:20261103T140000Z"}},
+		{"two rules", []string{"RRULE:FREQ=DAILY", "RRULE:FREQ=WEEKLY"}},
+		{"no frequency", []string{"RRULE:COUNT=3"}},
+		{"unsupported frequency", []string{"RRULE:FREQ=HOURLY"}},
+		{"unsupported part", []string{"RRULE:FREQ=MONTHLY;BYSETPOS=-1"}},
+		{"count and until", []string{"RRULE:FREQ=DAILY;COUNT=3;UNTIL=20261231T000000Z"}},
+		{"zero interval", []string{"RRULE:FREQ=DAILY;INTERVAL=0"}},
+		{"invalid week start", []string{"RRULE:FREQ=WEEKLY;WKST=XX"}},
+		{"ordinal in a weekly rule", []string{"RRULE:FREQ=WEEKLY;BYDAY=1MO"}},
+		{"yearly weekday without month", []string{"RRULE:FREQ=YEARLY;BYDAY=MO"}},
+		{"month day out of range", []string{"RRULE:FREQ=MONTHLY;BYMONTHDAY=32"}},
+		{"unknown time zone", []string{"RRULE:FREQ=DAILY", "EXDATE;TZID=Mars/Olympus:20261103T090000"}},
+		{"unsupported property", []string{"RRULE:FREQ=DAILY", "RDATE:20261103T140000Z"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.lines); !errors.Is(err, ErrInvalidRule) {
+				t.Errorf("Parse(%q) error = %v, want ErrInvalidRule", tt.lines, err)
+			}
+		})
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		lines []string
+		want  string
+	}{
+		{[]string{"RRULE:FREQ=WEEKLY;BYDAY=TU,TH"}, "every week on Tue and Thu"},
+		{[]string{"RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE,FR;COUNT=10"}, "every 2 weeks on Mon, Wed and Fri, 10 times"},
+		{[]string{"RRULE:FREQ=MONTHLY;BYDAY=-1FR"}, "every month on last Fri"},
+		{[]string{"RRULE:FREQ=YEARLY;BYMONTH=3;BYMONTHDAY=14;UNTIL=20301231T000000Z"}, "every year on day 14 in Mar, until Dec 31, 2030"},
+		{[]string{"not a rule"}, "not a rule"},
+	}
+
+	for _, tt := range tests {
+		if got := Describe(tt.lines); got != tt.want {
+			t.Errorf("Describe(%q) = %q, want %q", tt.lines, got, tt.want)
+		}
+	}
+}
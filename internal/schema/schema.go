@@ -0,0 +1,81 @@
+// Package schema builds JSON Schema documents from Go structs so model
+// providers can constrain their output to the shape the service parses.
+//
+// Field names come from `json` tags. Fields without omitempty are required.
+// An `enum:"a,b"` tag restricts string values and a `desc:"..."` tag adds a
+// description.
+package schema
+
+import (
+	"reflect"
+	"strings"
+)
+
+// FromValue returns the JSON Schema for the type of v.
+func FromValue(v any) map[string]any {
+	return fromType(reflect.TypeOf(v))
+}
+
+func fromType(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": fromType(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object"}
+	case reflect.Struct:
+		return fromStruct(t)
+	}
+	return map[string]any{}
+}
+
+func fromStruct(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := fromType(field.Type)
+		if enum := field.Tag.Get("enum"); enum != "" {
+			property["enum"] = strings.Split(enum, ",")
+		}
+		if desc := field.Tag.Get("desc"); desc != "" {
+			property["description"] = desc
+		}
+		properties[name] = property
+
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	result := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		result["required"] = required
+	}
+	return result
+}
@@ -0,0 +1,231 @@
+package agent
+
+import (
+	"ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/datetime"
+	"ai_agent/internal/recurrence"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const defaultMeetingMinutes = 30
+
+// parseAction extracts and validates the structured action in a model reply.
+func parseAction(aiResponse string) (dto.AgentAction, error) {
+	raw := extractJSON(aiResponse)
+	if raw == "" {
+		return dto.AgentAction{}, fmt.Errorf("%w: no JSON object found", errors.ErrInvalidAIResponse)
+	}
+
+	var action dto.AgentAction
+	decoder := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	if err := decoder.Decode(&action); err != nil {
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+			return dto.AgentAction{}, fmt.Errorf("%w: %s must be of type %s, got %s",
+				errors.ErrInvalidActionParameters, typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+		return dto.AgentAction{}, fmt.Errorf("%w: %v", errors.ErrInvalidAIResponse, err)
+	}
+
+	action.Action = strings.TrimSpace(action.Action)
+	if err := validateAction(&action); err != nil {
+		return dto.AgentAction{}, err
+	}
+
+	return action, nil
+}
+
+// extractJSON strips markdown code fences and any text surrounding the JSON object.
+func extractJSON(text string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		if newline := strings.Index(text, "\n"); newline >= 0 {
+			text = text[newline+1:]
+		} else {
+			text = strings.TrimPrefix(text, "```")
+		}
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	}
+
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return ""
+	}
+	return text[start : end+1]
+}
+
+// validateAction checks the parameters required by each action and fills in defaults.
+func validateAction(action *dto.AgentAction) error {
+	params := &action.Parameters
+
+	switch action.Action {
+	case dto.ActionScheduleMeeting:
+		if len(params.Attendees) == 0 {
+			return fmt.Errorf("%w: attendees are required", errors.ErrInvalidActionParameters)
+		}
+		for _, attendee := range params.Attendees {
+			if err := validateEmail("attendees", attendee); err != nil {
+				return err
+			}
+		}
+		if params.StartTime == "" && params.TimeExpression == "" {
+			return fmt.Errorf("%w: start_time or time_expression is required", errors.ErrInvalidActionParameters)
+		}
+		if err := validateOptionalTimestamp("start_time", params.StartTime); err != nil {
+			return err
+		}
+		if params.DurationMinutes < 0 {
+			return fmt.Errorf("%w: duration_minutes must be positive", errors.ErrInvalidActionParameters)
+		}
+		if params.DurationMinutes == 0 {
+			params.DurationMinutes = defaultMeetingMinutes
+		}
+		if strings.TrimSpace(params.Title) == "" {
+			params.Title = "Meeting"
+		}
+		if len(params.Recurrence) > 0 {
+			if _, err := recurrence.Parse(params.Recurrence); err != nil {
+				return fmt.Errorf("%w: %v", errors.ErrInvalidActionParameters, err)
+			}
+		}
+	case dto.ActionRescheduleMeeting:
+		if err := validateEventRef(params); err != nil {
+			return err
+		}
+		if params.StartTime == "" && params.TimeExpression == "" {
+			return fmt.Errorf("%w: start_time or time_expression is required", errors.ErrInvalidActionParameters)
+		}
+		if err := validateOptionalTimestamp("start_time", params.StartTime); err != nil {
+			return err
+		}
+		if params.DurationMinutes < 0 {
+			return fmt.Errorf("%w: duration_minutes must be positive", errors.ErrInvalidActionParameters)
+		}
+	case dto.ActionCancelMeeting:
+		if err := validateEventRef(params); err != nil {
+			return err
+		}
+	case dto.ActionSendEmail:
+		if params.ToEmail == "" && len(params.To) == 0 {
+			return fmt.Errorf("%w: to_email is required", errors.ErrInvalidActionParameters)
+		}
+		if params.ToEmail != "" {
+			if err := validateEmail("to_email", params.ToEmail); err != nil {
+				return err
+			}
+		}
+		for field, addresses := range map[string][]string{"to": params.To, "cc": params.Cc, "bcc": params.Bcc} {
+			for _, address := range addresses {
+				if err := validateEmail(field, address); err != nil {
+					return err
+				}
+			}
+		}
+		if params.ReplyTo != "" {
+			if err := validateEmail("reply_to", params.ReplyTo); err != nil {
+				return err
+			}
+		}
+		if strings.TrimSpace(params.Subject) == "" {
+			return fmt.Errorf("%w: subject is required", errors.ErrInvalidActionParameters)
+		}
+	case dto.ActionFindFreeSlot:
+		for _, attendee := range params.Attendees {
+			if err := validateEmail("attendees", attendee); err != nil {
+				return err
+			}
+		}
+		if params.DurationMinutes < 0 {
+			return fmt.Errorf("%w: duration_minutes must be positive", errors.ErrInvalidActionParameters)
+		}
+		if params.DurationMinutes == 0 {
+			params.DurationMinutes = defaultMeetingMinutes
+		}
+		if err := validateOptionalTimestamp("window_start", params.WindowStart); err != nil {
+			return err
+		}
+		if err := validateOptionalTimestamp("window_end", params.WindowEnd); err != nil {
+			return err
+		}
+	case dto.ActionFinalAnswer:
+		if strings.TrimSpace(action.Answer) == "" {
+			return fmt.Errorf("%w: answer is required", errors.ErrInvalidActionParameters)
+		}
+	case dto.ActionAskUser:
+		if strings.TrimSpace(action.Question) == "" {
+			return fmt.Errorf("%w: question is required", errors.ErrInvalidActionParameters)
+		}
+	case dto.ActionGetEvents, dto.ActionRemind:
+	case "":
+		return fmt.Errorf("%w: action is missing", errors.ErrInvalidAIResponse)
+	default:
+		return fmt.Errorf("%w: %q", errors.ErrUnknownAction, action.Action)
+	}
+
+	return nil
+}
+
+func validateEventRef(params *dto.ActionParameters) error {
+	if strings.TrimSpace(params.EventID) == "" && strings.TrimSpace(params.Event) == "" {
+		return fmt.Errorf("%w: event_id or event is required", errors.ErrInvalidActionParameters)
+	}
+	return nil
+}
+
+func validateEmail(field, address string) error {
+	if strings.TrimSpace(address) == "" {
+		return fmt.Errorf("%w: %s is required", errors.ErrInvalidActionParameters, field)
+	}
+	if _, err := mail.ParseAddress(address); err != nil {
+		return fmt.Errorf("%w: %s contains invalid email %q", errors.ErrInvalidActionParameters, field, address)
+	}
+	return nil
+}
+
+func validateOptionalTimestamp(field, value string) error {
+	if value == "" {
+		return nil
+	}
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		return fmt.Errorf("%w: %s %q is not an RFC3339 timestamp", errors.ErrInvalidActionParameters, field, value)
+	}
+	return nil
+}
+
+// resolveActionTimes resolves the user's time expression in the configured zone
+// and uses it in place of the model's start_time, which is often off by a day or
+// an offset. Expressions the resolver does not understand leave start_time as is.
+func (s *Service) resolveActionTimes(ctx context.Context, action *dto.AgentAction) error {
+	params := &action.Parameters
+	if action.Action != dto.ActionScheduleMeeting || params.TimeExpression == "" {
+		return nil
+	}
+
+	resolved, err := datetime.NewResolver(s.location(), s.now).Resolve(params.TimeExpression)
+	if err != nil {
+		if params.StartTime == "" {
+			return fmt.Errorf("%w: %v", errors.ErrInvalidActionParameters, err)
+		}
+		s.logger.Warn(ctx, "Could not resolve time expression, keeping model start_time",
+			zap.String("time_expression", params.TimeExpression), zap.Error(err))
+		return nil
+	}
+
+	if modelTime, err := time.Parse(time.RFC3339, params.StartTime); err == nil && !modelTime.Equal(resolved) {
+		s.logger.Warn(ctx, "Overriding model start_time with resolved time expression",
+			zap.String("time_expression", params.TimeExpression),
+			zap.String("model_start_time", params.StartTime),
+			zap.Time("resolved_start_time", resolved))
+	}
+	params.StartTime = resolved.Format(time.RFC3339)
+	return nil
+}
@@ -1,12 +1,18 @@
 package agent
 
 import (
+	"ai_agent/internal/constants/errors"
 	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/recurrence"
 	"ai_agent/internal/service"
+	"ai_agent/internal/storage"
 	"ai_agent/platform"
 	"ai_agent/platform/logger"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"html"
 	"strings"
 	"time"
 
@@ -16,167 +22,372 @@ import (
 type Service struct {
 	calendar platform.Calendar
 	email    platform.Email
-	gemini   platform.Gemini
-	logger   logger.Logger
-	config   dto.Config
+	llm      platform.LLM
+	sessions storage.Session
+
+	confirmations storage.Confirmation
+	reminders     storage.Reminder
+
+	logger logger.Logger
+	config dto.Config
+	now    func() time.Time
 }
 
-func NewService(calendar platform.Calendar, email platform.Email, 
-	gemini platform.Gemini, 
+func NewService(calendar platform.Calendar, email platform.Email,
+	llm platform.LLM, sessions storage.Session,
+	confirmations storage.Confirmation, reminders storage.Reminder,
 	logger logger.Logger, config dto.Config) service.AgentService {
 	return &Service{
-		calendar: calendar,
-		email:    email,
-		gemini:   gemini,
-		logger:   logger,
-		config:   config,
+		calendar:      calendar,
+		email:         email,
+		llm:           llm,
+		sessions:      sessions,
+		confirmations: confirmations,
+		reminders:     reminders,
+		logger:        logger,
+		config:        config,
+		now:           time.Now,
 	}
 }
 
-func (s *Service) ProcessNaturalLanguageCommand(ctx context.Context, command string) (string, error) {
-	s.logger.Info(ctx, "Processing natural language command", zap.String("command", command))
+func (s *Service) ProcessNaturalLanguageCommand(ctx context.Context, req dto.NaturalLanguageRequest) (dto.CommandResult, error) {
+	s.logger.Info(ctx, "Processing natural language command",
+		zap.String("session_id", req.SessionID), zap.String("command", req.Command), zap.Bool("dry_run", req.DryRun))
 
-	// Use Gemini to understand the command and generate a structured response
-	prompt := fmt.Sprintf(`
-You are an AI executive assistant. Analyze the following command and respond with a JSON object in this exact format:
-{
-  "action": "schedule_meeting|send_email|get_events|remind",
-  "parameters": {
-    "attendees": ["email1@example.com", "email2@example.com"],
-    "start_time": "2024-01-15T10:00:00Z",
-    "duration_minutes": 30,
-    "title": "Meeting Title",
-    "to_email": "recipient@example.com",
-    "subject": "Email Subject",
-    "body": "Email body content",
-    "reminder_text": "Reminder message"
-  }
-}
-
-Command: %s
-
-Only respond with the JSON object, no other text.
-`, command)
-
-	response, err := s.gemini.ProcessCommand(ctx, prompt)
+	session, err := s.loadSession(ctx, req.SessionID)
 	if err != nil {
-		s.logger.Error(ctx, "Failed to process command with Gemini", zap.Error(err))
-		return "", fmt.Errorf("failed to process command: %w", err)
+		return dto.CommandResult{}, err
 	}
 
-	// Parse the response and execute the action
-	return s.executeAction(ctx, response)
+	// Let the model plan and call tools until the command is done, needs more
+	// input or needs confirmation
+	result, err := s.runAgent(ctx, session.ID, req, session.Turns)
+	if err != nil {
+		return dto.CommandResult{SessionID: session.ID}, err
+	}
+	result.SessionID = session.ID
+
+	session.Turns = append(session.Turns,
+		dto.ConversationTurn{Role: dto.RoleUser, Content: req.Command},
+		dto.ConversationTurn{Role: dto.RoleAssistant, Content: result.Result},
+	)
+	if err := s.sessions.Save(ctx, session); err != nil {
+		s.logger.Error(ctx, "Failed to save session", zap.String("session_id", session.ID), zap.Error(err))
+		return result, err
+	}
+
+	return result, nil
 }
 
-// ScheduleMeeting schedules a meeting using AI assistance
-func (s *Service) ScheduleMeeting(ctx context.Context, attendees []string, startTime time.Time, duration time.Duration, title string) error {
-	s.logger.Info(ctx, "Scheduling meeting", zap.String("title", title), zap.Strings("attendees", attendees))
+// appendTurns adds turns to an existing session, logging rather than failing on errors
+func (s *Service) appendTurns(ctx context.Context, sessionID string, turns ...dto.ConversationTurn) {
+	session, err := s.loadSession(ctx, sessionID)
+	if err != nil {
+		s.logger.Warn(ctx, "Failed to add to session", zap.String("session_id", sessionID), zap.Error(err))
+		return
+	}
+	session.Turns = append(session.Turns, turns...)
+	if err := s.sessions.Save(ctx, session); err != nil {
+		s.logger.Error(ctx, "Failed to save session", zap.String("session_id", sessionID), zap.Error(err))
+	}
+}
 
-	// Add the user to attendees if not already present
-	userInAttendees := false
-	for _, attendee := range attendees {
-		if attendee == s.config.UserEmail {
-			userInAttendees = true
-			break
+// loadSession returns the stored session, or a new one when the ID is empty.
+// Session IDs are only issued here, so an unknown or expired ID is not found
+// rather than adopted.
+func (s *Service) loadSession(ctx context.Context, sessionID string) (dto.Session, error) {
+	if sessionID != "" {
+		session, ok, err := s.sessions.Get(ctx, sessionID)
+		if err != nil {
+			s.logger.Error(ctx, "Failed to load session", zap.String("session_id", sessionID), zap.Error(err))
+			return dto.Session{}, err
 		}
+		if !ok {
+			return dto.Session{}, fmt.Errorf("%w: session %q is unknown or has expired", errors.ErrNotFound, sessionID)
+		}
+		return session, nil
+	}
+
+	id, err := randomID()
+	if err != nil {
+		s.logger.Error(ctx, "Failed to generate session ID", zap.Error(err))
+		return dto.Session{}, err
 	}
-	if !userInAttendees {
-		attendees = append(attendees, s.config.UserEmail)
+	return dto.Session{ID: id}, nil
+}
+
+// randomID returns a random hex identifier for sessions and tokens
+func randomID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random ID: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// ScheduleMeeting schedules a meeting after checking the calendars of the user
+// and the attendees for conflicts, which are handled according to the
+// meeting's conflict policy or else the configured one. Recurring meetings are
+// checked over their occurrences in the next few weeks.
+func (s *Service) ScheduleMeeting(ctx context.Context, meeting dto.Meeting) (dto.ScheduleResult, error) {
+	s.logger.Info(ctx, "Scheduling meeting", zap.String("title", meeting.Title), zap.Strings("attendees", meeting.Attendees))
+
+	if err := s.validateMeeting(meeting); err != nil {
+		return dto.ScheduleResult{}, err
+	}
+
+	policy := meeting.ConflictPolicy
+	if policy == "" {
+		policy = s.config.ConflictPolicy
+	}
+	switch policy {
+	case "", dto.ConflictReject, dto.ConflictWarn, dto.ConflictPropose:
+	default:
+		return dto.ScheduleResult{}, fmt.Errorf("%w: unknown conflict policy %q", errors.ErrInvalidInput, policy)
+	}
+
+	if meeting.Calendar != "" {
+		cal, err := s.namedCalendar(meeting.Calendar)
+		if err != nil {
+			return dto.ScheduleResult{}, err
+		}
+		if !cal.Write {
+			return dto.ScheduleResult{}, fmt.Errorf("%w: calendar %q is read-only", errors.ErrActionNotAllowed, cal.Name)
+		}
+		meeting.Calendar = cal.Name
+	}
+
+	// Add the user to attendees if not already present
+	attendees := s.withUser(meeting.Attendees)
+
+	slots, err := meetingSlots(meeting.StartTime.In(s.location()), meeting.Duration, meeting.Recurrence)
+	if err != nil {
+		return dto.ScheduleResult{}, err
+	}
+	conflicts, err := s.conflicts(ctx, attendees, slots)
+	if err != nil {
+		return dto.ScheduleResult{}, err
+	}
+
+	outcome := dto.OutcomeScheduled
+	if len(conflicts) > 0 {
+		s.logger.Warn(ctx, "Meeting conflicts with busy time",
+			zap.String("policy", policy), zap.Int("conflicts", len(conflicts)))
+
+		switch policy {
+		case dto.ConflictWarn:
+			outcome = dto.OutcomeScheduledWithConflicts
+		case dto.ConflictPropose:
+			alternative, err := s.nearestFreeSlot(ctx, attendees, meeting.StartTime, meeting.Duration)
+			if err != nil {
+				return dto.ScheduleResult{}, err
+			}
+			return dto.ScheduleResult{}, &dto.ConflictError{Outcome: dto.OutcomeAlternativeProposed, Conflicts: conflicts, Alternative: alternative}
+		default:
+			return dto.ScheduleResult{}, &dto.ConflictError{Outcome: dto.OutcomeRejected, Conflicts: conflicts}
+		}
 	}
 
 	// Schedule the meeting
-	err := s.calendar.ScheduleMeeting(ctx, attendees, startTime, duration, title)
+	event, err := s.calendar.ScheduleMeeting(ctx, meetingEvent(meeting, attendees, s.config.UserEmail))
 	if err != nil {
 		s.logger.Error(ctx, "Failed to schedule meeting", zap.Error(err))
-		return err
+		return dto.ScheduleResult{}, err
 	}
 
 	// Send confirmation email to attendees
-	emailBody := fmt.Sprintf(`
-		<h2>Meeting Scheduled</h2>
-		<p><strong>Title:</strong> %s</p>
-		<p><strong>Time:</strong> %s</p>
-		<p><strong>Duration:</strong> %d minutes</p>
-		<p><strong>Attendees:</strong> %s</p>
-		<p>This meeting has been automatically scheduled by your AI assistant.</p>
-	`, title, startTime.Format("Monday, January 2, 2006 at 3:04 PM"), int(duration.Minutes()), strings.Join(attendees, ", "))
+	s.notifyAttendees(ctx, attendees, meetingSubject("scheduled", meeting.Title),
+		meetingEmailBody("scheduled", event, s.location()))
+
+	s.logger.Info(ctx, "Successfully scheduled meeting and sent confirmations", zap.String("event_id", event.ID))
+	return dto.ScheduleResult{Event: event, Outcome: outcome, Conflicts: conflicts}, nil
+}
 
+// validateMeeting rejects meetings that could never take place, before any
+// time is spent checking them for conflicts.
+func (s *Service) validateMeeting(meeting dto.Meeting) error {
+	if meeting.Duration <= 0 {
+		return fmt.Errorf("%w: duration must be positive", errors.ErrInvalidInput)
+	}
+	if meeting.StartTime.Before(s.now()) {
+		return fmt.Errorf("%w: start time %s is in the past", errors.ErrInvalidInput, meeting.StartTime.In(s.location()).Format(time.RFC3339))
+	}
+	return nil
+}
+
+// meetingEvent is the event booked for a meeting request
+func meetingEvent(meeting dto.Meeting, attendees []string, organizer string) dto.Event {
+	return dto.Event{
+		Title:       meeting.Title,
+		Description: meeting.Description,
+		Location:    meeting.Location,
+		Attendees:   attendees,
+		Organizer:   organizer,
+		StartTime:   meeting.StartTime,
+		EndTime:     meeting.StartTime.Add(meeting.Duration),
+		Recurrence:  meeting.Recurrence,
+		Calendar:    meeting.Calendar,
+	}
+}
+
+// withUser adds the user to the attendee list if not already present
+func (s *Service) withUser(attendees []string) []string {
 	for _, attendee := range attendees {
-		if attendee != s.config.UserEmail { // Don't send email to self
-			err := s.email.SendEmail(ctx, attendee, "Meeting Scheduled: "+title, emailBody)
-			if err != nil {
-				s.logger.Error(ctx, "Failed to send meeting confirmation email", zap.String("attendee", attendee), zap.Error(err))
-			}
+		if attendee == s.config.UserEmail {
+			return attendees
 		}
 	}
+	return append(append([]string(nil), attendees...), s.config.UserEmail)
+}
 
-	s.logger.Info(ctx, "Successfully scheduled meeting and sent confirmations")
-	return nil
+// meetingEmailBody renders the notice sent to attendees when a meeting is
+// scheduled, rescheduled or updated
+func meetingEmailBody(change string, event dto.Event, loc *time.Location) string {
+	var details strings.Builder
+	if !event.AllDay {
+		fmt.Fprintf(&details, "\n\t\t<p><strong>Duration:</strong> %d minutes</p>", int(event.EndTime.Sub(event.StartTime).Minutes()))
+	}
+	if len(event.Recurrence) > 0 {
+		fmt.Fprintf(&details, "\n\t\t<p><strong>Repeats:</strong> %s</p>", html.EscapeString(recurrence.Describe(event.Recurrence)))
+	}
+	if event.Location != "" {
+		fmt.Fprintf(&details, "\n\t\t<p><strong>Location:</strong> %s</p>", html.EscapeString(event.Location))
+	}
+	if event.ConferenceURL != "" {
+		fmt.Fprintf(&details, "\n\t\t<p><strong>Join:</strong> <a href=\"%[1]s\">%[1]s</a></p>", html.EscapeString(event.ConferenceURL))
+	}
+	if event.Description != "" {
+		fmt.Fprintf(&details, "\n\t\t<p>%s</p>", strings.ReplaceAll(html.EscapeString(event.Description), "\n", "<br>"))
+	}
+	return fmt.Sprintf(`
+		<h2>Meeting %s</h2>
+		<p><strong>Title:</strong> %s</p>
+		<p><strong>Time:</strong> %s</p>%s
+		<p><strong>Attendees:</strong> %s</p>
+		<p>This meeting has been automatically %s by your AI assistant.</p>
+	`, capitalize(change), html.EscapeString(event.Title), describeTime(event, loc), details.String(), html.EscapeString(strings.Join(event.Attendees, ", ")), change)
 }
 
-// SendEmail sends an email with AI-generated content
-func (s *Service) SendEmail(ctx context.Context, toEmail string, subject string, body string) error {
-	s.logger.Info(ctx, "Sending email", zap.String("to", toEmail), zap.String("subject", subject))
+// SendEmail sends an email, writing its body with AI when it has none
+func (s *Service) SendEmail(ctx context.Context, message dto.EmailMessage) error {
+	s.logger.Info(ctx, "Sending email", zap.Strings("to", message.To), zap.String("subject", message.Subject))
 
 	// If body is empty, generate content using AI
-	if body == "" {
-		generatedBody, err := s.gemini.ProcessCommand(ctx, fmt.Sprintf("Generate a professional email body for subject: %s", subject))
+	if message.Text == "" && message.HTML == "" {
+		generatedBody, err := s.generateEmailBody(ctx, message.Subject)
 		if err != nil {
-			s.logger.Error(ctx, "Failed to generate email body", zap.Error(err))
 			return err
 		}
-		body = generatedBody
+		message.Text = generatedBody
 	}
 
-	return s.email.SendEmail(ctx, toEmail, subject, body)
+	return s.email.SendEmail(ctx, message)
 }
 
-// GetUpcomingEvents retrieves and formats upcoming events
-func (s *Service) GetUpcomingEvents(ctx context.Context) ([]dto.Event, error) {
-	s.logger.Info(ctx, "Getting upcoming events")
+// generateEmailBody writes an email body for the subject using AI
+func (s *Service) generateEmailBody(ctx context.Context, subject string) (string, error) {
+	if s.llm == nil {
+		return "", fmt.Errorf("%w: cannot write an email body, please provide one", errors.ErrLLMUnavailable)
+	}
 
-	events, err := s.calendar.GetUpcomingEvents(ctx)
+	body, err := s.llm.ProcessCommand(ctx, fmt.Sprintf("Generate a professional email body for subject: %s", subject))
 	if err != nil {
-		s.logger.Error(ctx, "Failed to get upcoming events", zap.Error(err))
-		return nil, err
+		s.logger.Error(ctx, "Failed to generate email body", zap.Error(err))
+		return "", err
 	}
+	return body, nil
+}
 
-	s.logger.Info(ctx, "Successfully retrieved events", zap.Int("count", len(events)))
-	return events, nil
+// GetUpcomingEvents retrieves the events of the next seven days
+func (s *Service) GetUpcomingEvents(ctx context.Context) ([]dto.Event, error) {
+	page, err := s.ListEvents(ctx, dto.EventQuery{})
+	if err != nil {
+		return nil, err
+	}
+	return page.Events, nil
 }
 
-// SendDailyReminder sends a daily summary of upcoming events
-func (s *Service) SendDailyReminder(ctx context.Context) error {
-	s.logger.Info(ctx, "Sending daily reminder")
+// maxEventsPerPage bounds the page size a caller may ask for.
+const maxEventsPerPage = 250
 
-	events, err := s.calendar.GetUpcomingEvents(ctx)
-	if err != nil {
-		s.logger.Error(ctx, "Failed to get events for daily reminder", zap.Error(err))
-		return err
+// ListEvents returns a page of the events matching query. A zero From is now
+// and a zero To is seven days after From.
+func (s *Service) ListEvents(ctx context.Context, query dto.EventQuery) (dto.EventPage, error) {
+	if query.From.IsZero() {
+		query.From = s.now()
+	}
+	if query.To.IsZero() {
+		query.To = query.From.AddDate(0, 0, 7)
+	}
+	if !query.To.After(query.From) {
+		return dto.EventPage{}, fmt.Errorf("%w: to must be after from", errors.ErrInvalidInput)
+	}
+	if query.Limit < 0 || query.Limit > maxEventsPerPage {
+		return dto.EventPage{}, fmt.Errorf("%w: limit must be between 1 and %d", errors.ErrInvalidInput, maxEventsPerPage)
+	}
+	if query.Calendar != "" {
+		cal, err := s.namedCalendar(query.Calendar)
+		if err != nil {
+			return dto.EventPage{}, err
+		}
+		if !cal.Read {
+			return dto.EventPage{}, fmt.Errorf("%w: calendar %q cannot be read", errors.ErrActionNotAllowed, cal.Name)
+		}
+		query.Calendar = cal.Name
 	}
 
-	// Generate reminder content using AI
-	var eventList strings.Builder
-	for _, event := range events {
-		eventList.WriteString(fmt.Sprintf("- %s at %s\n", event.Title, event.StartTime.Format("3:04 PM")))
+	s.logger.Info(ctx, "Getting events",
+		zap.Time("from", query.From),
+		zap.Time("to", query.To),
+		zap.String("query", query.Query),
+		zap.String("attendee", query.Attendee),
+		zap.String("calendar", query.Calendar),
+		zap.Int("limit", query.Limit))
+
+	page, err := s.calendar.ListEvents(ctx, query)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to get events", zap.Error(err))
+		return dto.EventPage{}, err
 	}
 
-	prompt := fmt.Sprintf(`
-Generate a friendly daily reminder email for the following upcoming events:
+	s.logger.Info(ctx, "Successfully retrieved events", zap.Int("count", len(page.Events)))
+	return page, nil
+}
 
-%s
+// ListCalendars returns the configured calendars, which is empty when the
+// provider's single calendar is used.
+func (s *Service) ListCalendars(ctx context.Context) []dto.Calendar {
+	return s.config.Calendars
+}
 
-Make it professional but warm, and include any relevant tips for the day.
-`, eventList.String())
+// namedCalendar returns the configured calendar called name.
+func (s *Service) namedCalendar(name string) (dto.Calendar, error) {
+	for _, cal := range s.config.Calendars {
+		if strings.EqualFold(cal.Name, name) {
+			return cal, nil
+		}
+	}
+	if len(s.config.Calendars) == 0 {
+		return dto.Calendar{}, fmt.Errorf("%w: no calendar named %q, only one calendar is configured", errors.ErrInvalidInput, name)
+	}
+	return dto.Calendar{}, fmt.Errorf("%w: no calendar named %q", errors.ErrInvalidInput, name)
+}
 
-	reminderBody, err := s.gemini.ProcessCommand(ctx, prompt)
+// SendDailyReminder sends a daily summary of upcoming events
+func (s *Service) SendDailyReminder(ctx context.Context) error {
+	s.logger.Info(ctx, "Sending daily reminder")
+
+	digest, err := s.dailyDigest(ctx)
 	if err != nil {
-		s.logger.Error(ctx, "Failed to generate reminder content", zap.Error(err))
 		return err
 	}
 
 	// Send the reminder
-	err = s.email.SendEmail(ctx, s.config.UserEmail, "Your Daily Schedule Reminder", reminderBody)
+	err = s.email.SendEmail(ctx, dto.EmailMessage{
+		To:      []string{s.config.UserEmail},
+		Subject: "Your Daily Schedule Reminder",
+		Text:    digest,
+	})
 	if err != nil {
 		s.logger.Error(ctx, "Failed to send daily reminder", zap.Error(err))
 		return err
@@ -186,31 +397,38 @@ Make it professional but warm, and include any relevant tips for the day.
 	return nil
 }
 
-// executeAction parses the AI response and executes the appropriate action
-func (s *Service) executeAction(ctx context.Context, aiResponse string) (string, error) {
-	// This is a simplified implementation - in a real system, you'd want proper JSON parsing
-	// For now, we'll use simple string matching
-
-	if strings.Contains(aiResponse, "schedule_meeting") {
-		// Extract meeting details from AI response and schedule
-		// This is a simplified version - you'd want proper JSON parsing
-		return "Meeting scheduled successfully!", nil
-	} else if strings.Contains(aiResponse, "send_email") {
-		// Extract email details and send
-		return "Email sent successfully!", nil
-	} else if strings.Contains(aiResponse, "get_events") {
-		events, err := s.GetUpcomingEvents(ctx)
-		if err != nil {
-			return "", err
-		}
+// dailyDigest writes the plain text summary of upcoming events that the daily
+// reminder sends
+func (s *Service) dailyDigest(ctx context.Context) (string, error) {
+	events, err := s.GetUpcomingEvents(ctx)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to get events for daily reminder", zap.Error(err))
+		return "", err
+	}
 
-		var eventList strings.Builder
-		eventList.WriteString("Upcoming events:\n")
-		for _, event := range events {
-			eventList.WriteString(fmt.Sprintf("- %s at %s\n", event.Title, event.StartTime.Format("3:04 PM")))
-		}
-		return eventList.String(), nil
+	// Generate reminder content using AI
+	var eventList strings.Builder
+	for _, event := range events {
+		eventList.WriteString("- " + eventSummary(event, s.location()) + "\n")
 	}
 
-	return "Command processed successfully!", nil
+	// Without a model, send the plain event list
+	if s.llm == nil {
+		return "Your upcoming events:\n\n" + eventList.String(), nil
+	}
+
+	prompt := fmt.Sprintf(`
+Generate a friendly daily reminder email, in plain text, for the following upcoming events:
+
+%s
+
+Make it professional but warm, and include any relevant tips for the day.
+`, eventList.String())
+
+	digest, err := s.llm.ProcessCommand(ctx, prompt)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to generate reminder content", zap.Error(err))
+		return "", err
+	}
+	return digest, nil
 }
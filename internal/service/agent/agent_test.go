@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"context"
+	stderrors "errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScheduleMeetingRejectsImpossibleMeetings(t *testing.T) {
+	tests := []struct {
+		name     string
+		start    time.Duration
+		duration time.Duration
+	}{
+		{"zero duration", time.Hour, 0},
+		{"negative duration", time.Hour, -30 * time.Minute},
+		{"yesterday", -24 * time.Hour, 30 * time.Minute},
+		{"earlier today", -time.Minute, 30 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, email := newTestService(t, &scriptedLLM{}, dto.Config{})
+			meeting := dto.Meeting{
+				Title:     "Budget review",
+				Attendees: []string{"bob@example.com"},
+				StartTime: s.now().Add(tt.start),
+				Duration:  tt.duration,
+			}
+
+			if _, err := s.ScheduleMeeting(ctx, meeting); !stderrors.Is(err, errors.ErrInvalidInput) {
+				t.Errorf("ScheduleMeeting error = %v, want ErrInvalidInput", err)
+			}
+			if len(email.sent) != 0 {
+				t.Errorf("invitations sent: %+v", email.sent)
+			}
+
+			s, _ = newTestService(t, &scriptedLLM{}, dto.Config{ConfirmActions: []string{dto.ActionScheduleMeeting}})
+			if _, planned, err := s.PlanMeeting(ctx, meeting); planned || !stderrors.Is(err, errors.ErrInvalidInput) {
+				t.Errorf("PlanMeeting = %v, %v; want ErrInvalidInput", planned, err)
+			}
+		})
+	}
+}
+
+func TestScheduleMeetingInThePastIsObserved(t *testing.T) {
+	llm := &scriptedLLM{replies: []string{
+		`{"action": "schedule_meeting", "parameters": {"title": "Budget review", "attendees": ["bob@example.com"], "time_expression": "yesterday at 3pm"}}`,
+		`{"action": "final_answer", "answer": "That time has already passed."}`,
+	}}
+	s, email := newTestService(t, llm, dto.Config{})
+
+	result, err := s.ProcessNaturalLanguageCommand(context.Background(), dto.NaturalLanguageRequest{
+		Command: "book the budget review with bob",
+	})
+	if err != nil {
+		t.Fatalf("ProcessNaturalLanguageCommand failed: %v", err)
+	}
+	if result.Result != "That time has already passed." {
+		t.Errorf("result = %q", result.Result)
+	}
+	if len(llm.prompts) != 2 || !strings.Contains(llm.prompts[1], "Result: error: ") || !strings.Contains(llm.prompts[1], "in the past") {
+		t.Errorf("prompts = %q, want the past start observed as an error", llm.prompts)
+	}
+	if len(email.sent) != 0 {
+		t.Errorf("invitations sent: %+v", email.sent)
+	}
+}
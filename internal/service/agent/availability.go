@@ -0,0 +1,193 @@
+package agent
+
+import (
+	"ai_agent/internal/availability"
+	"ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/recurrence"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// maxAvailabilityWindow bounds the span of an availability search.
+const maxAvailabilityWindow = 60 * 24 * time.Hour
+
+// FindAvailability returns ranked slots when the user and the attendees are
+// all free. Unset fields fall back to the next seven days, the configured
+// working hours and the configured meeting buffer. Windows longer than
+// maxAvailabilityWindow are refused.
+func (s *Service) FindAvailability(ctx context.Context, req dto.AvailabilityRequest) ([]dto.Slot, error) {
+	req = s.withAvailabilityDefaults(req)
+	calendars := s.withUser(req.Attendees)
+
+	s.logger.Info(ctx, "Finding availability",
+		zap.Strings("calendars", calendars),
+		zap.Duration("duration", req.Duration),
+		zap.Time("window_start", req.WindowStart),
+		zap.Time("window_end", req.WindowEnd))
+
+	if req.Duration <= 0 || !req.WindowEnd.After(req.WindowStart) {
+		return nil, fmt.Errorf("%w: duration must be positive and the window must end after it starts", errors.ErrInvalidInput)
+	}
+	if req.WindowEnd.Sub(req.WindowStart) > maxAvailabilityWindow {
+		return nil, fmt.Errorf("%w: the window can span at most %d days", errors.ErrInvalidInput, int(maxAvailabilityWindow.Hours()/24))
+	}
+
+	busyByCalendar, err := s.calendar.FreeBusy(ctx, calendars, req.WindowStart, req.WindowEnd)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to get free/busy", zap.Error(err))
+		return nil, err
+	}
+
+	var busy []dto.TimeRange
+	for _, ranges := range busyByCalendar {
+		busy = append(busy, ranges...)
+	}
+
+	slots, err := availability.FindSlots(busy, req, s.location())
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info(ctx, "Found available slots", zap.Int("count", len(slots)))
+	return slots, nil
+}
+
+func (s *Service) withAvailabilityDefaults(req dto.AvailabilityRequest) dto.AvailabilityRequest {
+	if req.WindowStart.IsZero() {
+		req.WindowStart = s.now()
+	}
+	if req.WindowEnd.IsZero() {
+		req.WindowEnd = req.WindowStart.AddDate(0, 0, 7)
+	}
+	if req.WorkdayStart == "" {
+		req.WorkdayStart = s.config.WorkdayStart
+	}
+	if req.WorkdayEnd == "" {
+		req.WorkdayEnd = s.config.WorkdayEnd
+	}
+	buffer := time.Duration(s.config.MeetingBufferMinutes) * time.Minute
+	if req.BufferBefore == 0 {
+		req.BufferBefore = buffer
+	}
+	if req.BufferAfter == 0 {
+		req.BufferAfter = buffer
+	}
+	return req
+}
+
+// conflictHorizon is how far ahead occurrences of a recurring meeting are
+// checked for conflicts.
+const conflictHorizon = 28 * 24 * time.Hour
+
+// meetingSlots returns the times a meeting occupies: its first occurrence, or
+// for a recurring meeting its occurrences within conflictHorizon. start should
+// be in the configured zone, which recurrences are expanded in.
+func meetingSlots(start time.Time, duration time.Duration, rrule []string) ([]dto.TimeRange, error) {
+	if len(rrule) == 0 {
+		return []dto.TimeRange{{Start: start, End: start.Add(duration)}}, nil
+	}
+	starts, err := recurrence.Expand(rrule, start, duration, start, start.Add(conflictHorizon))
+	if err != nil {
+		return nil, err
+	}
+	slots := make([]dto.TimeRange, 0, len(starts))
+	for _, t := range starts {
+		slots = append(slots, dto.TimeRange{Start: t, End: t.Add(duration)})
+	}
+	return slots, nil
+}
+
+// conflicts returns the busy time on the calendars that overlaps any of the
+// slots, which must be sorted. Conflicts on the user's calendar are labelled
+// with the events causing them. Calendars the provider cannot read are not
+// checked.
+func (s *Service) conflicts(ctx context.Context, calendars []string, slots []dto.TimeRange) ([]dto.Conflict, error) {
+	if len(slots) == 0 {
+		return nil, nil
+	}
+	busyByCalendar, err := s.calendar.FreeBusy(ctx, calendars, slots[0].Start, slots[len(slots)-1].End)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to get free/busy", zap.Error(err))
+		return nil, err
+	}
+
+	var conflicts []dto.Conflict
+	for _, calendar := range calendars {
+		for _, busy := range busyByCalendar[calendar] {
+			if overlapsAny(slots, busy) {
+				conflicts = append(conflicts, dto.Conflict{Calendar: calendar, Start: busy.Start, End: busy.End})
+			}
+		}
+	}
+
+	isUsers := func(c dto.Conflict) bool { return c.Calendar == s.config.UserEmail }
+	if !slices.ContainsFunc(conflicts, isUsers) {
+		return conflicts, nil
+	}
+
+	// The busy time is still reported if its events cannot be listed
+	page, err := s.ListEvents(ctx, dto.EventQuery{From: slots[0].Start, To: slots[len(slots)-1].End})
+	if err != nil {
+		return conflicts, nil
+	}
+	events := page.Events
+	for i, conflict := range conflicts {
+		if !isUsers(conflict) {
+			continue
+		}
+		for _, event := range events {
+			if event.StartTime.Before(conflict.End) && event.EndTime.After(conflict.Start) &&
+				overlapsAny(slots, dto.TimeRange{Start: event.StartTime, End: event.EndTime}) {
+				conflicts[i].EventID, conflicts[i].Title = event.ID, event.Title
+				break
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+func overlapsAny(slots []dto.TimeRange, r dto.TimeRange) bool {
+	for _, slot := range slots {
+		if slot.Start.Before(r.End) && slot.End.After(r.Start) {
+			return true
+		}
+	}
+	return false
+}
+
+// nearestFreeSlot looks up to a week either side of start for the closest time
+// everyone is free, never earlier than now.
+func (s *Service) nearestFreeSlot(ctx context.Context, calendars []string, start time.Time, duration time.Duration) (*dto.TimeRange, error) {
+	req := s.withAvailabilityDefaults(dto.AvailabilityRequest{
+		Duration:    duration,
+		WindowStart: start.AddDate(0, 0, -7),
+		WindowEnd:   start.AddDate(0, 0, 7),
+	})
+	if now := s.now(); req.WindowStart.Before(now) {
+		req.WindowStart = now
+	}
+	if !req.WindowEnd.After(req.WindowStart) {
+		return nil, nil
+	}
+
+	busyByCalendar, err := s.calendar.FreeBusy(ctx, calendars, req.WindowStart, req.WindowEnd)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to get free/busy", zap.Error(err))
+		return nil, err
+	}
+	var busy []dto.TimeRange
+	for _, ranges := range busyByCalendar {
+		busy = append(busy, ranges...)
+	}
+
+	slot, ok, err := availability.Nearest(busy, req, s.location(), start)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &dto.TimeRange{Start: slot.Start, End: slot.End}, nil
+}
@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+)
+
+func TestFindAvailabilityWindow(t *testing.T) {
+	s, _ := newTestService(t, &scriptedLLM{}, dto.Config{WorkdayStart: "09:00", WorkdayEnd: "17:00"})
+	from := s.now()
+
+	tests := []struct {
+		name    string
+		to      time.Time
+		wantErr error
+	}{
+		{"default week", time.Time{}, nil},
+		{"longest window", from.Add(maxAvailabilityWindow), nil},
+		{"too long", from.Add(maxAvailabilityWindow + time.Minute), errors.ErrInvalidInput},
+		{"ends before it starts", from.Add(-time.Hour), errors.ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slots, err := s.FindAvailability(context.Background(), dto.AvailabilityRequest{
+				Duration:    30 * time.Minute,
+				WindowStart: from,
+				WindowEnd:   tt.to,
+			})
+			if tt.wantErr != nil {
+				if !stderrors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || len(slots) == 0 {
+				t.Errorf("FindAvailability = %v, %v; want slots", slots, err)
+			}
+		})
+	}
+}
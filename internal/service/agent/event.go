@@ -0,0 +1,400 @@
+package agent
+
+import (
+	"ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/datetime"
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.uber.org/zap"
+)
+
+// GetEvent retrieves a single calendar event
+func (s *Service) GetEvent(ctx context.Context, id string) (dto.Event, error) {
+	event, err := s.calendar.GetEvent(ctx, id)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to get event", zap.String("event_id", id), zap.Error(err))
+		return dto.Event{}, err
+	}
+	return event, nil
+}
+
+// UpdateEvent applies changes to an event and notifies its attendees. Attendees
+// removed from the event are told it is cancelled for them.
+func (s *Service) UpdateEvent(ctx context.Context, id string, update dto.EventUpdate) (dto.Event, error) {
+	s.logger.Info(ctx, "Updating event", zap.String("event_id", id))
+
+	previous, err := s.GetEvent(ctx, id)
+	if err != nil {
+		return dto.Event{}, err
+	}
+
+	updated, err := s.calendar.UpdateEvent(ctx, s.applyUpdate(previous, update))
+	if err != nil {
+		s.logger.Error(ctx, "Failed to update event", zap.String("event_id", id), zap.Error(err))
+		return dto.Event{}, err
+	}
+
+	s.notifyAttendees(ctx, updated.Attendees, meetingSubject("updated", updated.Title),
+		meetingEmailBody("updated", updated, s.location()))
+
+	var removed []string
+	for _, attendee := range previous.Attendees {
+		if !contains(updated.Attendees, attendee) {
+			removed = append(removed, attendee)
+		}
+	}
+	s.notifyAttendees(ctx, removed, meetingSubject("cancelled", previous.Title),
+		cancelledEmailBody(previous, s.location()))
+
+	s.logger.Info(ctx, "Successfully updated event", zap.String("event_id", id))
+	return updated, nil
+}
+
+// applyUpdate returns the event with the changes in update made.
+func (s *Service) applyUpdate(event dto.Event, update dto.EventUpdate) dto.Event {
+	duration := event.EndTime.Sub(event.StartTime)
+	if update.Duration > 0 {
+		duration = update.Duration
+	}
+	if update.Title != "" {
+		event.Title = update.Title
+	}
+	if update.Attendees != nil {
+		event.Attendees = s.withUser(update.Attendees)
+	}
+	if !update.StartTime.IsZero() {
+		event.StartTime = update.StartTime
+	}
+	if event.AllDay && (!update.StartTime.IsZero() || update.Duration > 0) {
+		// Moved off midnight or given a length in hours, it is no longer all-day
+		start := event.StartTime.In(s.location())
+		if !start.Equal(time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())) || duration%(24*time.Hour) != 0 {
+			event.AllDay = false
+		}
+	}
+	event.EndTime = event.StartTime.Add(duration)
+	return event
+}
+
+// RescheduleMeeting moves a meeting and notifies its attendees. A zero duration
+// keeps the meeting's current length.
+func (s *Service) RescheduleMeeting(ctx context.Context, id string, startTime time.Time, duration time.Duration) (dto.Event, error) {
+	s.logger.Info(ctx, "Rescheduling meeting", zap.String("event_id", id), zap.Time("start_time", startTime))
+
+	event, err := s.calendar.Reschedule(ctx, id, startTime, duration)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to reschedule meeting", zap.String("event_id", id), zap.Error(err))
+		return dto.Event{}, err
+	}
+
+	s.notifyAttendees(ctx, event.Attendees, meetingSubject("rescheduled", event.Title),
+		meetingEmailBody("rescheduled", event, s.location()))
+
+	s.logger.Info(ctx, "Successfully rescheduled meeting", zap.String("event_id", id))
+	return event, nil
+}
+
+// CancelMeeting cancels a meeting and notifies its attendees
+func (s *Service) CancelMeeting(ctx context.Context, id string) error {
+	s.logger.Info(ctx, "Cancelling meeting", zap.String("event_id", id))
+
+	event, err := s.GetEvent(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.calendar.Cancel(ctx, id); err != nil {
+		s.logger.Error(ctx, "Failed to cancel meeting", zap.String("event_id", id), zap.Error(err))
+		return err
+	}
+
+	s.notifyAttendees(ctx, event.Attendees, meetingSubject("cancelled", event.Title),
+		cancelledEmailBody(event, s.location()))
+
+	s.logger.Info(ctx, "Successfully cancelled meeting", zap.String("event_id", id))
+	return nil
+}
+
+// notifyAttendees queues email to everyone but the user, logging rather than failing on errors
+func (s *Service) notifyAttendees(ctx context.Context, attendees []string, subject, body string) {
+	for _, attendee := range s.recipients(attendees) {
+		if err := s.email.SendEmail(ctx, dto.EmailMessage{To: []string{attendee}, Subject: subject, HTML: body}); err != nil {
+			s.logger.Error(ctx, "Failed to send meeting notification", zap.String("attendee", attendee), zap.Error(err))
+		}
+	}
+}
+
+// recipients returns the attendees notified about a meeting, which excludes the user
+func (s *Service) recipients(attendees []string) []string {
+	var recipients []string
+	for _, attendee := range attendees {
+		if attendee != s.config.UserEmail {
+			recipients = append(recipients, attendee)
+		}
+	}
+	return recipients
+}
+
+// lookupEvent returns the event an action refers to, by ID or by description.
+func (s *Service) lookupEvent(ctx context.Context, params dto.ActionParameters) (dto.Event, error) {
+	if params.EventID != "" {
+		return s.GetEvent(ctx, params.EventID)
+	}
+	return s.findEvent(ctx, params.Event)
+}
+
+// resolveReschedule finds the event a reschedule action refers to and its new
+// start time. A time expression without a date keeps the event's date, and one
+// without a time keeps its time of day.
+func (s *Service) resolveReschedule(ctx context.Context, params dto.ActionParameters) (dto.Event, time.Time, error) {
+	event, err := s.lookupEvent(ctx, params)
+	if err != nil {
+		return dto.Event{}, time.Time{}, err
+	}
+
+	if params.TimeExpression != "" {
+		startTime, err := datetime.NewResolver(s.location(), s.now).ResolveFrom(params.TimeExpression, event.StartTime)
+		if err == nil {
+			return event, startTime, nil
+		}
+		if params.StartTime == "" {
+			return dto.Event{}, time.Time{}, fmt.Errorf("%w: %v", errors.ErrInvalidActionParameters, err)
+		}
+		s.logger.Warn(ctx, "Could not resolve time expression, keeping model start_time",
+			zap.String("time_expression", params.TimeExpression), zap.Error(err))
+	}
+
+	startTime, _ := time.Parse(time.RFC3339, params.StartTime)
+	return event, startTime.In(s.location()), nil
+}
+
+// eventSearchHorizon is how far ahead findEvent looks for an event described
+// by its title.
+const eventSearchHorizon = 90 * 24 * time.Hour
+
+// findEvent picks the upcoming event the user describes: by its start time
+// ("3pm", "tomorrow at 10"), by words from its title ("the client call") or by
+// both ("the review on november 20"). Events are looked for up to
+// eventSearchHorizon ahead, or up to the time described when that is later.
+func (s *Service) findEvent(ctx context.Context, description string) (dto.Event, error) {
+	loc := s.location()
+	resolver := datetime.NewResolver(loc, s.now)
+	ref := normalizeEventRef(description)
+
+	title, when := ref, ""
+	if i := strings.LastIndex(ref, " on "); i > 0 {
+		if _, err := resolver.Resolve(ref[i+len(" on "):]); err == nil {
+			title, when = ref[:i], ref[i+len(" on "):]
+		}
+	}
+
+	from := s.now()
+	to := from.Add(eventSearchHorizon)
+	for _, expression := range []string{ref, when} {
+		if at, err := resolver.Resolve(expression); err == nil && !at.Before(to) {
+			to = at.AddDate(0, 0, 1)
+		}
+	}
+	page, err := s.ListEvents(ctx, dto.EventQuery{From: from, To: to})
+	if err != nil {
+		return dto.Event{}, err
+	}
+	events := page.Events
+
+	var matches []dto.Event
+	if when == "" {
+		matches = eventsAtTime(events, ref, resolver)
+	}
+	if len(matches) == 0 {
+		day, _ := resolver.Resolve(when)
+		wanted := words(title)
+		for _, event := range events {
+			if len(wanted) == 0 || !containsAll(words(event.Title), wanted) {
+				continue
+			}
+			if when == "" || sameDay(event.StartTime.In(loc), day) {
+				matches = append(matches, event)
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return dto.Event{}, fmt.Errorf("%w: no upcoming event matches %q", errors.ErrNotFound, description)
+	case 1:
+		return matches[0], nil
+	}
+
+	options := make([]string, 0, len(matches))
+	for _, event := range matches {
+		options = append(options, eventSummary(event, s.location()))
+	}
+	return dto.Event{}, fmt.Errorf("%w: %q matches several events: %s",
+		errors.ErrInvalidActionParameters, description, strings.Join(options, "; "))
+}
+
+// eventsAtTime returns the events starting at the time ref resolves to or,
+// failing that, the next event at the same time of day.
+func eventsAtTime(events []dto.Event, ref string, resolver *datetime.Resolver) []dto.Event {
+	at, err := resolver.Resolve(ref)
+	if err != nil {
+		return nil
+	}
+
+	var matches []dto.Event
+	for _, event := range events {
+		if event.StartTime.Equal(at) {
+			matches = append(matches, event)
+		}
+	}
+	if len(matches) > 0 {
+		return matches
+	}
+
+	for _, event := range events {
+		start := event.StartTime.In(resolver.Location())
+		if start.Hour() == at.Hour() && start.Minute() == at.Minute() {
+			if len(matches) == 0 || start.Before(matches[0].StartTime) {
+				matches = []dto.Event{event}
+			}
+		}
+	}
+	return matches
+}
+
+func sameDay(a, b time.Time) bool {
+	y1, m1, d1 := a.Date()
+	y2, m2, d2 := b.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
+
+// normalizeEventRef strips the words around an event description, so "my 3pm
+// meeting" becomes "3pm" and "the client call" becomes "client call".
+func normalizeEventRef(description string) string {
+	ref := strings.ToLower(strings.TrimSpace(description))
+	for _, prefix := range []string{"my ", "the ", "our ", "this ", "that "} {
+		ref = strings.TrimPrefix(ref, prefix)
+	}
+	if fields := strings.Fields(ref); len(fields) > 1 && contains([]string{"meeting", "call", "one"}, fields[len(fields)-1]) {
+		if _, err := datetime.NewResolver(time.UTC, nil).Resolve(strings.Join(fields[:len(fields)-1], " ")); err == nil {
+			ref = strings.Join(fields[:len(fields)-1], " ")
+		}
+	}
+	return ref
+}
+
+// words splits text into lowercase words, ignoring punctuation
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAll(values []string, wanted []string) bool {
+	for _, w := range wanted {
+		if !contains(values, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// meetingSubject is the subject line for a meeting notification
+func meetingSubject(change, title string) string {
+	return "Meeting " + capitalize(change) + ": " + title
+}
+
+// cancelledEmailBody renders the notice sent when a meeting is cancelled
+func cancelledEmailBody(event dto.Event, loc *time.Location) string {
+	return fmt.Sprintf(`
+		<h2>Meeting Cancelled</h2>
+		<p><strong>Title:</strong> %s</p>
+		<p><strong>Was scheduled for:</strong> %s</p>
+		<p>This meeting has been cancelled by your AI assistant.</p>
+	`, html.EscapeString(event.Title), describeTime(event, loc))
+}
+
+// describeTime says in full when an event happens in loc, for emails.
+func describeTime(event dto.Event, loc *time.Location) string {
+	const dateLayout = "Monday, January 2, 2006"
+	const timeLayout = dateLayout + " at 3:04 PM"
+
+	start, last := eventDays(event, loc)
+	switch {
+	case event.AllDay && last.After(start):
+		return start.Format(dateLayout) + " to " + last.Format(dateLayout) + " (all day)"
+	case event.AllDay:
+		return start.Format(dateLayout) + " (all day)"
+	case last.After(start):
+		return start.Format(timeLayout) + " to " + event.EndTime.In(loc).Format(timeLayout)
+	}
+	return start.Format(timeLayout)
+}
+
+// eventSummary describes an event on one line, for listings and digests.
+func eventSummary(event dto.Event, loc *time.Location) string {
+	const dateLayout = "Mon Jan 2"
+	const timeLayout = dateLayout + " 3:04 PM"
+
+	start, last := eventDays(event, loc)
+	var summary string
+	switch {
+	case event.AllDay && last.After(start):
+		summary = fmt.Sprintf("%s all day %s to %s", event.Title, start.Format(dateLayout), last.Format(dateLayout))
+	case event.AllDay:
+		summary = fmt.Sprintf("%s all day %s", event.Title, start.Format(dateLayout))
+	case last.After(start):
+		summary = fmt.Sprintf("%s from %s to %s", event.Title, start.Format(timeLayout), event.EndTime.In(loc).Format(timeLayout))
+	default:
+		summary = fmt.Sprintf("%s at %s", event.Title, start.Format(timeLayout))
+	}
+
+	var where []string
+	if event.Location != "" {
+		where = append(where, event.Location)
+	}
+	if event.ConferenceURL != "" {
+		where = append(where, event.ConferenceURL)
+	}
+	if len(where) > 0 {
+		summary += " (" + strings.Join(where, ", ") + ")"
+	}
+	return summary
+}
+
+// eventDays returns an event's start in loc and the start of the last day it
+// covers. An event ending at midnight does not cover the day that begins.
+func eventDays(event dto.Event, loc *time.Location) (time.Time, time.Time) {
+	start := event.StartTime.In(loc)
+	last := start
+	if event.EndTime.After(event.StartTime) {
+		last = event.EndTime.In(loc).Add(-time.Nanosecond)
+	}
+	last = time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, loc)
+	if last.Before(start) {
+		last = start
+	}
+	return start, last
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+)
+
+func TestFindEvent(t *testing.T) {
+	s, _ := newTestService(t, &scriptedLLM{}, dto.Config{})
+	ctx := context.Background()
+	loc := s.location()
+
+	// Both reviews are more than a week away, the second beyond the horizon
+	for _, start := range []time.Time{
+		time.Date(2026, 11, 20, 14, 0, 0, 0, loc),
+		time.Date(2027, 3, 10, 14, 0, 0, 0, loc),
+	} {
+		if _, err := s.calendar.ScheduleMeeting(ctx, dto.Event{
+			Title:     "Quarterly review",
+			Attendees: []string{"alice@example.com"},
+			StartTime: start,
+			EndTime:   start.Add(time.Hour),
+		}); err != nil {
+			t.Fatalf("failed to seed calendar: %v", err)
+		}
+	}
+
+	tests := []struct {
+		description string
+		// wantStart is the start of the event found; empty expects wantErr
+		wantStart string
+		wantErr   error
+	}{
+		{"standup", "2026-10-28T09:30:00-04:00", nil},
+		{"my 9:30 meeting", "2026-10-28T09:30:00-04:00", nil},
+		{"the quarterly review", "2026-11-20T14:00:00-05:00", nil},
+		{"november 20 at 2pm", "2026-11-20T14:00:00-05:00", nil},
+		{"the review on november 20", "2026-11-20T14:00:00-05:00", nil},
+		{"the review on march 10", "2027-03-10T14:00:00-05:00", nil},
+		{"the review on november 21", "", errors.ErrNotFound},
+		{"the retro", "", errors.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			event, err := s.findEvent(ctx, tt.description)
+			if tt.wantErr != nil {
+				if !stderrors.Is(err, tt.wantErr) {
+					t.Errorf("findEvent(%q) = %+v, %v; want %v", tt.description, event, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("findEvent(%q) failed: %v", tt.description, err)
+			}
+			if got := event.StartTime.In(loc).Format(time.RFC3339); got != tt.wantStart {
+				t.Errorf("findEvent(%q) found the event at %s, want %s", tt.description, got, tt.wantStart)
+			}
+		})
+	}
+}
+
+func TestConflictsNameEventsBeyondAWeek(t *testing.T) {
+	s, _ := newTestService(t, &scriptedLLM{}, dto.Config{})
+	ctx := context.Background()
+	start := time.Date(2026, 11, 20, 14, 0, 0, 0, s.location())
+	if _, err := s.calendar.ScheduleMeeting(ctx, dto.Event{
+		Title:     "Quarterly review",
+		Attendees: []string{"alice@example.com"},
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("failed to seed calendar: %v", err)
+	}
+
+	slot := dto.TimeRange{Start: start.Add(30 * time.Minute), End: start.Add(90 * time.Minute)}
+	conflicts, err := s.conflicts(ctx, []string{"alice@example.com"}, []dto.TimeRange{slot})
+	if err != nil {
+		t.Fatalf("conflicts failed: %v", err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Title != "Quarterly review" || conflicts[0].EventID == "" {
+		t.Errorf("conflicts = %+v, want the review named", conflicts)
+	}
+}
@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/ical"
+	"ai_agent/internal/recurrence"
+	"bytes"
+	"context"
+	"io"
+
+	"go.uber.org/zap"
+)
+
+// calendarName is shown by clients subscribed to the exported feed.
+const calendarName = "AI Executive Assistant"
+
+// ExportEvents returns the upcoming events as an iCalendar file, with
+// recurring events given as their individual occurrences.
+func (s *Service) ExportEvents(ctx context.Context) ([]byte, error) {
+	events, err := s.GetUpcomingEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := ical.Encode(&buf, events, ical.Options{Name: calendarName, Method: "PUBLISH", Location: s.location(), Now: s.now()}); err != nil {
+		s.logger.Error(ctx, "Failed to encode events", zap.Error(err))
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ImportEvents adds the events of an iCalendar file to the calendar. Nothing
+// is sent to attendees and conflicts are not checked, since the events already
+// exist elsewhere. Events that cannot be added are reported as skipped.
+func (s *Service) ImportEvents(ctx context.Context, r io.Reader) (dto.ImportResult, error) {
+	events, err := ical.Decode(r, s.location())
+	if err != nil {
+		s.logger.Warn(ctx, "Failed to decode iCalendar data", zap.Error(err))
+		return dto.ImportResult{}, err
+	}
+	s.logger.Info(ctx, "Importing events", zap.Int("count", len(events)))
+
+	var result dto.ImportResult
+	for _, event := range events {
+		skip := func(reason string) {
+			result.Skipped = append(result.Skipped, dto.SkippedEvent{UID: event.ID, Title: event.Title, Reason: reason})
+		}
+		if !event.EndTime.After(event.StartTime) {
+			skip("event ends before it starts")
+			continue
+		}
+		if len(event.Recurrence) > 0 {
+			if _, err := recurrence.Parse(event.Recurrence); err != nil {
+				skip(err.Error())
+				continue
+			}
+		}
+
+		// Imported events are standalone; the backend assigns new IDs
+		event.ID, event.RecurringEventID = "", ""
+		created, err := s.calendar.ScheduleMeeting(ctx, event)
+		if err != nil {
+			s.logger.Error(ctx, "Failed to import event", zap.String("title", event.Title), zap.Error(err))
+			skip(err.Error())
+			continue
+		}
+		result.Imported = append(result.Imported, created)
+	}
+
+	s.logger.Info(ctx, "Imported events",
+		zap.Int("imported", len(result.Imported)),
+		zap.Int("skipped", len(result.Skipped)))
+	return result, nil
+}
@@ -0,0 +1,225 @@
+package agent
+
+import (
+	"ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/datetime"
+	"ai_agent/internal/intent"
+	"ai_agent/internal/schema"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const defaultAgentMaxSteps = 6
+
+// ruleConfidenceThreshold is the rule-based match confidence at which the model is skipped.
+const ruleConfidenceThreshold = intent.ConfidenceExact
+
+// actionSchema constrains model replies to the shape parseAction accepts.
+var actionSchema = schema.FromValue(dto.AgentAction{})
+
+// agentStep records one tool call and what it returned, for feeding back to the model.
+type agentStep struct {
+	Action      dto.AgentAction
+	Observation string
+}
+
+// runAgent asks the model for tool calls until it gives a final answer, asks the
+// user a question, or runs out of steps.
+// Side-effecting tools are planned instead of run when the request is a dry run
+// or config requires confirmation.
+func (s *Service) runAgent(ctx context.Context, sessionID string, req dto.NaturalLanguageRequest, history []dto.ConversationTurn) (dto.CommandResult, error) {
+	// Rules only see the current command, so they are not used mid-conversation
+	ruleAction, confidence, ruleMatched := dto.AgentAction{}, 0.0, false
+	if len(history) == 0 {
+		ruleAction, confidence, ruleMatched = intent.Parse(req.Command, datetime.NewResolver(s.location(), s.now))
+	}
+	if ruleMatched && confidence >= ruleConfidenceThreshold {
+		s.logger.Info(ctx, "Command matched a rule, skipping the model", zap.String("action", ruleAction.Action))
+		return s.runRuleAction(ctx, sessionID, req, ruleAction, confidence)
+	}
+	if s.llm == nil {
+		if ruleMatched {
+			s.logger.Info(ctx, "No model configured, using rule match", zap.String("action", ruleAction.Action))
+			return s.runRuleAction(ctx, sessionID, req, ruleAction, confidence)
+		}
+		return dto.CommandResult{}, fmt.Errorf("%w: command not understood without a model", errors.ErrLLMUnavailable)
+	}
+
+	maxSteps := s.config.AgentMaxSteps
+	if maxSteps <= 0 {
+		maxSteps = defaultAgentMaxSteps
+	}
+
+	var steps []agentStep
+	for i := 0; i < maxSteps; i++ {
+		prompt := s.buildAgentPrompt(req.Command, history, steps)
+		reply, err := s.llm.Generate(ctx, prompt, s.actionOptions())
+		if err != nil {
+			s.logger.Error(ctx, "Failed to process command with language model", zap.Error(err))
+			// Falling back is only safe before any tool has run
+			if ruleMatched && len(steps) == 0 {
+				s.logger.Info(ctx, "Falling back to rule match", zap.String("action", ruleAction.Action))
+				return s.runRuleAction(ctx, sessionID, req, ruleAction, confidence)
+			}
+			return dto.CommandResult{}, fmt.Errorf("failed to process command: %w", err)
+		}
+
+		action, err := s.parseWithRepair(ctx, prompt, reply)
+		if err != nil {
+			return dto.CommandResult{}, err
+		}
+
+		switch action.Action {
+		case dto.ActionFinalAnswer:
+			s.logger.Info(ctx, "Agent finished", zap.Int("steps", i+1))
+			return dto.CommandResult{Result: action.Answer}, nil
+		case dto.ActionAskUser:
+			s.logger.Info(ctx, "Agent needs clarification", zap.Int("steps", i+1))
+			return dto.CommandResult{Result: action.Question, NeedsClarification: true}, nil
+		}
+
+		if t, ok := s.tool(action.Action); ok && t.sideEffect && (req.DryRun || s.requiresConfirmation(action.Action)) {
+			return s.planAction(ctx, sessionID, action)
+		}
+
+		observation, err := s.executeAction(ctx, action)
+		if err != nil {
+			s.logger.Warn(ctx, "Tool call failed", zap.String("action", action.Action), zap.Error(err))
+			observation = "error: " + err.Error()
+		}
+		steps = append(steps, agentStep{Action: action, Observation: observation})
+	}
+
+	s.logger.Error(ctx, "Agent step limit reached", zap.Int("max_steps", maxSteps))
+	return dto.CommandResult{}, fmt.Errorf("%w after %d steps", errors.ErrAgentStepLimit, maxSteps)
+}
+
+// actionOptions asks the model for JSON matching the action schema.
+func (s *Service) actionOptions() dto.GenerationOptions {
+	temperature := s.config.LLMTemperature
+	return dto.GenerationOptions{
+		ResponseMIMEType: "application/json",
+		ResponseSchema:   actionSchema,
+		Temperature:      &temperature,
+		MaxOutputTokens:  s.config.LLMMaxOutputTokens,
+	}
+}
+
+// parseWithRepair validates a model reply and, while attempts remain, sends
+// invalid replies back to the model together with the validation error.
+func (s *Service) parseWithRepair(ctx context.Context, prompt string, reply string) (dto.AgentAction, error) {
+	for attempt := 0; ; attempt++ {
+		action, err := parseAction(reply)
+		if err == nil {
+			err = s.resolveActionTimes(ctx, &action)
+		}
+		if err == nil {
+			return action, nil
+		}
+
+		if attempt >= s.config.LLMRepairAttempts {
+			s.logger.Error(ctx, "Model reply still invalid after repair attempts", zap.Int("attempts", attempt), zap.Error(err))
+			return dto.AgentAction{}, err
+		}
+		s.logger.Warn(ctx, "Model returned an invalid action, asking for a correction", zap.Int("attempt", attempt+1), zap.Error(err))
+
+		repairPrompt := fmt.Sprintf("%s\nYour previous reply was invalid: %v\nPrevious reply:\n%s\n\nReply again with one corrected JSON object only.\n",
+			prompt, err, reply)
+		reply, err = s.llm.Generate(ctx, repairPrompt, s.actionOptions())
+		if err != nil {
+			s.logger.Error(ctx, "Failed to get corrected reply from language model", zap.Error(err))
+			return dto.AgentAction{}, fmt.Errorf("failed to process command: %w", err)
+		}
+	}
+}
+
+// runRuleAction runs an action produced by the rule-based parser, honouring
+// dry runs and confirmation config like the agent loop does. Matches at or
+// below intent.ConfidenceConfirm are always planned for confirmation.
+func (s *Service) runRuleAction(ctx context.Context, sessionID string, req dto.NaturalLanguageRequest, action dto.AgentAction, confidence float64) (dto.CommandResult, error) {
+	if err := validateAction(&action); err != nil {
+		return dto.CommandResult{}, err
+	}
+
+	// Moves and cancels no model has checked wait for the user
+	confirm := confidence <= intent.ConfidenceConfirm
+	if t, ok := s.tool(action.Action); ok && t.sideEffect && (req.DryRun || confirm || s.requiresConfirmation(action.Action)) {
+		return s.planAction(ctx, sessionID, action)
+	}
+
+	result, err := s.executeAction(ctx, action)
+	if err != nil {
+		return dto.CommandResult{}, err
+	}
+	return dto.CommandResult{Result: result}, nil
+}
+
+// buildAgentPrompt describes the tools, the earlier conversation, the command and
+// the steps taken so far.
+func (s *Service) buildAgentPrompt(command string, history []dto.ConversationTurn, steps []agentStep) string {
+	var prompt strings.Builder
+
+	now := s.now().In(s.location())
+
+	prompt.WriteString("You are an AI executive assistant that completes the user's command by calling tools.\n")
+	prompt.WriteString(fmt.Sprintf("The current date and time is %s (time zone %s). Give all times as RFC3339 with this zone's offset.\n\n",
+		now.Format("Monday, January 2, 2006 15:04 -07:00"), now.Location()))
+	prompt.WriteString("Available tools:\n")
+	for _, t := range s.tools() {
+		prompt.WriteString(fmt.Sprintf("- %s: %s Parameters: %s\n", t.name, t.description, t.parameters))
+	}
+	if len(s.config.Calendars) > 0 {
+		prompt.WriteString("\nThe user's calendars:\n")
+		for _, cal := range s.config.Calendars {
+			var notes []string
+			if cal.Default {
+				notes = append(notes, "default for new meetings")
+			}
+			if !cal.Write {
+				notes = append(notes, "read-only")
+			}
+			if !cal.Read {
+				notes = append(notes, "not listed")
+			}
+			if len(notes) > 0 {
+				prompt.WriteString(fmt.Sprintf("- %s (%s)\n", cal.Name, strings.Join(notes, ", ")))
+			} else {
+				prompt.WriteString("- " + cal.Name + "\n")
+			}
+		}
+	}
+
+	prompt.WriteString(`
+Respond with exactly one JSON object and no other text. To call a tool:
+{"action": "<tool name>", "parameters": {...}}
+When required information is missing or ambiguous (no attendee, unclear day or time),
+do not guess. Ask the user instead:
+{"action": "ask_user", "question": "<clarifying question>"}
+When the command is complete, or cannot be completed, reply:
+{"action": "final_answer", "answer": "<message for the user>"}
+`)
+
+	if len(history) > 0 {
+		prompt.WriteString("\nConversation so far:\n")
+		for _, turn := range history {
+			prompt.WriteString(fmt.Sprintf("%s: %s\n", turn.Role, turn.Content))
+		}
+	}
+
+	prompt.WriteString("\nCommand: " + command + "\n")
+
+	if len(steps) > 0 {
+		prompt.WriteString("\nSteps taken so far:\n")
+		for i, step := range steps {
+			call, _ := json.Marshal(step.Action)
+			prompt.WriteString(fmt.Sprintf("%d. %s\n   Result: %s\n", i+1, call, step.Observation))
+		}
+	}
+
+	return prompt.String()
+}
@@ -0,0 +1,289 @@
+package agent
+
+import (
+	"ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/storage/confirmation"
+	"ai_agent/internal/storage/session"
+	"ai_agent/platform/calendar"
+	"ai_agent/platform/logger"
+	"context"
+	stderrors "errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// scriptedLLM replies to each prompt with the next of its replies, and with
+// the last one once they run out, recording every prompt it is sent.
+type scriptedLLM struct {
+	replies []string
+	prompts []string
+}
+
+func (l *scriptedLLM) Name() string { return "scripted" }
+
+func (l *scriptedLLM) ProcessCommand(ctx context.Context, command string) (string, error) {
+	return l.Generate(ctx, command, dto.GenerationOptions{})
+}
+
+func (l *scriptedLLM) Generate(ctx context.Context, prompt string, opts dto.GenerationOptions) (string, error) {
+	l.prompts = append(l.prompts, prompt)
+	reply := l.replies[min(len(l.prompts), len(l.replies))-1]
+	return reply, nil
+}
+
+// recordingEmail keeps the messages it is asked to send.
+type recordingEmail struct {
+	sent []dto.EmailMessage
+}
+
+func (e *recordingEmail) SendEmail(ctx context.Context, message dto.EmailMessage) error {
+	e.sent = append(e.sent, message)
+	return nil
+}
+
+func newTestService(t *testing.T, llm *scriptedLLM, config dto.Config) (*Service, *recordingEmail) {
+	t.Helper()
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	now := time.Date(2026, 10, 27, 10, 30, 0, 0, loc)
+
+	config.TimeZone = loc.String()
+	config.UserEmail = "alice@example.com"
+	log := logger.InitLogger(zap.NewNop())
+
+	cal := calendar.InitLocalCalendar(config, log)
+	start := time.Date(2026, 10, 28, 9, 30, 0, 0, loc)
+	if _, err := cal.ScheduleMeeting(context.Background(), dto.Event{
+		Title:     "Standup",
+		Attendees: []string{"alice@example.com", "bob@example.com"},
+		StartTime: start,
+		EndTime:   start.Add(15 * time.Minute),
+	}); err != nil {
+		t.Fatalf("failed to seed calendar: %v", err)
+	}
+
+	email := &recordingEmail{}
+	return &Service{
+		calendar:      cal,
+		email:         email,
+		llm:           llm,
+		sessions:      session.InitSessionStore(time.Hour),
+		confirmations: confirmation.InitConfirmationStore(),
+		logger:        log,
+		config:        config,
+		now:           func() time.Time { return now },
+	}, email
+}
+
+func TestRunAgent(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  dto.Config
+		replies []string
+		// wantResult is the answer; empty expects wantErr
+		wantResult string
+		wantErr    error
+		// wantPrompts holds text expected in each prompt sent to the model
+		wantPrompts [][]string
+	}{
+		{
+			name: "tool call, observation, final answer",
+			replies: []string{
+				`{"action": "get_events", "parameters": {}}`,
+				`{"action": "final_answer", "answer": "You have standup tomorrow at 9:30."}`,
+			},
+			wantResult: "You have standup tomorrow at 9:30.",
+			wantPrompts: [][]string{
+				{"Command: what does tomorrow look like"},
+				{"Steps taken so far:", `1. {"action":"get_events"`, "Result: ", "Standup"},
+			},
+		},
+		{
+			name: "failed tool call is observed",
+			replies: []string{
+				`{"action": "cancel_meeting", "parameters": {"event_id": "missing"}}`,
+				`{"action": "final_answer", "answer": "I could not find that meeting."}`,
+			},
+			wantResult: "I could not find that meeting.",
+			wantPrompts: [][]string{
+				{"Command: what does tomorrow look like"},
+				{`"event_id":"missing"`, "Result: error: "},
+			},
+		},
+		{
+			name: "question for the user",
+			replies: []string{
+				`{"action": "ask_user", "question": "Which day do you mean?"}`,
+			},
+			wantResult:  "Which day do you mean?",
+			wantPrompts: [][]string{{"Command: what does tomorrow look like"}},
+		},
+		{
+			name:   "step limit",
+			config: dto.Config{AgentMaxSteps: 3},
+			replies: []string{
+				`{"action": "get_events", "parameters": {}}`,
+			},
+			wantErr: errors.ErrAgentStepLimit,
+			wantPrompts: [][]string{
+				{"Command: what does tomorrow look like"},
+				{"1. "},
+				{"1. ", "2. "},
+			},
+		},
+		{
+			name:   "invalid JSON is sent back",
+			config: dto.Config{LLMRepairAttempts: 1},
+			replies: []string{
+				`Sure! Let me look at your calendar.`,
+				`{"action": "final_answer", "answer": "Nothing else tomorrow."}`,
+			},
+			wantResult: "Nothing else tomorrow.",
+			wantPrompts: [][]string{
+				{"Command: what does tomorrow look like"},
+				{"Your previous reply was invalid: ", "no JSON object found", "Previous reply:\nSure! Let me look at your calendar."},
+			},
+		},
+		{
+			name:   "invalid action is sent back",
+			config: dto.Config{LLMRepairAttempts: 1},
+			replies: []string{
+				`{"action": "teleport", "parameters": {}}`,
+				`{"action": "get_events", "parameters": {}}`,
+				`{"action": "final_answer", "answer": "Just standup."}`,
+			},
+			wantResult: "Just standup.",
+			wantPrompts: [][]string{
+				{"Command: what does tomorrow look like"},
+				{"Your previous reply was invalid: ", `"teleport"`},
+				{"Steps taken so far:", "Standup"},
+			},
+		},
+		{
+			name:   "invalid JSON past the repair attempts",
+			config: dto.Config{LLMRepairAttempts: 1},
+			replies: []string{
+				`not json`,
+				`still not json`,
+			},
+			wantErr: errors.ErrInvalidAIResponse,
+			wantPrompts: [][]string{
+				{"Command: what does tomorrow look like"},
+				{"Previous reply:\nnot json"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := &scriptedLLM{replies: tt.replies}
+			s, _ := newTestService(t, llm, tt.config)
+
+			result, err := s.ProcessNaturalLanguageCommand(context.Background(), dto.NaturalLanguageRequest{
+				Command: "what does tomorrow look like",
+			})
+			if tt.wantErr != nil {
+				if !stderrors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("ProcessNaturalLanguageCommand failed: %v", err)
+				}
+				if result.Result != tt.wantResult {
+					t.Errorf("result = %q, want %q", result.Result, tt.wantResult)
+				}
+			}
+
+			if len(llm.prompts) != len(tt.wantPrompts) {
+				t.Fatalf("model was prompted %d times, want %d", len(llm.prompts), len(tt.wantPrompts))
+			}
+			for i, wants := range tt.wantPrompts {
+				for _, want := range wants {
+					if !strings.Contains(llm.prompts[i], want) {
+						t.Errorf("prompt %d does not contain %q:\n%s", i+1, want, llm.prompts[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRunAgentPlansConfirmedActions(t *testing.T) {
+	llm := &scriptedLLM{replies: []string{
+		`{"action": "send_email", "parameters": {"to_email": "bob@example.com", "subject": "Standup notes", "body": "Notes attached."}}`,
+	}}
+	s, email := newTestService(t, llm, dto.Config{ConfirmActions: []string{dto.ActionSendEmail}})
+	ctx := context.Background()
+
+	result, err := s.ProcessNaturalLanguageCommand(ctx, dto.NaturalLanguageRequest{Command: "send bob the standup notes"})
+	if err != nil {
+		t.Fatalf("ProcessNaturalLanguageCommand failed: %v", err)
+	}
+	if result.ConfirmationToken == "" || result.Plan == nil {
+		t.Fatalf("result = %+v, want a plan awaiting confirmation", result)
+	}
+	if len(email.sent) != 0 {
+		t.Fatalf("email sent before confirmation: %+v", email.sent)
+	}
+
+	// Another session cannot confirm the plan
+	if _, err := s.ConfirmCommand(ctx, result.ConfirmationToken, "someone-else"); !stderrors.Is(err, errors.ErrInvalidConfirmationToken) {
+		t.Fatalf("confirm from another session error = %v, want ErrInvalidConfirmationToken", err)
+	}
+
+	if _, err := s.ConfirmCommand(ctx, result.ConfirmationToken, result.SessionID); err != nil {
+		t.Fatalf("ConfirmCommand failed: %v", err)
+	}
+	if len(email.sent) != 1 || email.sent[0].Subject != "Standup notes" || email.sent[0].Text != "Notes attached." {
+		t.Errorf("sent = %+v, want the planned email", email.sent)
+	}
+	if len(llm.prompts) != 1 {
+		t.Errorf("model was prompted %d times, want 1", len(llm.prompts))
+	}
+}
+
+func TestSessionsAreIssuedByTheServer(t *testing.T) {
+	llm := &scriptedLLM{replies: []string{
+		`{"action": "ask_user", "question": "Which day do you mean?"}`,
+		`{"action": "final_answer", "answer": "Booked for Thursday."}`,
+	}}
+	s, _ := newTestService(t, llm, dto.Config{})
+	ctx := context.Background()
+
+	first, err := s.ProcessNaturalLanguageCommand(ctx, dto.NaturalLanguageRequest{Command: "book lunch with bob"})
+	if err != nil {
+		t.Fatalf("ProcessNaturalLanguageCommand failed: %v", err)
+	}
+	if first.SessionID == "" {
+		t.Fatal("no session ID issued")
+	}
+
+	second, err := s.ProcessNaturalLanguageCommand(ctx, dto.NaturalLanguageRequest{Command: "thursday", SessionID: first.SessionID})
+	if err != nil {
+		t.Fatalf("ProcessNaturalLanguageCommand in the session failed: %v", err)
+	}
+	if second.SessionID != first.SessionID {
+		t.Errorf("session ID = %q, want %q", second.SessionID, first.SessionID)
+	}
+	if len(llm.prompts) != 2 || !strings.Contains(llm.prompts[1], "Conversation so far:") || !strings.Contains(llm.prompts[1], "book lunch with bob") {
+		t.Errorf("prompts = %q, want the second to carry the conversation", llm.prompts)
+	}
+
+	_, err = s.ProcessNaturalLanguageCommand(ctx, dto.NaturalLanguageRequest{Command: "thursday", SessionID: "chosen-by-the-client"})
+	if !stderrors.Is(err, errors.ErrNotFound) {
+		t.Errorf("unknown session error = %v, want ErrNotFound", err)
+	}
+	if len(llm.prompts) != 2 {
+		t.Errorf("model was prompted for an unknown session")
+	}
+	if _, ok, _ := s.sessions.Get(ctx, "chosen-by-the-client"); ok {
+		t.Error("session created under a client-chosen ID")
+	}
+}
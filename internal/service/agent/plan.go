@@ -0,0 +1,426 @@
+package agent
+
+import (
+	"ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/recurrence"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const defaultConfirmationTTL = 10 * time.Minute
+
+// requiresConfirmation reports whether config says the action must be confirmed first.
+func (s *Service) requiresConfirmation(action string) bool {
+	for _, confirm := range s.config.ConfirmActions {
+		if confirm == action || confirm == "all" {
+			return true
+		}
+	}
+	return false
+}
+
+// planAction stores a side-effecting action instead of running it and returns
+// the plan together with the token that confirms it.
+func (s *Service) planAction(ctx context.Context, sessionID string, action dto.AgentAction) (dto.CommandResult, error) {
+	plan, err := s.buildPlan(ctx, &action)
+	if err != nil {
+		return dto.CommandResult{}, err
+	}
+	return s.holdAction(ctx, dto.PendingAction{SessionID: sessionID, Action: action, Plan: plan})
+}
+
+// PlanMeeting holds a meeting requested through the API for confirmation when
+// ConfirmActions lists schedule_meeting. It returns false when the meeting can
+// be scheduled straight away.
+func (s *Service) PlanMeeting(ctx context.Context, meeting dto.Meeting) (dto.CommandResult, bool, error) {
+	if !s.requiresConfirmation(dto.ActionScheduleMeeting) {
+		return dto.CommandResult{}, false, nil
+	}
+	if err := s.validateMeeting(meeting); err != nil {
+		return dto.CommandResult{}, false, err
+	}
+
+	action := dto.AgentAction{
+		Action: dto.ActionScheduleMeeting,
+		Parameters: dto.ActionParameters{
+			Title:           meeting.Title,
+			Attendees:       meeting.Attendees,
+			StartTime:       meeting.StartTime.Format(time.RFC3339),
+			DurationMinutes: int(meeting.Duration.Minutes()),
+			Recurrence:      meeting.Recurrence,
+			Calendar:        meeting.Calendar,
+		},
+	}
+	plan, err := s.buildPlan(ctx, &action)
+	if err != nil {
+		return dto.CommandResult{}, false, err
+	}
+	// The request may say more about the meeting than an action can
+	attendees := s.withUser(meeting.Attendees)
+	plan.Email.Body = meetingEmailBody("scheduled", meetingEvent(meeting, attendees, s.config.UserEmail), s.location())
+
+	result, err := s.holdAction(ctx, dto.PendingAction{Action: action, Plan: plan, Meeting: &meeting})
+	return result, true, err
+}
+
+// PlanEmail holds an email requested through the API for confirmation when
+// ConfirmActions lists send_email. It returns false when the email can be sent
+// straight away. A body left empty is written now, so confirming sends what
+// was previewed.
+func (s *Service) PlanEmail(ctx context.Context, message dto.EmailMessage) (dto.CommandResult, bool, error) {
+	if !s.requiresConfirmation(dto.ActionSendEmail) {
+		return dto.CommandResult{}, false, nil
+	}
+
+	if message.Text == "" && message.HTML == "" {
+		body, err := s.generateEmailBody(ctx, message.Subject)
+		if err != nil {
+			return dto.CommandResult{}, false, err
+		}
+		message.Text = body
+	}
+	if err := message.Validate(); err != nil {
+		return dto.CommandResult{}, false, err
+	}
+
+	body := message.Text
+	if message.HTML != "" {
+		body = message.HTML
+	}
+	plan := dto.ActionPlan{
+		Action:   dto.ActionSendEmail,
+		TimeZone: s.location().String(),
+		Email: &dto.EmailPreview{
+			To:      message.To,
+			Cc:      message.Cc,
+			Bcc:     message.Bcc,
+			Subject: message.Subject,
+			Body:    body,
+		},
+	}
+
+	result, err := s.holdAction(ctx, dto.PendingAction{Action: dto.AgentAction{Action: dto.ActionSendEmail}, Plan: plan, Email: &message})
+	return result, true, err
+}
+
+// PlanUpdateEvent holds an event update requested through the API for
+// confirmation when ConfirmActions lists reschedule_meeting, since an update
+// can move a meeting and notifies its attendees as a reschedule does. It
+// returns false when the update can be made straight away.
+func (s *Service) PlanUpdateEvent(ctx context.Context, id string, update dto.EventUpdate) (dto.CommandResult, bool, error) {
+	if !s.requiresConfirmation(dto.ActionRescheduleMeeting) {
+		return dto.CommandResult{}, false, nil
+	}
+
+	event, err := s.GetEvent(ctx, id)
+	if err != nil {
+		return dto.CommandResult{}, false, err
+	}
+	updated := s.applyUpdate(event, update)
+
+	loc := s.location()
+	plan := dto.ActionPlan{
+		Action:    dto.ActionRescheduleMeeting,
+		Title:     updated.Title,
+		Attendees: updated.Attendees,
+		StartTime: updated.StartTime.In(loc).Format(time.RFC3339),
+		EndTime:   updated.EndTime.In(loc).Format(time.RFC3339),
+		TimeZone:  loc.String(),
+		Email: &dto.EmailPreview{
+			To:      s.recipients(updated.Attendees),
+			Subject: meetingSubject("updated", updated.Title),
+			Body:    meetingEmailBody("updated", updated, loc),
+		},
+	}
+	action := dto.AgentAction{Action: dto.ActionRescheduleMeeting, Parameters: dto.ActionParameters{EventID: id}}
+
+	result, err := s.holdAction(ctx, dto.PendingAction{Action: action, Plan: plan, Update: &update})
+	return result, true, err
+}
+
+// PlanRescheduleMeeting holds a move requested through the API for
+// confirmation when ConfirmActions lists reschedule_meeting. It returns false
+// when the meeting can be moved straight away.
+func (s *Service) PlanRescheduleMeeting(ctx context.Context, id string, startTime time.Time, duration time.Duration) (dto.CommandResult, bool, error) {
+	if !s.requiresConfirmation(dto.ActionRescheduleMeeting) {
+		return dto.CommandResult{}, false, nil
+	}
+
+	result, err := s.planAction(ctx, "", dto.AgentAction{
+		Action: dto.ActionRescheduleMeeting,
+		Parameters: dto.ActionParameters{
+			EventID:         id,
+			StartTime:       startTime.Format(time.RFC3339),
+			DurationMinutes: int(duration.Minutes()),
+		},
+	})
+	return result, true, err
+}
+
+// PlanCancelMeeting holds a cancellation requested through the API for
+// confirmation when ConfirmActions lists cancel_meeting. It returns false when
+// the meeting can be cancelled straight away.
+func (s *Service) PlanCancelMeeting(ctx context.Context, id string) (dto.CommandResult, bool, error) {
+	if !s.requiresConfirmation(dto.ActionCancelMeeting) {
+		return dto.CommandResult{}, false, nil
+	}
+
+	result, err := s.planAction(ctx, "", dto.AgentAction{
+		Action:     dto.ActionCancelMeeting,
+		Parameters: dto.ActionParameters{EventID: id},
+	})
+	return result, true, err
+}
+
+// PlanDailyReminder holds a daily reminder requested through the API for
+// confirmation when ConfirmActions lists remind. The digest is written now,
+// so confirming sends what was previewed. It returns false when the reminder
+// can be sent straight away.
+func (s *Service) PlanDailyReminder(ctx context.Context) (dto.CommandResult, bool, error) {
+	if !s.requiresConfirmation(dto.ActionRemind) {
+		return dto.CommandResult{}, false, nil
+	}
+
+	result, err := s.planAction(ctx, "", dto.AgentAction{Action: dto.ActionRemind})
+	return result, true, err
+}
+
+// holdAction stores a planned action until it is confirmed and returns the
+// plan together with the token that confirms it.
+func (s *Service) holdAction(ctx context.Context, pending dto.PendingAction) (dto.CommandResult, error) {
+	token, err := randomID()
+	if err != nil {
+		s.logger.Error(ctx, "Failed to generate confirmation token", zap.Error(err))
+		return dto.CommandResult{}, err
+	}
+
+	ttl := time.Duration(s.config.ConfirmationTTLMinutes) * time.Minute
+	if ttl <= 0 {
+		ttl = defaultConfirmationTTL
+	}
+
+	pending.Token = token
+	pending.ExpiresAt = s.now().Add(ttl)
+	if err := s.confirmations.Put(ctx, pending); err != nil {
+		s.logger.Error(ctx, "Failed to store pending action", zap.Error(err))
+		return dto.CommandResult{}, err
+	}
+
+	s.logger.Info(ctx, "Action awaiting confirmation", zap.String("action", pending.Action.Action), zap.Time("expires_at", pending.ExpiresAt))
+	return dto.CommandResult{
+		SessionID:         pending.SessionID,
+		Result:            describePlan(pending.Plan),
+		Plan:              &pending.Plan,
+		ConfirmationToken: token,
+		ExpiresAt:         pending.ExpiresAt,
+	}, nil
+}
+
+// buildPlan resolves everything the action will do. Generated email bodies and
+// digests are written back into the action so confirming sends exactly what
+// was previewed.
+func (s *Service) buildPlan(ctx context.Context, action *dto.AgentAction) (dto.ActionPlan, error) {
+	loc := s.location()
+	params := &action.Parameters
+	plan := dto.ActionPlan{Action: action.Action, TimeZone: loc.String()}
+
+	switch action.Action {
+	case dto.ActionScheduleMeeting:
+		startTime, _ := time.Parse(time.RFC3339, params.StartTime)
+		duration := time.Duration(params.DurationMinutes) * time.Minute
+		attendees := s.withUser(params.Attendees)
+
+		plan.Title = params.Title
+		plan.Calendar = params.Calendar
+		plan.Attendees = attendees
+		plan.StartTime = startTime.In(loc).Format(time.RFC3339)
+		plan.EndTime = startTime.Add(duration).In(loc).Format(time.RFC3339)
+
+		if len(params.Recurrence) > 0 {
+			plan.Recurrence = recurrence.Describe(params.Recurrence)
+		}
+
+		slots, err := meetingSlots(startTime.In(loc), duration, params.Recurrence)
+		if err != nil {
+			return dto.ActionPlan{}, err
+		}
+		conflicts, err := s.conflicts(ctx, attendees, slots)
+		if err != nil {
+			s.logger.Warn(ctx, "Could not check plan for conflicts", zap.Error(err))
+		}
+		plan.Conflicts = conflicts
+
+		plan.Email = &dto.EmailPreview{
+			To:      s.recipients(attendees),
+			Subject: meetingSubject("scheduled", params.Title),
+			Body: meetingEmailBody("scheduled", meetingEvent(dto.Meeting{
+				Title:      params.Title,
+				StartTime:  startTime,
+				Duration:   duration,
+				Recurrence: params.Recurrence,
+			}, attendees, s.config.UserEmail), loc),
+		}
+
+	case dto.ActionRescheduleMeeting:
+		event, startTime, err := s.resolveReschedule(ctx, *params)
+		if err != nil {
+			return dto.ActionPlan{}, err
+		}
+		duration := time.Duration(params.DurationMinutes) * time.Minute
+		if duration <= 0 {
+			duration = event.EndTime.Sub(event.StartTime)
+		}
+		// Pin the event and time so confirming moves exactly what was previewed
+		params.EventID, params.Event = event.ID, ""
+		params.StartTime, params.TimeExpression = startTime.Format(time.RFC3339), ""
+
+		plan.Title = event.Title
+		plan.Attendees = event.Attendees
+		plan.StartTime = startTime.In(loc).Format(time.RFC3339)
+		plan.EndTime = startTime.Add(duration).In(loc).Format(time.RFC3339)
+		moved := event
+		moved.StartTime, moved.EndTime, moved.AllDay = startTime, startTime.Add(duration), false
+		plan.Email = &dto.EmailPreview{
+			To:      s.recipients(event.Attendees),
+			Subject: meetingSubject("rescheduled", event.Title),
+			Body:    meetingEmailBody("rescheduled", moved, loc),
+		}
+
+	case dto.ActionCancelMeeting:
+		event, err := s.lookupEvent(ctx, *params)
+		if err != nil {
+			return dto.ActionPlan{}, err
+		}
+		params.EventID, params.Event = event.ID, ""
+
+		plan.Title = event.Title
+		plan.Attendees = event.Attendees
+		plan.StartTime = event.StartTime.In(loc).Format(time.RFC3339)
+		plan.EndTime = event.EndTime.In(loc).Format(time.RFC3339)
+		plan.Email = &dto.EmailPreview{
+			To:      s.recipients(event.Attendees),
+			Subject: meetingSubject("cancelled", event.Title),
+			Body:    cancelledEmailBody(event, loc),
+		}
+
+	case dto.ActionSendEmail:
+		if params.Body == "" {
+			body, err := s.generateEmailBody(ctx, params.Subject)
+			if err != nil {
+				return dto.ActionPlan{}, err
+			}
+			params.Body = body
+		}
+		message := emailMessage(*params)
+		plan.Email = &dto.EmailPreview{
+			To:      message.To,
+			Cc:      message.Cc,
+			Bcc:     message.Bcc,
+			Subject: message.Subject,
+			Body:    message.Text,
+		}
+
+	case dto.ActionRemind:
+		if strings.TrimSpace(params.ReminderText) == "" {
+			digest, err := s.dailyDigest(ctx)
+			if err != nil {
+				return dto.ActionPlan{}, err
+			}
+			params.ReminderText = digest
+		}
+		plan.Email = &dto.EmailPreview{
+			To:      []string{s.config.UserEmail},
+			Subject: "Reminder",
+			Body:    params.ReminderText,
+		}
+	}
+
+	return plan, nil
+}
+
+// ConfirmCommand runs the action planned under the given confirmation token,
+// which only the session the action was planned in can use. Actions planned
+// for direct API requests belong to no session.
+func (s *Service) ConfirmCommand(ctx context.Context, token string, sessionID string) (dto.CommandResult, error) {
+	s.logger.Info(ctx, "Confirming planned action", zap.String("session_id", sessionID))
+
+	pending, ok, err := s.confirmations.Take(ctx, token)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to load pending action", zap.Error(err))
+		return dto.CommandResult{}, err
+	}
+	if !ok {
+		return dto.CommandResult{}, errors.ErrInvalidConfirmationToken
+	}
+	if pending.SessionID != sessionID {
+		s.logger.Warn(ctx, "Confirmation token used from another session", zap.String("session_id", sessionID))
+		// Leave it for the session it belongs to
+		if err := s.confirmations.Put(ctx, pending); err != nil {
+			s.logger.Error(ctx, "Failed to restore pending action", zap.Error(err))
+		}
+		return dto.CommandResult{}, errors.ErrInvalidConfirmationToken
+	}
+
+	var result string
+	switch {
+	case pending.Meeting != nil:
+		result, err = s.runMeeting(ctx, *pending.Meeting)
+	case pending.Email != nil:
+		result, err = s.runEmail(ctx, *pending.Email)
+	case pending.Update != nil:
+		result, err = s.runUpdate(ctx, pending.Action.Parameters.EventID, *pending.Update)
+	default:
+		result, err = s.executeAction(ctx, pending.Action)
+	}
+	if err != nil {
+		s.logger.Error(ctx, "Failed to run confirmed action", zap.String("action", pending.Action.Action), zap.Error(err))
+		return dto.CommandResult{SessionID: pending.SessionID}, err
+	}
+
+	if pending.SessionID != "" {
+		s.appendTurns(ctx, pending.SessionID, dto.ConversationTurn{Role: dto.RoleAssistant, Content: result})
+	}
+
+	return dto.CommandResult{SessionID: pending.SessionID, Result: result}, nil
+}
+
+// describePlan summarises a plan in one sentence for the conversation history.
+func describePlan(plan dto.ActionPlan) string {
+	switch plan.Action {
+	case dto.ActionScheduleMeeting:
+		var warning string
+		if len(plan.Conflicts) > 0 {
+			warning = fmt.Sprintf(" It overlaps %d busy period(s).", len(plan.Conflicts))
+		}
+		var repeats string
+		if plan.Recurrence != "" {
+			repeats = ", repeating " + plan.Recurrence + ","
+		}
+		var on string
+		if plan.Calendar != "" {
+			on = " on the " + plan.Calendar + " calendar"
+		}
+		return fmt.Sprintf("Ready to schedule %q%s from %s to %s%s with %s.%s Confirm to proceed.",
+			plan.Title, on, plan.StartTime, plan.EndTime, repeats, strings.Join(plan.Attendees, ", "), warning)
+	case dto.ActionRescheduleMeeting:
+		return fmt.Sprintf("Ready to move %q to %s - %s and notify %s. Confirm to proceed.",
+			plan.Title, plan.StartTime, plan.EndTime, strings.Join(plan.Email.To, ", "))
+	case dto.ActionCancelMeeting:
+		return fmt.Sprintf("Ready to cancel %q at %s and notify %s. Confirm to proceed.",
+			plan.Title, plan.StartTime, strings.Join(plan.Email.To, ", "))
+	case dto.ActionSendEmail, dto.ActionRemind:
+		var copies string
+		if copied := slices.Concat(plan.Email.Cc, plan.Email.Bcc); len(copied) > 0 {
+			copies = ", copying " + strings.Join(copied, ", ") + ","
+		}
+		return fmt.Sprintf("Ready to email %s%s with subject %q. Confirm to proceed.",
+			strings.Join(plan.Email.To, ", "), copies, plan.Email.Subject)
+	}
+	return fmt.Sprintf("Ready to run %s. Confirm to proceed.", plan.Action)
+}
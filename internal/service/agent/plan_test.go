@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"context"
+	stderrors "errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// standup returns the event newTestService seeds, or false once it is gone.
+func standup(t *testing.T, s *Service) (dto.Event, bool) {
+	t.Helper()
+	page, err := s.ListEvents(context.Background(), dto.EventQuery{Query: "standup"})
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	if len(page.Events) == 0 {
+		return dto.Event{}, false
+	}
+	return page.Events[0], true
+}
+
+func TestDirectRequestsFollowConfirmActions(t *testing.T) {
+	tests := []struct {
+		name   string
+		action string
+		plan   func(ctx context.Context, s *Service, id string) (dto.CommandResult, bool, error)
+		// wantSubject is the subject of an email sent once confirmed
+		wantSubject string
+		// wantStart is the standup's start once confirmed, empty if cancelled
+		wantStart string
+		wantTitle string
+	}{
+		{
+			name:   "update",
+			action: dto.ActionRescheduleMeeting,
+			plan: func(ctx context.Context, s *Service, id string) (dto.CommandResult, bool, error) {
+				return s.PlanUpdateEvent(ctx, id, dto.EventUpdate{Title: "Daily standup"})
+			},
+			wantSubject: "Meeting Updated: Daily standup",
+			wantStart:   "2026-10-28T09:30:00-04:00",
+			wantTitle:   "Daily standup",
+		},
+		{
+			name:   "reschedule",
+			action: dto.ActionRescheduleMeeting,
+			plan: func(ctx context.Context, s *Service, id string) (dto.CommandResult, bool, error) {
+				return s.PlanRescheduleMeeting(ctx, id, time.Date(2026, 10, 28, 11, 0, 0, 0, s.location()), 0)
+			},
+			wantSubject: "Meeting Rescheduled: Standup",
+			wantStart:   "2026-10-28T11:00:00-04:00",
+			wantTitle:   "Standup",
+		},
+		{
+			name:   "cancel",
+			action: dto.ActionCancelMeeting,
+			plan: func(ctx context.Context, s *Service, id string) (dto.CommandResult, bool, error) {
+				return s.PlanCancelMeeting(ctx, id)
+			},
+			wantSubject: "Meeting Cancelled: Standup",
+		},
+		{
+			name:   "daily reminder",
+			action: dto.ActionRemind,
+			plan: func(ctx context.Context, s *Service, id string) (dto.CommandResult, bool, error) {
+				return s.PlanDailyReminder(ctx)
+			},
+			wantSubject: "Reminder",
+			wantStart:   "2026-10-28T09:30:00-04:00",
+			wantTitle:   "Standup",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			llm := &scriptedLLM{replies: []string{"You have standup tomorrow at 9:30."}}
+
+			s, email := newTestService(t, llm, dto.Config{})
+			event, _ := standup(t, s)
+			if _, planned, err := tt.plan(ctx, s, event.ID); err != nil || planned {
+				t.Fatalf("plan without confirmation = %v, %v; want no plan", planned, err)
+			}
+
+			s, email = newTestService(t, llm, dto.Config{ConfirmActions: []string{tt.action}})
+			event, _ = standup(t, s)
+			result, planned, err := tt.plan(ctx, s, event.ID)
+			if err != nil || !planned || result.ConfirmationToken == "" || result.Plan == nil {
+				t.Fatalf("plan = %+v, %v, %v; want a plan awaiting confirmation", result, planned, err)
+			}
+			if len(email.sent) != 0 {
+				t.Errorf("email sent before confirmation: %+v", email.sent)
+			}
+			if got, _ := standup(t, s); !got.StartTime.Equal(event.StartTime) || got.Title != event.Title {
+				t.Errorf("standup changed before confirmation: %+v", got)
+			}
+
+			// Plans for direct requests belong to no session
+			if _, err := s.ConfirmCommand(ctx, result.ConfirmationToken, "some-session"); !stderrors.Is(err, errors.ErrInvalidConfirmationToken) {
+				t.Fatalf("confirm from a session error = %v, want ErrInvalidConfirmationToken", err)
+			}
+			if _, err := s.ConfirmCommand(ctx, result.ConfirmationToken, ""); err != nil {
+				t.Fatalf("ConfirmCommand failed: %v", err)
+			}
+
+			if len(email.sent) != 1 || !strings.HasPrefix(email.sent[0].Subject, tt.wantSubject) {
+				t.Errorf("sent = %+v, want one email with subject %q", email.sent, tt.wantSubject)
+			}
+			got, ok := standup(t, s)
+			if tt.wantStart == "" {
+				if ok {
+					t.Errorf("standup not cancelled: %+v", got)
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("standup is gone")
+			}
+			if got.StartTime.In(s.location()).Format(time.RFC3339) != tt.wantStart || got.Title != tt.wantTitle {
+				t.Errorf("standup = %q at %s, want %q at %s", got.Title, got.StartTime.Format(time.RFC3339), tt.wantTitle, tt.wantStart)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package agent
+
+import (
+	"ai_agent/internal/constants/model/dto"
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// reminderClaimTTL is how long after a meeting starts the record of its
+// reminders is kept. Reminders are only sent before the start, so a shorter
+// record would do; the margin covers clock differences between replicas.
+const reminderClaimTTL = 24 * time.Hour
+
+// SendMeetingReminders emails a reminder for each meeting starting within
+// MeetingReminderMinutes, to its attendees or only the user as configured.
+// Each reminder is claimed in the reminder store first, so it is sent once
+// however often this runs and however many replicas run it. It returns the
+// number of reminders sent.
+func (s *Service) SendMeetingReminders(ctx context.Context) (int, error) {
+	lead := time.Duration(s.config.MeetingReminderMinutes) * time.Minute
+	if lead <= 0 || s.reminders == nil {
+		return 0, nil
+	}
+
+	now := s.now()
+	page, err := s.calendar.ListEvents(ctx, dto.EventQuery{From: now, To: now.Add(lead)})
+	if err != nil {
+		s.logger.Error(ctx, "Failed to get events for meeting reminders", zap.Error(err))
+		return 0, err
+	}
+
+	sent := 0
+	for _, event := range page.Events {
+		// All-day events have no start worth a reminder minutes ahead
+		if event.AllDay || event.Status == dto.EventCancelled || !event.StartTime.After(now) || event.StartTime.After(now.Add(lead)) {
+			continue
+		}
+
+		var claimed []string
+		for _, recipient := range s.reminderRecipients(event) {
+			key := reminderKey(event, recipient)
+			ok, err := s.reminders.Claim(ctx, key, event.StartTime.Add(reminderClaimTTL))
+			if err != nil {
+				s.logger.Error(ctx, "Failed to claim meeting reminder", zap.String("event_id", event.ID), zap.Error(err))
+				continue
+			}
+			if ok {
+				claimed = append(claimed, recipient)
+			}
+		}
+		if len(claimed) == 0 {
+			continue
+		}
+
+		note := s.prepNote(ctx, event)
+		for _, recipient := range claimed {
+			loc := s.recipientLocation(recipient)
+			subject := fmt.Sprintf("Reminder: %s at %s", event.Title, event.StartTime.In(loc).Format("3:04 PM MST"))
+			message := dto.EmailMessage{To: []string{recipient}, Subject: subject, HTML: reminderEmailBody(event, loc, note)}
+			if err := s.email.SendEmail(ctx, message); err != nil {
+				s.logger.Error(ctx, "Failed to send meeting reminder",
+					zap.String("event_id", event.ID), zap.String("recipient", recipient), zap.Error(err))
+				// Let the next run try again
+				if err := s.reminders.Release(ctx, reminderKey(event, recipient)); err != nil {
+					s.logger.Error(ctx, "Failed to release meeting reminder", zap.String("event_id", event.ID), zap.Error(err))
+				}
+				continue
+			}
+			sent++
+		}
+		s.logger.Info(ctx, "Sent meeting reminders", zap.String("event_id", event.ID), zap.Int("recipients", len(claimed)))
+	}
+	return sent, nil
+}
+
+// reminderRecipients returns who is reminded of an event: only the user, or
+// every attendee and the user, leaving out those who declined.
+func (s *Service) reminderRecipients(event dto.Event) []string {
+	candidates := []string{s.config.UserEmail}
+	if s.config.ReminderRecipients != dto.RemindUser {
+		candidates = s.withUser(event.Attendees)
+	}
+
+	var recipients []string
+	for _, candidate := range candidates {
+		if candidate == "" || containsFold(recipients, candidate) {
+			continue
+		}
+		if responseOf(event, candidate) == dto.ResponseDeclined {
+			continue
+		}
+		recipients = append(recipients, candidate)
+	}
+	return recipients
+}
+
+// recipientLocation is the time zone a recipient's reminder is written in.
+func (s *Service) recipientLocation(email string) *time.Location {
+	for address, zone := range s.config.RecipientTimeZones {
+		if strings.EqualFold(address, email) {
+			if loc, err := time.LoadLocation(zone); err == nil {
+				return loc
+			}
+		}
+	}
+	return s.location()
+}
+
+// prepNote asks the model for a short note on preparing for a meeting. It is
+// left out when there is no model or it fails.
+func (s *Service) prepNote(ctx context.Context, event dto.Event) string {
+	if s.llm == nil {
+		return ""
+	}
+
+	prompt := fmt.Sprintf(`
+Write a short preparation note, two or three sentences of plain text, for someone about to join this meeting:
+
+Title: %s
+Attendees: %s
+Description: %s
+
+Suggest what to review or bring. Do not repeat the time or location.
+`, event.Title, strings.Join(event.Attendees, ", "), event.Description)
+
+	note, err := s.llm.ProcessCommand(ctx, prompt)
+	if err != nil {
+		s.logger.Warn(ctx, "Failed to write meeting prep note", zap.String("event_id", event.ID), zap.Error(err))
+		return ""
+	}
+	return strings.TrimSpace(note)
+}
+
+// reminderEmailBody renders a meeting reminder with times in loc
+func reminderEmailBody(event dto.Event, loc *time.Location, note string) string {
+	var details strings.Builder
+	if event.Location != "" {
+		fmt.Fprintf(&details, "\n\t\t<p><strong>Location:</strong> %s</p>", html.EscapeString(event.Location))
+	}
+	if event.ConferenceURL != "" {
+		fmt.Fprintf(&details, "\n\t\t<p><strong>Join:</strong> <a href=\"%[1]s\">%[1]s</a></p>", html.EscapeString(event.ConferenceURL))
+	}
+	if note != "" {
+		fmt.Fprintf(&details, "\n\t\t<p><strong>To prepare:</strong> %s</p>", strings.ReplaceAll(html.EscapeString(note), "\n", "<br>"))
+	}
+	return fmt.Sprintf(`
+		<h2>Meeting Reminder</h2>
+		<p><strong>Title:</strong> %s</p>
+		<p><strong>Time:</strong> %s (%s)</p>%s
+		<p>This reminder was sent by your AI assistant.</p>
+	`, html.EscapeString(event.Title), describeTime(event, loc), loc, details.String())
+}
+
+// reminderKey identifies the reminder for one start of an event to one
+// recipient, so a meeting that is moved is reminded of again.
+func reminderKey(event dto.Event, recipient string) string {
+	return "meeting:" + event.ID + ":" + event.StartTime.UTC().Format(time.RFC3339) + ":" + strings.ToLower(recipient)
+}
+
+// responseOf returns an attendee's reply to an event, if the calendar reports it.
+func responseOf(event dto.Event, email string) string {
+	for attendee, response := range event.Responses {
+		if strings.EqualFold(attendee, email) {
+			return response
+		}
+	}
+	return ""
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,261 @@
+package agent
+
+import (
+	"ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/recurrence"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// tool is a service capability the agent loop can call.
+type tool struct {
+	name        string
+	description string
+	parameters  string
+	sideEffect  bool
+	run         func(ctx context.Context, params dto.ActionParameters) (string, error)
+}
+
+// tools lists the capabilities exposed to the model, in prompt order.
+func (s *Service) tools() []tool {
+	return []tool{
+		{
+			name:        dto.ActionGetEvents,
+			description: "List the user's upcoming calendar events, from every calendar unless one is named.",
+			parameters:  `{"calendar": "calendar name, or empty for all"}`,
+			run:         s.runGetEvents,
+		},
+		{
+			name:        dto.ActionFindFreeSlot,
+			description: "Find times when the user and the attendees are all free during working hours, best first.",
+			parameters:  `{"attendees": ["email"], "duration_minutes": 30, "window_start": "RFC3339", "window_end": "RFC3339"}`,
+			run:         s.runFindFreeSlot,
+		},
+		{
+			name:        dto.ActionScheduleMeeting,
+			description: "Schedule a meeting and invite the attendees. For a repeating meeting, give RFC 5545 recurrence lines; start_time is then the first occurrence.",
+			parameters:  `{"attendees": ["email"], "start_time": "RFC3339", "time_expression": "the user's own words for the time, e.g. next Tuesday at 3pm", "duration_minutes": 30, "title": "Meeting Title", "recurrence": ["RRULE:FREQ=WEEKLY;BYDAY=TU", "EXDATE:20240130T150000Z"], "calendar": "calendar name, or empty for the default"}`,
+			sideEffect:  true,
+			run:         s.runScheduleMeeting,
+		},
+		{
+			name:        dto.ActionRescheduleMeeting,
+			description: "Move an existing meeting and notify its attendees. Identify it by event_id from get_events, or describe it in event.",
+			parameters:  `{"event_id": "id from get_events", "event": "title or time of the meeting, e.g. client call or 3pm", "start_time": "RFC3339", "time_expression": "the user's own words for the new time, e.g. tomorrow", "duration_minutes": 0}`,
+			sideEffect:  true,
+			run:         s.runRescheduleMeeting,
+		},
+		{
+			name:        dto.ActionCancelMeeting,
+			description: "Cancel an existing meeting and notify its attendees. Identify it by event_id from get_events, or describe it in event.",
+			parameters:  `{"event_id": "id from get_events", "event": "title or time of the meeting, e.g. client call or 3pm"}`,
+			sideEffect:  true,
+			run:         s.runCancelMeeting,
+		},
+		{
+			name:        dto.ActionSendEmail,
+			description: "Send an email. Leave body empty to have it written for you.",
+			parameters:  `{"to_email": "email", "to": ["more@example.com"], "cc": ["email"], "bcc": ["email"], "reply_to": "email", "subject": "Email Subject", "body": "Email body content"}`,
+			sideEffect:  true,
+			run:         s.runSendEmail,
+		},
+		{
+			name:        dto.ActionRemind,
+			description: "Email the user a reminder. Without reminder_text the daily schedule digest is sent.",
+			parameters:  `{"reminder_text": "Reminder message"}`,
+			sideEffect:  true,
+			run:         s.runRemind,
+		},
+	}
+}
+
+// tool looks up a tool by name
+func (s *Service) tool(name string) (tool, bool) {
+	for _, t := range s.tools() {
+		if t.name == name {
+			return t, true
+		}
+	}
+	return tool{}, false
+}
+
+// executeAction runs the tool matching a parsed action
+func (s *Service) executeAction(ctx context.Context, action dto.AgentAction) (string, error) {
+	t, ok := s.tool(action.Action)
+	if !ok {
+		return "", fmt.Errorf("%w: %q", errors.ErrUnknownAction, action.Action)
+	}
+
+	s.logger.Info(ctx, "Executing action", zap.String("action", action.Action))
+	return t.run(ctx, action.Parameters)
+}
+
+func (s *Service) runGetEvents(ctx context.Context, params dto.ActionParameters) (string, error) {
+	page, err := s.ListEvents(ctx, dto.EventQuery{Calendar: params.Calendar})
+	if err != nil {
+		return "", err
+	}
+	events := page.Events
+
+	var eventList strings.Builder
+	eventList.WriteString("Upcoming events:\n")
+	for _, event := range events {
+		eventList.WriteString(fmt.Sprintf("- %s (id: %s)\n", eventSummary(event, s.location()), event.ID))
+	}
+	return eventList.String(), nil
+}
+
+func (s *Service) runFindFreeSlot(ctx context.Context, params dto.ActionParameters) (string, error) {
+	req := dto.AvailabilityRequest{
+		Attendees: params.Attendees,
+		Duration:  time.Duration(params.DurationMinutes) * time.Minute,
+		Limit:     3,
+	}
+	if params.WindowStart != "" {
+		req.WindowStart, _ = time.Parse(time.RFC3339, params.WindowStart)
+	}
+	if params.WindowEnd != "" {
+		req.WindowEnd, _ = time.Parse(time.RFC3339, params.WindowEnd)
+	}
+
+	slots, err := s.FindAvailability(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	if len(slots) == 0 {
+		return "No free slot found for everyone in the requested window.", nil
+	}
+
+	var slotList strings.Builder
+	slotList.WriteString("Free slots, best first:\n")
+	for _, slot := range slots {
+		slotList.WriteString(fmt.Sprintf("- %s to %s\n", slot.Start.In(s.location()).Format(time.RFC3339), slot.End.In(s.location()).Format(time.RFC3339)))
+	}
+	return slotList.String(), nil
+}
+
+func (s *Service) runScheduleMeeting(ctx context.Context, params dto.ActionParameters) (string, error) {
+	startTime, _ := time.Parse(time.RFC3339, params.StartTime)
+	return s.runMeeting(ctx, dto.Meeting{
+		Title:      params.Title,
+		Attendees:  params.Attendees,
+		StartTime:  startTime,
+		Duration:   time.Duration(params.DurationMinutes) * time.Minute,
+		Recurrence: params.Recurrence,
+		Calendar:   params.Calendar,
+	})
+}
+
+// runMeeting schedules a meeting and describes the outcome.
+func (s *Service) runMeeting(ctx context.Context, meeting dto.Meeting) (string, error) {
+	meeting.StartTime = meeting.StartTime.In(s.location())
+	result, err := s.ScheduleMeeting(ctx, meeting)
+	if err != nil {
+		return "", err
+	}
+
+	observation := fmt.Sprintf("Meeting %q scheduled for %s (%d minutes) with %s (id: %s).",
+		meeting.Title, meeting.StartTime.Format("Monday, January 2, 2006 at 3:04 PM MST"),
+		int(meeting.Duration.Minutes()), strings.Join(meeting.Attendees, ", "), result.Event.ID)
+	if len(meeting.Recurrence) > 0 {
+		observation += " It repeats " + recurrence.Describe(meeting.Recurrence) + "."
+	}
+	for _, conflict := range result.Conflicts {
+		observation += fmt.Sprintf(" Warning: %s is busy from %s to %s.", conflict.Calendar,
+			conflict.Start.In(s.location()).Format("3:04 PM"), conflict.End.In(s.location()).Format("3:04 PM"))
+	}
+	return observation, nil
+}
+
+func (s *Service) runRescheduleMeeting(ctx context.Context, params dto.ActionParameters) (string, error) {
+	event, startTime, err := s.resolveReschedule(ctx, params)
+	if err != nil {
+		return "", err
+	}
+	duration := time.Duration(params.DurationMinutes) * time.Minute
+
+	event, err = s.RescheduleMeeting(ctx, event.ID, startTime, duration)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Meeting %q moved to %s (%d minutes).",
+		event.Title, event.StartTime.In(s.location()).Format("Monday, January 2, 2006 at 3:04 PM MST"),
+		int(event.EndTime.Sub(event.StartTime).Minutes())), nil
+}
+
+func (s *Service) runCancelMeeting(ctx context.Context, params dto.ActionParameters) (string, error) {
+	event, err := s.lookupEvent(ctx, params)
+	if err != nil {
+		return "", err
+	}
+	if err := s.CancelMeeting(ctx, event.ID); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Meeting %q on %s cancelled.", event.Title, describeTime(event, s.location())), nil
+}
+
+func (s *Service) runSendEmail(ctx context.Context, params dto.ActionParameters) (string, error) {
+	return s.runEmail(ctx, emailMessage(params))
+}
+
+// runEmail sends an email and describes the outcome.
+func (s *Service) runEmail(ctx context.Context, message dto.EmailMessage) (string, error) {
+	if err := s.SendEmail(ctx, message); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Email %q queued for %s.", message.Subject, strings.Join(message.Recipients(), ", ")), nil
+}
+
+// runUpdate updates an event and describes the outcome.
+func (s *Service) runUpdate(ctx context.Context, id string, update dto.EventUpdate) (string, error) {
+	event, err := s.UpdateEvent(ctx, id, update)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Meeting %q updated; it is on %s.", event.Title, describeTime(event, s.location())), nil
+}
+
+// emailMessage is the email a send_email action sends. The model writes
+// plain text, so the body is sent as such.
+func emailMessage(params dto.ActionParameters) dto.EmailMessage {
+	var to []string
+	if params.ToEmail != "" {
+		to = append(to, params.ToEmail)
+	}
+	return dto.EmailMessage{
+		To:      append(to, params.To...),
+		Cc:      params.Cc,
+		Bcc:     params.Bcc,
+		ReplyTo: params.ReplyTo,
+		Subject: params.Subject,
+		Text:    params.Body,
+	}
+}
+
+func (s *Service) runRemind(ctx context.Context, params dto.ActionParameters) (string, error) {
+	if strings.TrimSpace(params.ReminderText) == "" {
+		if err := s.SendDailyReminder(ctx); err != nil {
+			return "", err
+		}
+		return "Daily reminder sent.", nil
+	}
+	if err := s.email.SendEmail(ctx, dto.EmailMessage{To: []string{s.config.UserEmail}, Subject: "Reminder", Text: params.ReminderText}); err != nil {
+		s.logger.Error(ctx, "Failed to send reminder", zap.Error(err))
+		return "", err
+	}
+	return fmt.Sprintf("Reminder sent to %s.", s.config.UserEmail), nil
+}
+
+// location returns the configured time zone, falling back to UTC.
+func (s *Service) location() *time.Location {
+	loc, err := time.LoadLocation(s.config.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
@@ -3,15 +3,33 @@ package service
 import (
 	"ai_agent/internal/constants/model/dto"
 	"context"
+	"io"
 	"time"
 )
 
 type AgentService interface {
-	ProcessNaturalLanguageCommand(ctx context.Context, command string) (string, error)
-	ScheduleMeeting(ctx context.Context, attendees []string,
-		startTime time.Time, duration time.Duration, title string) error
-	SendEmail(ctx context.Context, toEmail string, subject string,
-		body string) error
+	ProcessNaturalLanguageCommand(ctx context.Context, req dto.NaturalLanguageRequest) (dto.CommandResult, error)
+	ConfirmCommand(ctx context.Context, token string, sessionID string) (dto.CommandResult, error)
+	PlanMeeting(ctx context.Context, meeting dto.Meeting) (dto.CommandResult, bool, error)
+	ScheduleMeeting(ctx context.Context, meeting dto.Meeting) (dto.ScheduleResult, error)
+	GetEvent(ctx context.Context, id string) (dto.Event, error)
+	PlanUpdateEvent(ctx context.Context, id string, update dto.EventUpdate) (dto.CommandResult, bool, error)
+	UpdateEvent(ctx context.Context, id string, update dto.EventUpdate) (dto.Event, error)
+	PlanRescheduleMeeting(ctx context.Context, id string,
+		startTime time.Time, duration time.Duration) (dto.CommandResult, bool, error)
+	RescheduleMeeting(ctx context.Context, id string,
+		startTime time.Time, duration time.Duration) (dto.Event, error)
+	PlanCancelMeeting(ctx context.Context, id string) (dto.CommandResult, bool, error)
+	CancelMeeting(ctx context.Context, id string) error
+	PlanEmail(ctx context.Context, message dto.EmailMessage) (dto.CommandResult, bool, error)
+	SendEmail(ctx context.Context, message dto.EmailMessage) error
 	GetUpcomingEvents(ctx context.Context) ([]dto.Event, error)
+	ListEvents(ctx context.Context, query dto.EventQuery) (dto.EventPage, error)
+	ListCalendars(ctx context.Context) []dto.Calendar
+	FindAvailability(ctx context.Context, req dto.AvailabilityRequest) ([]dto.Slot, error)
+	PlanDailyReminder(ctx context.Context) (dto.CommandResult, bool, error)
 	SendDailyReminder(ctx context.Context) error
+	SendMeetingReminders(ctx context.Context) (int, error)
+	ExportEvents(ctx context.Context) ([]byte, error)
+	ImportEvents(ctx context.Context, r io.Reader) (dto.ImportResult, error)
 }
@@ -0,0 +1,53 @@
+package confirmation
+
+import (
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/storage"
+	"context"
+	"sync"
+	"time"
+)
+
+type confirmationStore struct {
+	mu      sync.Mutex
+	pending map[string]dto.PendingAction
+}
+
+// InitConfirmationStore keeps pending actions in memory until they are taken or expire.
+func InitConfirmationStore() storage.Confirmation {
+	return &confirmationStore{
+		pending: make(map[string]dto.PendingAction),
+	}
+}
+
+// Put implements storage.Confirmation.
+func (c *confirmationStore) Put(ctx context.Context, pending dto.PendingAction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.evictExpired(time.Now())
+	c.pending[pending.Token] = pending
+	return nil
+}
+
+// Take implements storage.Confirmation.
+func (c *confirmationStore) Take(ctx context.Context, token string) (dto.PendingAction, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.evictExpired(time.Now())
+	pending, ok := c.pending[token]
+	if !ok {
+		return dto.PendingAction{}, false, nil
+	}
+	delete(c.pending, token)
+	return pending, true, nil
+}
+
+func (c *confirmationStore) evictExpired(now time.Time) {
+	for token, pending := range c.pending {
+		if now.After(pending.ExpiresAt) {
+			delete(c.pending, token)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package job
+
+import (
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+)
+
+type jobStore struct {
+	mu     sync.Mutex
+	path   string
+	states map[string]dto.JobState
+}
+
+// jobFile is the layout of the state file.
+type jobFile struct {
+	Jobs []dto.JobState `json:"jobs"`
+}
+
+// InitJobStore keeps job state in the JSON file at path, which is created on
+// the first save. With an empty path state is kept in memory.
+func InitJobStore(path string) (storage.Job, error) {
+	j := &jobStore{
+		path:   path,
+		states: make(map[string]dto.JobState),
+	}
+	if err := j.load(); err != nil {
+		return nil, fmt.Errorf("failed to read job state %s: %w", path, err)
+	}
+	return j, nil
+}
+
+// Get implements storage.Job.
+func (j *jobStore) Get(ctx context.Context, name string) (dto.JobState, bool, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	state, ok := j.states[name]
+	return state, ok, nil
+}
+
+// Save implements storage.Job.
+func (j *jobStore) Save(ctx context.Context, state dto.JobState) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	previous, existed := j.states[state.Name]
+	j.states[state.Name] = state
+	if err := j.write(); err != nil {
+		// Keep memory and disk in step
+		if existed {
+			j.states[state.Name] = previous
+		} else {
+			delete(j.states, state.Name)
+		}
+		return fmt.Errorf("failed to save job state: %w", err)
+	}
+	return nil
+}
+
+// load reads the state file, which need not exist yet.
+func (j *jobStore) load() error {
+	if j.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(j.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var file jobFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	for _, state := range file.Jobs {
+		j.states[state.Name] = state
+	}
+	return nil
+}
+
+// write replaces the state file atomically. j.mu must be held.
+func (j *jobStore) write() error {
+	if j.path == "" {
+		return nil
+	}
+
+	file := jobFile{Jobs: make([]dto.JobState, 0, len(j.states))}
+	for _, state := range j.states {
+		file.Jobs = append(file.Jobs, state)
+	}
+	sort.Slice(file.Jobs, func(a, b int) bool { return file.Jobs[a].Name < file.Jobs[b].Name })
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return storage.WriteFile(j.path, data)
+}
@@ -0,0 +1,267 @@
+package outbox
+
+import (
+	apperrors "ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type outboxStore struct {
+	mu   sync.Mutex
+	path string
+	// dir holds the email of each message in a file of its own, so a change
+	// of status rewrites only the small index at path
+	dir      string
+	messages map[string]dto.OutboxMessage
+	// emails holds the emails when the outbox is kept in memory
+	emails map[string]dto.EmailMessage
+}
+
+// outboxFile is the layout of the outbox file.
+type outboxFile struct {
+	Messages []dto.OutboxMessage `json:"messages"`
+}
+
+// InitOutboxStore keeps the delivery state of the outbox in the JSON file at
+// path, rewritten after every change, and each email in a file of its own in
+// a directory beside it, such as data/outbox-emails for data/outbox.json. The
+// email of a sent message is removed; only its addresses and subject are
+// kept. With an empty path the outbox is kept in memory and queued emails are
+// lost on restart.
+func InitOutboxStore(path string) (storage.Outbox, error) {
+	o := &outboxStore{
+		path:     path,
+		messages: make(map[string]dto.OutboxMessage),
+		emails:   make(map[string]dto.EmailMessage),
+	}
+	if path != "" {
+		o.dir = strings.TrimSuffix(path, filepath.Ext(path)) + "-emails"
+	}
+	if err := o.load(); err != nil {
+		return nil, fmt.Errorf("failed to read outbox %s: %w", path, err)
+	}
+	return o, nil
+}
+
+// Save implements storage.Outbox.
+func (o *outboxStore) Save(ctx context.Context, message dto.OutboxMessage) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	newEmail := hasContent(message.Message)
+	if newEmail {
+		if err := o.writeEmail(message.ID, message.Message); err != nil {
+			return fmt.Errorf("failed to save outbox email: %w", err)
+		}
+	}
+
+	previous, existed := o.messages[message.ID]
+	o.messages[message.ID] = withoutContent(message)
+	if err := o.write(); err != nil {
+		// Keep memory and disk in step
+		if existed {
+			o.messages[message.ID] = previous
+		} else {
+			delete(o.messages, message.ID)
+			o.removeEmail(message.ID)
+		}
+		return fmt.Errorf("failed to save outbox: %w", err)
+	}
+
+	// A sent email is not needed again
+	if message.Status == dto.OutboxSent {
+		o.removeEmail(message.ID)
+	}
+	return nil
+}
+
+// List implements storage.Outbox.
+func (o *outboxStore) List(ctx context.Context) ([]dto.OutboxMessage, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.sorted(), nil
+}
+
+// Pending implements storage.Outbox.
+func (o *outboxStore) Pending(ctx context.Context) ([]dto.OutboxMessage, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var pending []dto.OutboxMessage
+	for _, message := range o.messages {
+		if message.Status == dto.OutboxQueued || message.Status == dto.OutboxRetrying {
+			pending = append(pending, message)
+		}
+	}
+	sortOldestFirst(pending)
+	return pending, nil
+}
+
+// Email implements storage.Outbox.
+func (o *outboxStore) Email(ctx context.Context, id string) (dto.EmailMessage, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.dir == "" {
+		email, ok := o.emails[id]
+		if !ok {
+			return dto.EmailMessage{}, fmt.Errorf("%w: no email stored for message %s", apperrors.ErrNotFound, id)
+		}
+		return email, nil
+	}
+
+	data, err := os.ReadFile(o.emailPath(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return dto.EmailMessage{}, fmt.Errorf("%w: no email stored for message %s", apperrors.ErrNotFound, id)
+	}
+	if err != nil {
+		return dto.EmailMessage{}, fmt.Errorf("failed to read outbox email: %w", err)
+	}
+	var email dto.EmailMessage
+	if err := json.Unmarshal(data, &email); err != nil {
+		return dto.EmailMessage{}, fmt.Errorf("failed to read outbox email: %w", err)
+	}
+	return email, nil
+}
+
+// Delete implements storage.Outbox.
+func (o *outboxStore) Delete(ctx context.Context, id string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	previous, ok := o.messages[id]
+	if !ok {
+		return nil
+	}
+	delete(o.messages, id)
+	if err := o.write(); err != nil {
+		o.messages[id] = previous
+		return fmt.Errorf("failed to save outbox: %w", err)
+	}
+	o.removeEmail(id)
+	return nil
+}
+
+// sorted returns the messages oldest first. o.mu must be held.
+func (o *outboxStore) sorted() []dto.OutboxMessage {
+	messages := make([]dto.OutboxMessage, 0, len(o.messages))
+	for _, message := range o.messages {
+		messages = append(messages, message)
+	}
+	sortOldestFirst(messages)
+	return messages
+}
+
+func sortOldestFirst(messages []dto.OutboxMessage) {
+	sort.Slice(messages, func(i, j int) bool {
+		if !messages[i].CreatedAt.Equal(messages[j].CreatedAt) {
+			return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+		}
+		return messages[i].ID < messages[j].ID
+	})
+}
+
+// load reads the outbox file, which need not exist yet.
+func (o *outboxStore) load() error {
+	if o.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(o.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var file outboxFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	for _, message := range file.Messages {
+		o.messages[message.ID] = message
+	}
+	return nil
+}
+
+// write replaces the outbox file atomically. o.mu must be held.
+func (o *outboxStore) write() error {
+	if o.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(outboxFile{Messages: o.sorted()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return storage.WriteFile(o.path, data)
+}
+
+// writeEmail stores the email of a message. o.mu must be held.
+func (o *outboxStore) writeEmail(id string, email dto.EmailMessage) error {
+	if o.dir == "" {
+		o.emails[id] = email
+		return nil
+	}
+	data, err := json.Marshal(email)
+	if err != nil {
+		return err
+	}
+	return storage.WriteFile(o.emailPath(id), data)
+}
+
+// removeEmail removes the email of a message, if it is stored. o.mu must be
+// held.
+func (o *outboxStore) removeEmail(id string) {
+	if o.dir == "" {
+		delete(o.emails, id)
+		return
+	}
+	os.Remove(o.emailPath(id))
+}
+
+// emailPath names the file holding a message's email. IDs are generated by
+// the outbox, never taken from requests.
+func (o *outboxStore) emailPath(id string) string {
+	return filepath.Join(o.dir, filepath.Base(id)+".json")
+}
+
+// hasContent reports whether an email carries its bodies or attachments,
+// which only the message being queued does.
+func hasContent(email dto.EmailMessage) bool {
+	if email.Text != "" || email.HTML != "" {
+		return true
+	}
+	for _, attachment := range email.Attachments {
+		if len(attachment.Content) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutContent leaves the bodies and attachment content out of a message,
+// keeping the attachments' names.
+func withoutContent(message dto.OutboxMessage) dto.OutboxMessage {
+	email := &message.Message
+	email.Text, email.HTML = "", ""
+	if len(email.Attachments) > 0 {
+		attachments := make([]dto.EmailAttachment, len(email.Attachments))
+		for i, attachment := range email.Attachments {
+			attachment.Content = nil
+			attachments[i] = attachment
+		}
+		email.Attachments = attachments
+	}
+	return message
+}
@@ -0,0 +1,129 @@
+package reminder
+
+import (
+	"ai_agent/internal/storage"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pruneInterval is how often expired claims are removed from the directory.
+const pruneInterval = time.Hour
+
+// claimSuffix names the files that hold claims.
+const claimSuffix = ".claim"
+
+type reminderStore struct {
+	mu         sync.Mutex
+	dir        string
+	claims     map[string]time.Time
+	lastPruned time.Time
+}
+
+// InitReminderStore keeps claims as files in dir, one per reminder, created
+// exclusively so that instances sharing the directory agree on who sends
+// each reminder, also across restarts. With an empty dir claims are kept in
+// memory.
+func InitReminderStore(dir string) (storage.Reminder, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create reminder directory: %w", err)
+		}
+	}
+	return &reminderStore{
+		dir:    dir,
+		claims: make(map[string]time.Time),
+	}, nil
+}
+
+// Claim implements storage.Reminder.
+func (r *reminderStore) Claim(ctx context.Context, key string, expires time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if r.dir == "" {
+		for claimed, until := range r.claims {
+			if now.After(until) {
+				delete(r.claims, claimed)
+			}
+		}
+		if _, ok := r.claims[key]; ok {
+			return false, nil
+		}
+		r.claims[key] = expires
+		return true, nil
+	}
+
+	if now.Sub(r.lastPruned) >= pruneInterval {
+		r.prune(now)
+		r.lastPruned = now
+	}
+
+	file, err := os.OpenFile(r.path(key), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim reminder: %w", err)
+	}
+	defer file.Close()
+
+	// The claim stands even if its expiry cannot be written; prune then keeps it
+	file.WriteString(expires.UTC().Format(time.RFC3339) + "\n" + key + "\n")
+	return true, nil
+}
+
+// Release implements storage.Reminder.
+func (r *reminderStore) Release(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.dir == "" {
+		delete(r.claims, key)
+		return nil
+	}
+	if err := os.Remove(r.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to release reminder: %w", err)
+	}
+	return nil
+}
+
+// path names the file for a claim by a hash of its key, which may hold
+// characters that are not allowed in file names.
+func (r *reminderStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(r.dir, hex.EncodeToString(sum[:16])+claimSuffix)
+}
+
+// prune removes claims that have expired. Claims whose expiry cannot be read
+// are kept.
+func (r *reminderStore) prune(now time.Time) {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), claimSuffix) {
+			continue
+		}
+		path := filepath.Join(r.dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		line, _, _ := strings.Cut(string(data), "\n")
+		expires, err := time.Parse(time.RFC3339, line)
+		if err == nil && now.After(expires) {
+			os.Remove(path)
+		}
+	}
+}
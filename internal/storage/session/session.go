@@ -0,0 +1,66 @@
+package session
+
+import (
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/storage"
+	"context"
+	"sync"
+	"time"
+)
+
+// maxTurns bounds how much history is kept, and sent to the model, per session.
+const maxTurns = 20
+
+type sessionStore struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	sessions map[string]dto.Session
+}
+
+// InitSessionStore keeps sessions in memory and forgets them after ttl of inactivity.
+func InitSessionStore(ttl time.Duration) storage.Session {
+	return &sessionStore{
+		ttl:      ttl,
+		sessions: make(map[string]dto.Session),
+	}
+}
+
+// Get implements storage.Session.
+func (s *sessionStore) Get(ctx context.Context, id string) (dto.Session, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evictExpired(time.Now())
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return dto.Session{}, false, nil
+	}
+	session.Turns = append([]dto.ConversationTurn(nil), session.Turns...)
+	return session, true, nil
+}
+
+// Save implements storage.Session.
+func (s *sessionStore) Save(ctx context.Context, session dto.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(session.Turns) > maxTurns {
+		session.Turns = session.Turns[len(session.Turns)-maxTurns:]
+	}
+	session.Turns = append([]dto.ConversationTurn(nil), session.Turns...)
+	session.UpdatedAt = time.Now()
+	s.sessions[session.ID] = session
+	return nil
+}
+
+func (s *sessionStore) evictExpired(now time.Time) {
+	if s.ttl <= 0 {
+		return
+	}
+	for id, session := range s.sessions {
+		if now.Sub(session.UpdatedAt) > s.ttl {
+			delete(s.sessions, id)
+		}
+	}
+}
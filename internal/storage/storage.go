@@ -1 +1,52 @@
 package storage
+
+import (
+	"ai_agent/internal/constants/model/dto"
+	"context"
+	"time"
+)
+
+type Session interface {
+	Get(ctx context.Context, id string) (dto.Session, bool, error)
+	Save(ctx context.Context, session dto.Session) error
+}
+
+type Confirmation interface {
+	Put(ctx context.Context, pending dto.PendingAction) error
+	// Take returns the pending action and removes it, so a token can only be used once.
+	Take(ctx context.Context, token string) (dto.PendingAction, bool, error)
+}
+
+// Reminder records the reminders that were sent. Replicas sharing a store see
+// each other's claims, so a reminder is sent by only one of them.
+type Reminder interface {
+	// Claim records key and reports whether it was not recorded before. The
+	// record may be forgotten once expires has passed.
+	Claim(ctx context.Context, key string, expires time.Time) (bool, error)
+	// Release forgets key, so a reminder that could not be sent is tried again.
+	Release(ctx context.Context, key string) error
+}
+
+// Job keeps the state of scheduled jobs.
+type Job interface {
+	Get(ctx context.Context, name string) (dto.JobState, bool, error)
+	Save(ctx context.Context, state dto.JobState) error
+}
+
+// Outbox keeps the emails waiting to be sent and the outcome of those that
+// were.
+type Outbox interface {
+	// Save adds a message or replaces the one with its ID. The email's bodies
+	// and attachments are stored when it has them and otherwise left as they
+	// were, so a change of status need not carry them.
+	Save(ctx context.Context, message dto.OutboxMessage) error
+	// List returns the messages, oldest first, without their emails' bodies
+	// and attachment content.
+	List(ctx context.Context) ([]dto.OutboxMessage, error)
+	// Pending returns the messages waiting for an attempt, queued or
+	// retrying, oldest first and like List without their emails' content.
+	Pending(ctx context.Context) ([]dto.OutboxMessage, error)
+	// Email returns the whole email of a message that is not yet sent.
+	Email(ctx context.Context, id string) (dto.EmailMessage, error)
+	Delete(ctx context.Context, id string) error
+}
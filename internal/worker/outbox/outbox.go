@@ -0,0 +1,337 @@
+package outbox
+
+import (
+	apperrors "ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/storage"
+	"ai_agent/platform"
+	"ai_agent/platform/logger"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// pollInterval bounds how long an idle worker waits before looking for
+	// messages that have become due.
+	pollInterval = 5 * time.Second
+	// retention is how long sent and dead messages are kept for inspection.
+	retention = 7 * 24 * time.Hour
+	// pruneInterval is how often messages past their retention are removed.
+	pruneInterval = time.Hour
+	// maxBackoff caps the wait between attempts.
+	maxBackoff = time.Hour
+)
+
+// Outbox delivers email in the background. Sends are stored before they
+// return and handed to the provider by a pool of workers, which retry
+// failures with exponential backoff and give up after a number of attempts.
+// Stored messages survive restarts; one that was being sent when the
+// assistant stopped is sent again, so a recipient may rarely get it twice.
+type Outbox struct {
+	email       platform.Email
+	store       storage.Outbox
+	logger      logger.Logger
+	workers     int
+	maxAttempts int
+	backoff     time.Duration
+	now         func() time.Time
+
+	mu sync.Mutex
+	// inFlight holds the IDs being sent, so no two workers take the same one
+	inFlight map[string]bool
+	wake     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewOutbox sends through email with config.EmailWorkers workers, making up
+// to config.EmailMaxAttempts attempts per message.
+func NewOutbox(email platform.Email, store storage.Outbox, config dto.Config, logger logger.Logger) *Outbox {
+	workers := config.EmailWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+	maxAttempts := config.EmailMaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+	return &Outbox{
+		email:       email,
+		store:       store,
+		logger:      logger,
+		workers:     workers,
+		maxAttempts: maxAttempts,
+		backoff:     30 * time.Second,
+		now:         time.Now,
+		inFlight:    make(map[string]bool),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// SendEmail implements platform.Email. It returns once the email is queued;
+// its delivery is reported by Messages. A message that could never be sent
+// is refused rather than queued.
+func (o *Outbox) SendEmail(ctx context.Context, message dto.EmailMessage) error {
+	if err := message.Validate(); err != nil {
+		return err
+	}
+	id, err := newMessageID()
+	if err != nil {
+		return err
+	}
+	now := o.now()
+	queued := dto.OutboxMessage{
+		ID:            id,
+		Message:       message,
+		Status:        dto.OutboxQueued,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		NextAttemptAt: now,
+	}
+	if err := o.store.Save(ctx, queued); err != nil {
+		o.logger.Error(ctx, "Failed to queue email", zap.Strings("to", message.To), zap.Error(err))
+		return err
+	}
+	o.logger.Info(ctx, "Queued email", zap.String("message_id", id), zap.Strings("to", message.To), zap.String("subject", message.Subject))
+
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Messages implements worker.Outbox. The emails are listed without their
+// bodies and attachment content.
+func (o *Outbox) Messages(ctx context.Context, status string) ([]dto.OutboxMessage, error) {
+	messages, err := o.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if status == "" {
+		return messages, nil
+	}
+	var matching []dto.OutboxMessage
+	for _, message := range messages {
+		if message.Status == status {
+			matching = append(matching, message)
+		}
+	}
+	return matching, nil
+}
+
+// Start delivers queued email until ctx is cancelled. Messages left sending
+// by a previous run are queued again, and sent and dead ones are removed once
+// past their retention, at the start and then every pruneInterval.
+func (o *Outbox) Start(ctx context.Context) {
+	o.requeue(ctx)
+	o.prune(ctx)
+
+	o.logger.Info(ctx, "Starting email outbox", zap.Int("workers", o.workers))
+	for range o.workers {
+		o.wg.Add(1)
+		go o.work(ctx)
+	}
+	o.wg.Add(1)
+	go o.pruneLoop(ctx)
+}
+
+// Wait blocks until the workers have stopped, which they do after finishing
+// the sends in progress when Start's context was cancelled, or ctx is done.
+func (o *Outbox) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		o.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (o *Outbox) work(ctx context.Context) {
+	defer o.wg.Done()
+
+	for ctx.Err() == nil {
+		message, wait, ok := o.next(ctx)
+		if ok {
+			// A send in progress at shutdown is finished, not abandoned
+			o.deliver(context.WithoutCancel(ctx), message)
+			continue
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+		case <-o.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// next takes the oldest message that is due and marks it as sending. When
+// none is due it returns how long to wait for the next.
+func (o *Outbox) next(ctx context.Context) (dto.OutboxMessage, time.Duration, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	messages, err := o.store.Pending(ctx)
+	if err != nil {
+		o.logger.Error(ctx, "Failed to read outbox", zap.Error(err))
+		return dto.OutboxMessage{}, pollInterval, false
+	}
+
+	now := o.now()
+	wait := pollInterval
+	for _, message := range messages {
+		if o.inFlight[message.ID] {
+			continue
+		}
+		if until := message.NextAttemptAt.Sub(now); until > 0 {
+			wait = min(wait, until)
+			continue
+		}
+
+		message.Status = dto.OutboxSending
+		message.Attempts++
+		message.UpdatedAt = now
+		if err := o.store.Save(ctx, message); err != nil {
+			o.logger.Error(ctx, "Failed to update outbox", zap.String("message_id", message.ID), zap.Error(err))
+			return dto.OutboxMessage{}, pollInterval, false
+		}
+		o.inFlight[message.ID] = true
+		return message, 0, true
+	}
+	return dto.OutboxMessage{}, wait, false
+}
+
+// deliver sends a message and records the outcome.
+func (o *Outbox) deliver(ctx context.Context, message dto.OutboxMessage) {
+	email, err := o.store.Email(ctx, message.ID)
+	if err == nil {
+		err = o.email.SendEmail(ctx, email)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.inFlight, message.ID)
+
+	now := o.now()
+	message.UpdatedAt = now
+	switch {
+	case err == nil:
+		message.Status = dto.OutboxSent
+		message.SentAt = now
+		message.NextAttemptAt = time.Time{}
+		o.logger.Info(ctx, "Delivered email", zap.String("message_id", message.ID), zap.Int("attempts", message.Attempts))
+	case permanent(err) || message.Attempts >= o.maxAttempts:
+		message.Status = dto.OutboxDead
+		message.LastError = err.Error()
+		message.NextAttemptAt = time.Time{}
+		o.logger.Error(ctx, "Gave up delivering email",
+			zap.String("message_id", message.ID), zap.Strings("to", message.Message.To), zap.Int("attempts", message.Attempts), zap.Error(err))
+	default:
+		message.Status = dto.OutboxRetrying
+		message.LastError = err.Error()
+		message.NextAttemptAt = now.Add(o.delay(message.Attempts))
+		o.logger.Warn(ctx, "Email delivery failed, will retry",
+			zap.String("message_id", message.ID), zap.Int("attempts", message.Attempts), zap.Time("next_attempt_at", message.NextAttemptAt), zap.Error(err))
+	}
+
+	if err := o.store.Save(ctx, message); err != nil {
+		o.logger.Error(ctx, "Failed to update outbox", zap.String("message_id", message.ID), zap.Error(err))
+	}
+}
+
+// delay is the wait after the given number of failed attempts, doubling each
+// time up to maxBackoff.
+func (o *Outbox) delay(attempts int) time.Duration {
+	delay := o.backoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// requeue queues again the messages that were being sent when the assistant
+// stopped. It runs before the workers start, so none of them is in flight.
+func (o *Outbox) requeue(ctx context.Context) {
+	messages, err := o.store.List(ctx)
+	if err != nil {
+		o.logger.Error(ctx, "Failed to read outbox", zap.Error(err))
+		return
+	}
+
+	now := o.now()
+	for _, message := range messages {
+		if message.Status != dto.OutboxSending {
+			continue
+		}
+		o.logger.Warn(ctx, "Requeueing email interrupted while sending", zap.String("message_id", message.ID))
+		message.Status = dto.OutboxRetrying
+		message.NextAttemptAt = now
+		message.UpdatedAt = now
+		if err := o.store.Save(ctx, message); err != nil {
+			o.logger.Error(ctx, "Failed to update outbox", zap.String("message_id", message.ID), zap.Error(err))
+		}
+	}
+}
+
+func (o *Outbox) pruneLoop(ctx context.Context) {
+	defer o.wg.Done()
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			o.prune(ctx)
+		}
+	}
+}
+
+// prune removes sent and dead messages past their retention.
+func (o *Outbox) prune(ctx context.Context) {
+	messages, err := o.store.List(ctx)
+	if err != nil {
+		o.logger.Error(ctx, "Failed to read outbox", zap.Error(err))
+		return
+	}
+
+	now := o.now()
+	for _, message := range messages {
+		if message.Status != dto.OutboxSent && message.Status != dto.OutboxDead || now.Sub(message.UpdatedAt) <= retention {
+			continue
+		}
+		if err := o.store.Delete(ctx, message.ID); err != nil {
+			o.logger.Error(ctx, "Failed to remove old email", zap.String("message_id", message.ID), zap.Error(err))
+		}
+	}
+}
+
+// permanent reports whether an error is the message's fault, or its email is
+// gone, so sending it again would fail the same way.
+func permanent(err error) bool {
+	return errors.Is(err, apperrors.ErrInvalidInput) || errors.Is(err, apperrors.ErrBadRequest) || errors.Is(err, apperrors.ErrInvalidData) ||
+		errors.Is(err, apperrors.ErrNotFound)
+}
+
+func newMessageID() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate message ID: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
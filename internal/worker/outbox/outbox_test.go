@@ -0,0 +1,310 @@
+package outbox
+
+import (
+	apperrors "ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	outboxstore "ai_agent/internal/storage/outbox"
+	"ai_agent/platform/logger"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// fakeEmail answers each send with the next of its errors, and nil once they
+// run out. With release set a send waits for it to be closed.
+type fakeEmail struct {
+	mu      sync.Mutex
+	errs    []error
+	sent    []dto.EmailMessage
+	started chan string
+	release chan struct{}
+}
+
+func (e *fakeEmail) SendEmail(ctx context.Context, message dto.EmailMessage) error {
+	if e.started != nil {
+		e.started <- message.Subject
+	}
+	if e.release != nil {
+		<-e.release
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var err error
+	if len(e.errs) > 0 {
+		err, e.errs = e.errs[0], e.errs[1:]
+	}
+	if err == nil {
+		e.sent = append(e.sent, message)
+	}
+	return err
+}
+
+func (e *fakeEmail) subjects() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var subjects []string
+	for _, message := range e.sent {
+		subjects = append(subjects, message.Subject)
+	}
+	return subjects
+}
+
+func testMessage(subject string) dto.EmailMessage {
+	return dto.EmailMessage{
+		To:      []string{"bob@example.com"},
+		Subject: subject,
+		Text:    "Notes attached.",
+	}
+}
+
+func newTestOutbox(t *testing.T, email *fakeEmail, maxAttempts int) *Outbox {
+	t.Helper()
+	store, err := outboxstore.InitOutboxStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := dto.Config{EmailWorkers: 1, EmailMaxAttempts: maxAttempts}
+	return NewOutbox(email, store, config, logger.InitLogger(zap.NewNop()))
+}
+
+func TestSendEmailQueues(t *testing.T) {
+	ctx := context.Background()
+	o := newTestOutbox(t, &fakeEmail{}, 3)
+	now := time.Date(2026, 10, 27, 10, 30, 0, 0, time.UTC)
+	o.now = func() time.Time { return now }
+
+	if err := o.SendEmail(ctx, testMessage("Standup notes")); err != nil {
+		t.Fatalf("SendEmail failed: %v", err)
+	}
+	invalid := testMessage("Standup notes")
+	invalid.To = nil
+	if err := o.SendEmail(ctx, invalid); !errors.Is(err, apperrors.ErrInvalidInput) {
+		t.Errorf("SendEmail without recipients error = %v, want ErrInvalidInput", err)
+	}
+
+	queued, err := o.Messages(ctx, dto.OutboxQueued)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queued) != 1 {
+		t.Fatalf("queued = %+v, want one message", queued)
+	}
+	message := queued[0]
+	if message.Attempts != 0 || !message.NextAttemptAt.Equal(now) || message.Message.Text != "" {
+		t.Errorf("queued message = %+v, want no attempts, due now and listed without its body", message)
+	}
+	email, err := o.store.Email(ctx, message.ID)
+	if err != nil || email.Text != "Notes attached." {
+		t.Errorf("stored email = %+v, %v; want the whole email", email, err)
+	}
+}
+
+func TestDelivery(t *testing.T) {
+	unavailable := fmt.Errorf("%w: connection refused", apperrors.ErrUnavailable)
+
+	tests := []struct {
+		name        string
+		errs        []error
+		maxAttempts int
+		wantStatus  string
+		wantSent    bool
+		// wantWaits are the waits before each retry
+		wantWaits []time.Duration
+	}{
+		{
+			name:        "sent at the first attempt",
+			maxAttempts: 3,
+			wantStatus:  dto.OutboxSent,
+			wantSent:    true,
+		},
+		{
+			name:        "retried with backoff then sent",
+			errs:        []error{unavailable, unavailable},
+			maxAttempts: 3,
+			wantStatus:  dto.OutboxSent,
+			wantSent:    true,
+			wantWaits:   []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:        "dead after the last attempt",
+			errs:        []error{unavailable, unavailable, unavailable},
+			maxAttempts: 3,
+			wantStatus:  dto.OutboxDead,
+			wantWaits:   []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:        "dead at once when the message is refused",
+			errs:        []error{fmt.Errorf("%w: mailbox does not exist", apperrors.ErrInvalidInput)},
+			maxAttempts: 3,
+			wantStatus:  dto.OutboxDead,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			email := &fakeEmail{errs: tt.errs}
+			o := newTestOutbox(t, email, tt.maxAttempts)
+			o.backoff = time.Second
+			now := time.Date(2026, 10, 27, 10, 30, 0, 0, time.UTC)
+			o.now = func() time.Time { return now }
+
+			if err := o.SendEmail(ctx, testMessage("Standup notes")); err != nil {
+				t.Fatalf("SendEmail failed: %v", err)
+			}
+
+			var waits []time.Duration
+			for range 10 {
+				message, wait, ok := o.next(ctx)
+				if !ok {
+					if wait >= pollInterval {
+						break
+					}
+					waits = append(waits, wait)
+					now = now.Add(wait)
+					continue
+				}
+				o.deliver(ctx, message)
+			}
+
+			messages, err := o.Messages(ctx, "")
+			if err != nil || len(messages) != 1 {
+				t.Fatalf("messages = %+v, %v; want one", messages, err)
+			}
+			final := messages[0]
+			if final.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", final.Status, tt.wantStatus)
+			}
+			if want := len(tt.errs) + 1; tt.wantSent && final.Attempts != want {
+				t.Errorf("attempts = %d, want %d", final.Attempts, want)
+			}
+			if got := len(email.sent) == 1; got != tt.wantSent {
+				t.Errorf("sent = %+v, want sent %v", email.sent, tt.wantSent)
+			}
+			if !slices.Equal(waits, tt.wantWaits) {
+				t.Errorf("waits = %v, want %v", waits, tt.wantWaits)
+			}
+			if tt.wantStatus == dto.OutboxDead && final.LastError == "" {
+				t.Error("dead message has no last error")
+			}
+			if _, err := o.store.Email(ctx, final.ID); tt.wantSent && !errors.Is(err, apperrors.ErrNotFound) {
+				t.Errorf("email of a sent message still stored, error = %v", err)
+			}
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	ctx := context.Background()
+	o := newTestOutbox(t, &fakeEmail{}, 3)
+	now := time.Date(2026, 10, 27, 10, 30, 0, 0, time.UTC)
+	o.now = func() time.Time { return now }
+
+	old := now.Add(-retention - time.Minute)
+	recent := now.Add(-retention + time.Minute)
+	for _, message := range []dto.OutboxMessage{
+		{ID: "old-sent", Status: dto.OutboxSent, UpdatedAt: old},
+		{ID: "old-dead", Status: dto.OutboxDead, UpdatedAt: old},
+		{ID: "recent-sent", Status: dto.OutboxSent, UpdatedAt: recent},
+		{ID: "old-retrying", Status: dto.OutboxRetrying, UpdatedAt: old, NextAttemptAt: now},
+	} {
+		message.Message = testMessage(message.ID)
+		if err := o.store.Save(ctx, message); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	o.prune(ctx)
+
+	messages, err := o.Messages(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+	slices.Sort(ids)
+	if want := []string{"old-retrying", "recent-sent"}; !slices.Equal(ids, want) {
+		t.Errorf("kept %q, want %q", ids, want)
+	}
+}
+
+func TestRequeue(t *testing.T) {
+	ctx := context.Background()
+	o := newTestOutbox(t, &fakeEmail{}, 3)
+	now := time.Date(2026, 10, 27, 10, 30, 0, 0, time.UTC)
+	o.now = func() time.Time { return now }
+
+	interrupted := dto.OutboxMessage{
+		ID:        "interrupted",
+		Message:   testMessage("Standup notes"),
+		Status:    dto.OutboxSending,
+		Attempts:  1,
+		CreatedAt: now.Add(-time.Hour),
+		UpdatedAt: now.Add(-time.Hour),
+	}
+	if err := o.store.Save(ctx, interrupted); err != nil {
+		t.Fatal(err)
+	}
+
+	o.requeue(ctx)
+
+	message, _, ok := o.next(ctx)
+	if !ok || message.ID != "interrupted" || message.Attempts != 2 {
+		t.Errorf("next = %+v, %v; want the interrupted message on its second attempt", message, ok)
+	}
+}
+
+func TestDrain(t *testing.T) {
+	email := &fakeEmail{started: make(chan string, 2), release: make(chan struct{})}
+	o := newTestOutbox(t, email, 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	o.Start(ctx)
+
+	for _, subject := range []string{"First", "Second"} {
+		if err := o.SendEmail(ctx, testMessage(subject)); err != nil {
+			t.Fatalf("SendEmail failed: %v", err)
+		}
+	}
+	select {
+	case subject := <-email.started:
+		if subject != "First" {
+			t.Fatalf("sent %q first, want the oldest", subject)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("first email was not sent")
+	}
+
+	cancel()
+	waitCtx, stop := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer stop()
+	if err := o.Wait(waitCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait during a send = %v, want it to wait for the send", err)
+	}
+
+	close(email.release)
+	if err := o.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	if got := email.subjects(); !slices.Equal(got, []string{"First"}) {
+		t.Errorf("sent %q, want only the send in progress finished", got)
+	}
+
+	queued, err := o.Messages(context.Background(), dto.OutboxQueued)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queued) != 1 || queued[0].Message.Subject != "Second" {
+		t.Errorf("queued = %+v, want the second email left for the next start", queued)
+	}
+}
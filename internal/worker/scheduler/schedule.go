@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+)
+
+// Schedule says when a job is due.
+type Schedule interface {
+	// Next returns the first due time after t.
+	Next(t time.Time) time.Time
+	String() string
+}
+
+type daily struct {
+	hour, minute int
+	loc          *time.Location
+}
+
+// Daily is due every day at clock, such as "09:00", in loc. When a daylight
+// saving change skips that time the job runs as much later as the clock
+// jumped (02:30 becomes 03:30), and when the time occurs twice it runs at the
+// first.
+func Daily(clock string, loc *time.Location) (Schedule, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time of day %q, want HH:MM", clock)
+	}
+	return daily{hour: t.Hour(), minute: t.Minute(), loc: loc}, nil
+}
+
+func (d daily) Next(t time.Time) time.Time {
+	year, month, day := t.In(d.loc).Date()
+	for i := 0; ; i++ {
+		// Computed from the date each time, so the wall clock time holds
+		// across changes of UTC offset
+		due := time.Date(year, month, day+i, d.hour, d.minute, 0, 0, d.loc)
+		// In a skipped hour time.Date may go back by the jump rather than
+		// forward; if so go forward
+		want := time.Date(year, month, day+i, d.hour, d.minute, 0, 0, time.UTC)
+		got := time.Date(due.Year(), due.Month(), due.Day(), due.Hour(), due.Minute(), 0, 0, time.UTC)
+		if got.Before(want) {
+			due = due.Add(want.Sub(got))
+		}
+		due = firstOccurrence(due)
+		if due.After(t) {
+			return due
+		}
+	}
+}
+
+// firstOccurrence returns the earlier instant with t's wall clock time when
+// clocks going back made that time occur twice, and otherwise t. time.Date
+// may give either.
+func firstOccurrence(t time.Time) time.Time {
+	start, _ := t.ZoneBounds()
+	if start.IsZero() {
+		return t
+	}
+	_, offset := t.Zone()
+	_, before := start.Add(-time.Second).Zone()
+	if before <= offset {
+		return t
+	}
+	if earlier := t.Add(-time.Duration(before-offset) * time.Second); earlier.Before(start) {
+		return earlier
+	}
+	return t
+}
+
+func (d daily) String() string {
+	return fmt.Sprintf("daily at %02d:%02d %s", d.hour, d.minute, d.loc)
+}
+
+type every struct {
+	interval time.Duration
+}
+
+// Every is due at each multiple of interval.
+func Every(interval time.Duration) Schedule {
+	return every{interval: interval}
+}
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Truncate(e.interval).Add(e.interval)
+}
+
+func (e every) String() string {
+	return "every " + e.interval.String()
+}
@@ -0,0 +1,165 @@
+package scheduler
+
+import (
+	"ai_agent/internal/constants/model/dto"
+	jobstore "ai_agent/internal/storage/job"
+	"ai_agent/platform/logger"
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	return loc
+}
+
+func TestDailyNext(t *testing.T) {
+	london := loadLocation(t, "Europe/London")
+	newYork := loadLocation(t, "America/New_York")
+
+	// In 2024 London's clocks went forward at 01:00 on March 31 and back at
+	// 02:00 on October 27; New York's forward at 02:00 on March 10 and back
+	// at 02:00 on November 3.
+	tests := []struct {
+		name  string
+		clock string
+		loc   *time.Location
+		after time.Time
+		want  string
+	}{
+		{"later today", "09:00", london, time.Date(2024, 3, 30, 8, 0, 0, 0, london), "2024-03-30T09:00:00Z"},
+		{"tomorrow once passed", "09:00", london, time.Date(2024, 3, 30, 9, 0, 0, 0, london), "2024-03-31T09:00:00+01:00"},
+
+		{"london skipped time", "01:30", london, time.Date(2024, 3, 30, 12, 0, 0, 0, london), "2024-03-31T02:30:00+01:00"},
+		{"london day after skipped time", "01:30", london, time.Date(2024, 3, 31, 2, 30, 0, 0, london), "2024-04-01T01:30:00+01:00"},
+		{"london start of skipped hour", "01:00", london, time.Date(2024, 3, 30, 12, 0, 0, 0, london), "2024-03-31T02:00:00+01:00"},
+		{"london repeated time runs at the first", "01:30", london, time.Date(2024, 10, 26, 12, 0, 0, 0, london), "2024-10-27T01:30:00+01:00"},
+		{"london not again at the second", "01:30", london, time.Date(2024, 10, 27, 0, 30, 0, 0, time.UTC), "2024-10-28T01:30:00Z"},
+
+		{"new york skipped time", "02:30", newYork, time.Date(2024, 3, 9, 12, 0, 0, 0, newYork), "2024-03-10T03:30:00-04:00"},
+		{"new york day after skipped time", "02:30", newYork, time.Date(2024, 3, 10, 3, 30, 0, 0, newYork), "2024-03-11T02:30:00-04:00"},
+		{"new york across spring forward", "08:00", newYork, time.Date(2024, 3, 9, 8, 0, 0, 0, newYork), "2024-03-10T08:00:00-04:00"},
+		{"new york repeated time runs at the first", "01:30", newYork, time.Date(2024, 11, 2, 12, 0, 0, 0, newYork), "2024-11-03T01:30:00-04:00"},
+		{"new york not again at the second", "01:30", newYork, time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC), "2024-11-04T01:30:00-05:00"},
+		{"new york across fall back", "08:00", newYork, time.Date(2024, 11, 2, 8, 0, 0, 0, newYork), "2024-11-03T08:00:00-05:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Daily(tt.clock, tt.loc)
+			if err != nil {
+				t.Fatalf("Daily(%q) failed: %v", tt.clock, err)
+			}
+			got := schedule.Next(tt.after).In(tt.loc).Format(time.RFC3339)
+			if got != tt.want {
+				t.Errorf("Next(%s) = %s, want %s", tt.after.Format(time.RFC3339), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDailyRejectsInvalidClock(t *testing.T) {
+	for _, clock := range []string{"", "9am", "24:00", "09:60", "9:00:00"} {
+		if _, err := Daily(clock, time.UTC); err == nil {
+			t.Errorf("Daily(%q) succeeded, want an error", clock)
+		}
+	}
+}
+
+func TestFirstDue(t *testing.T) {
+	london := loadLocation(t, "Europe/London")
+	newYork := loadLocation(t, "America/New_York")
+
+	tests := []struct {
+		name  string
+		clock string
+		loc   *time.Location
+		now   time.Time
+		// lastScheduled is the due time the job last ran for, zero if never
+		lastScheduled time.Time
+		want          string
+	}{
+		{
+			name:  "never ran",
+			clock: "09:00",
+			loc:   london,
+			now:   time.Date(2024, 3, 31, 12, 0, 0, 0, london),
+			want:  "2024-04-01T09:00:00+01:00",
+		},
+		{
+			name:          "nothing missed",
+			clock:         "09:00",
+			loc:           london,
+			now:           time.Date(2024, 3, 31, 8, 0, 0, 0, london),
+			lastScheduled: time.Date(2024, 3, 30, 9, 0, 0, 0, london),
+			want:          "2024-03-31T09:00:00+01:00",
+		},
+		{
+			name:          "several missed across spring forward run once",
+			clock:         "09:00",
+			loc:           london,
+			now:           time.Date(2024, 3, 31, 12, 0, 0, 0, london),
+			lastScheduled: time.Date(2024, 3, 28, 9, 0, 0, 0, london),
+			want:          "2024-03-31T09:00:00+01:00",
+		},
+		{
+			name:          "missed skipped time",
+			clock:         "02:30",
+			loc:           newYork,
+			now:           time.Date(2024, 3, 10, 12, 0, 0, 0, newYork),
+			lastScheduled: time.Date(2024, 3, 9, 2, 30, 0, 0, newYork),
+			want:          "2024-03-10T03:30:00-04:00",
+		},
+		{
+			name:          "missed repeated time",
+			clock:         "01:30",
+			loc:           newYork,
+			now:           time.Date(2024, 11, 3, 12, 0, 0, 0, newYork),
+			lastScheduled: time.Date(2024, 11, 1, 1, 30, 0, 0, newYork),
+			want:          "2024-11-03T01:30:00-04:00",
+		},
+		{
+			name:          "ran at the repeated time",
+			clock:         "01:30",
+			loc:           newYork,
+			now:           time.Date(2024, 11, 3, 6, 0, 0, 0, time.UTC),
+			lastScheduled: time.Date(2024, 11, 3, 1, 30, 0, 0, newYork),
+			want:          "2024-11-04T01:30:00-05:00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store, err := jobstore.InitJobStore("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.lastScheduled.IsZero() {
+				if err := store.Save(ctx, dto.JobState{Name: "reminder", LastScheduled: tt.lastScheduled}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			schedule, err := Daily(tt.clock, tt.loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s := NewScheduler(store, logger.InitLogger(zap.NewNop()))
+			s.now = func() time.Time { return tt.now }
+			s.Add("reminder", "Daily reminder", schedule, func(ctx context.Context) error { return nil })
+
+			got := s.firstDue(ctx, s.jobs[0]).In(tt.loc).Format(time.RFC3339)
+			if got != tt.want {
+				t.Errorf("first due = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
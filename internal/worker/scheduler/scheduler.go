@@ -0,0 +1,231 @@
+package scheduler
+
+import (
+	"ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/storage"
+	"ai_agent/platform/logger"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type job struct {
+	name        string
+	description string
+	schedule    Schedule
+	run         func(ctx context.Context) error
+
+	// next and running are guarded by Scheduler.mu
+	next    time.Time
+	running bool
+}
+
+// Scheduler runs jobs on their schedules. The latest due time each job ran
+// for is saved, so a restart neither repeats a run nor loses one: a run
+// missed while the assistant was down happens as soon as it is back, once
+// however many were missed.
+type Scheduler struct {
+	store  storage.Job
+	logger logger.Logger
+	now    func() time.Time
+
+	mu   sync.Mutex
+	jobs []*job
+	// wg tracks the job loops and the runs in progress
+	wg sync.WaitGroup
+}
+
+func NewScheduler(store storage.Job, logger logger.Logger) *Scheduler {
+	return &Scheduler{
+		store:  store,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Add registers a job. Jobs must be added before Start.
+func (s *Scheduler) Add(name, description string, schedule Schedule, run func(ctx context.Context) error) {
+	s.jobs = append(s.jobs, &job{
+		name:        name,
+		description: description,
+		schedule:    schedule,
+		run:         run,
+	})
+}
+
+// Start runs the jobs on their schedules until ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, j := range s.jobs {
+		s.logger.Info(ctx, "Scheduling job", zap.String("job", j.name), zap.String("schedule", j.schedule.String()))
+		s.wg.Add(1)
+		go s.loop(ctx, j)
+	}
+}
+
+// Wait blocks until the scheduler has stopped and the runs in progress have
+// finished, or ctx is done.
+func (s *Scheduler) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Jobs implements worker.Scheduler.
+func (s *Scheduler) Jobs(ctx context.Context) []dto.Job {
+	jobs := make([]dto.Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		s.mu.Lock()
+		info := dto.Job{
+			Name:        j.name,
+			Description: j.description,
+			Schedule:    j.schedule.String(),
+			NextRun:     j.next,
+			Running:     j.running,
+		}
+		s.mu.Unlock()
+
+		state, _, err := s.store.Get(ctx, j.name)
+		if err != nil {
+			s.logger.Warn(ctx, "Failed to read job state", zap.String("job", j.name), zap.Error(err))
+		}
+		info.LastRun = state.LastRun
+		jobs = append(jobs, info)
+	}
+	return jobs
+}
+
+// Run implements worker.Scheduler. It leaves the schedule unchanged.
+func (s *Scheduler) Run(ctx context.Context, name string) (dto.JobRun, error) {
+	for _, j := range s.jobs {
+		if j.name == name {
+			s.logger.Info(ctx, "Running job on request", zap.String("job", name))
+			return s.execute(ctx, j, time.Time{})
+		}
+	}
+	return dto.JobRun{}, fmt.Errorf("%w: job %q", errors.ErrNotFound, name)
+}
+
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	defer s.wg.Done()
+
+	due := s.firstDue(ctx, j)
+	for {
+		s.mu.Lock()
+		j.next = due
+		s.mu.Unlock()
+
+		timer := time.NewTimer(due.Sub(s.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.runScheduled(ctx, j, due)
+
+		// A run that overran later due times skips them
+		after := due
+		if now := s.now(); now.After(after) {
+			after = now
+		}
+		due = j.schedule.Next(after)
+	}
+}
+
+// firstDue is when a job is next due after a start: the latest due time
+// missed since its last run, or else the next one.
+func (s *Scheduler) firstDue(ctx context.Context, j *job) time.Time {
+	now := s.now()
+	state, ok, err := s.store.Get(ctx, j.name)
+	if err != nil {
+		s.logger.Warn(ctx, "Failed to read job state", zap.String("job", j.name), zap.Error(err))
+	}
+	if !ok || state.LastScheduled.IsZero() {
+		return j.schedule.Next(now)
+	}
+
+	missed := j.schedule.Next(state.LastScheduled)
+	if missed.After(now) {
+		return missed
+	}
+	for next := j.schedule.Next(missed); !next.After(now); next = j.schedule.Next(next) {
+		missed = next
+	}
+	s.logger.Info(ctx, "Catching up missed job run", zap.String("job", j.name), zap.Time("due", missed))
+	return missed
+}
+
+// runScheduled runs a job for a due time, unless it already ran for it.
+func (s *Scheduler) runScheduled(ctx context.Context, j *job, due time.Time) {
+	state, _, err := s.store.Get(ctx, j.name)
+	if err == nil && !state.LastScheduled.Before(due) {
+		s.logger.Info(ctx, "Job already ran", zap.String("job", j.name), zap.Time("due", due))
+		return
+	}
+
+	if _, err := s.execute(ctx, j, due); err != nil {
+		s.logger.Error(ctx, "Job failed", zap.String("job", j.name), zap.Error(err))
+	}
+}
+
+// execute runs a job and records the run. scheduled is the due time it runs
+// for, zero when triggered by hand. The run is recorded before it starts, so
+// a crash part way through does not lead to it running again.
+func (s *Scheduler) execute(ctx context.Context, j *job, scheduled time.Time) (dto.JobRun, error) {
+	s.mu.Lock()
+	if j.running {
+		s.mu.Unlock()
+		return dto.JobRun{}, fmt.Errorf("%w: job %q is already running", errors.ErrConflict, j.name)
+	}
+	j.running = true
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		j.running = false
+		s.mu.Unlock()
+		s.wg.Done()
+	}()
+
+	run := dto.JobRun{Scheduled: scheduled, StartedAt: s.now()}
+	s.record(ctx, j, run)
+
+	// Shutdown waits for the run to finish rather than cutting it short
+	err := j.run(context.WithoutCancel(ctx))
+
+	run.FinishedAt = s.now()
+	if err != nil {
+		run.Error = err.Error()
+	}
+	s.record(ctx, j, run)
+	return run, err
+}
+
+func (s *Scheduler) record(ctx context.Context, j *job, run dto.JobRun) {
+	state, _, err := s.store.Get(ctx, j.name)
+	if err != nil {
+		s.logger.Warn(ctx, "Failed to read job state", zap.String("job", j.name), zap.Error(err))
+	}
+	state.Name = j.name
+	if !run.Scheduled.IsZero() {
+		state.LastScheduled = run.Scheduled
+	}
+	state.LastRun = &run
+	if err := s.store.Save(ctx, state); err != nil {
+		s.logger.Error(ctx, "Failed to save job state", zap.String("job", j.name), zap.Error(err))
+	}
+}
@@ -0,0 +1,22 @@
+package worker
+
+import (
+	"ai_agent/internal/constants/model/dto"
+	"context"
+)
+
+// Scheduler runs background jobs and lets an administrator inspect and
+// trigger them.
+type Scheduler interface {
+	Jobs(ctx context.Context) []dto.Job
+	// Run runs a job now, outside its schedule.
+	Run(ctx context.Context, name string) (dto.JobRun, error)
+}
+
+// Outbox reports the delivery of queued email.
+type Outbox interface {
+	// Messages returns the messages in the outbox, oldest first, only those
+	// with status if it is set. Their emails come without bodies and
+	// attachment content.
+	Messages(ctx context.Context, status string) ([]dto.OutboxMessage, error)
+}
@@ -0,0 +1,573 @@
+package calendar
+
+import (
+	apperrors "ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/ical"
+	"ai_agent/internal/recurrence"
+	"ai_agent/platform"
+	"ai_agent/platform/httpclient"
+	"ai_agent/platform/logger"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// maxPreconditionRetries bounds how often a change is reapplied after
+	// someone else changed the same event.
+	maxPreconditionRetries = 3
+
+	caldavTimeFormat = "20060102T150405Z"
+
+	propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<D:propfind xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop>
+    <D:resourcetype/>
+    <D:displayname/>
+    <D:current-user-principal/>
+    <C:calendar-home-set/>
+    <C:supported-calendar-component-set/>
+  </D:prop>
+</D:propfind>`
+
+	calendarQueryBody = `<?xml version="1.0" encoding="utf-8"?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop>
+    <D:getetag/>
+    <C:calendar-data/>
+  </D:prop>
+  <C:filter>
+    <C:comp-filter name="VCALENDAR">
+      <C:comp-filter name="VEVENT">%s</C:comp-filter>
+    </C:comp-filter>
+  </C:filter>
+</C:calendar-query>`
+)
+
+// errPreconditionFailed is returned when an event changed on the server after
+// it was read.
+var errPreconditionFailed = fmt.Errorf("%w: event was changed on the server", apperrors.ErrConflict)
+
+// caldavCalendar keeps events on a CalDAV server such as Nextcloud or
+// Radicale. Each event is a calendar object resource holding the series and
+// any changed occurrences, written back only if its ETag is unchanged.
+type caldavCalendar struct {
+	config dto.Config
+	client *httpclient.Client
+	logger logger.Logger
+
+	// loc is the zone events are written and recurrences expanded in
+	loc  *time.Location
+	base *url.URL
+
+	mu         sync.Mutex
+	collection *url.URL
+}
+
+// calendarObject is a resource in the calendar collection.
+type calendarObject struct {
+	href   *url.URL
+	etag   string
+	events []dto.Event
+}
+
+// multistatus is a WebDAV 207 response.
+type multistatus struct {
+	Responses []struct {
+		Href      string `xml:"DAV: href"`
+		Propstats []struct {
+			Prop   davProp `xml:"DAV: prop"`
+			Status string  `xml:"DAV: status"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+type davProp struct {
+	ResourceType struct {
+		Calendar *struct{} `xml:"urn:ietf:params:xml:ns:caldav calendar"`
+	} `xml:"DAV: resourcetype"`
+	DisplayName          string         `xml:"DAV: displayname"`
+	CurrentUserPrincipal davHref        `xml:"DAV: current-user-principal"`
+	CalendarHomeSet      davHref        `xml:"urn:ietf:params:xml:ns:caldav calendar-home-set"`
+	Components           []davComponent `xml:"urn:ietf:params:xml:ns:caldav supported-calendar-component-set>comp"`
+	ETag                 string         `xml:"DAV: getetag"`
+	CalendarData         string         `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+}
+
+type davHref struct {
+	Href string `xml:"DAV: href"`
+}
+
+type davComponent struct {
+	Name string `xml:"name,attr"`
+}
+
+// davResource is a response of a multistatus with its properties.
+type davResource struct {
+	href *url.URL
+	prop davProp
+}
+
+func InitCalDAVCalendar(config dto.Config, logger logger.Logger) platform.Calendar {
+	loc, err := time.LoadLocation(config.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	base, err := url.Parse(config.CalDAVURL)
+	if err != nil {
+		logger.Error(context.Background(), "Invalid CalDAV URL", zap.Error(err))
+		base = &url.URL{}
+	}
+	return &caldavCalendar{
+		config: config,
+		client: httpclient.New(httpclient.Options{
+			Name:             "caldav",
+			Timeout:          30 * time.Second,
+			MaxRetries:       config.HTTPMaxRetries,
+			BreakerThreshold: config.BreakerThreshold,
+			BreakerCooldown:  time.Duration(config.BreakerCooldownSeconds) * time.Second,
+			RatePerMinute:    config.CalendarRatePerMinute,
+		}, logger),
+		logger: logger,
+		loc:    loc,
+		base:   base,
+	}
+}
+
+// ListEvents implements platform.Calendar. Recurring events are expanded
+// into their occurrences. The server returns every event in the range, which
+// is then filtered and paged locally.
+func (c *caldavCalendar) ListEvents(ctx context.Context, query dto.EventQuery) (dto.EventPage, error) {
+	c.logger.Info(ctx, "Fetching events from CalDAV", zap.Time("from", query.From), zap.Time("to", query.To))
+
+	events, err := c.between(ctx, query.From, query.To)
+	if err != nil {
+		c.logger.Error(ctx, "Failed to fetch events", zap.Error(err))
+		return dto.EventPage{}, fmt.Errorf("failed to fetch events: %w", err)
+	}
+	result, err := page(events, query)
+	if err != nil {
+		return dto.EventPage{}, err
+	}
+
+	c.logger.Info(ctx, "Successfully fetched events", zap.Int("count", len(result.Events)))
+	return result, nil
+}
+
+// GetEvent implements platform.Calendar.
+func (c *caldavCalendar) GetEvent(ctx context.Context, id string) (dto.Event, error) {
+	c.logger.Info(ctx, "Fetching event from CalDAV", zap.String("event_id", id))
+
+	_, event, err := c.lookup(ctx, id)
+	if err != nil {
+		c.logger.Error(ctx, "Failed to fetch event", zap.String("event_id", id), zap.Error(err))
+		return dto.Event{}, fmt.Errorf("failed to fetch event: %w", err)
+	}
+	return event, nil
+}
+
+// ScheduleMeeting implements platform.Calendar.
+func (c *caldavCalendar) ScheduleMeeting(ctx context.Context, event dto.Event) (dto.Event, error) {
+	c.logger.Info(ctx, "Creating event on CalDAV",
+		zap.String("title", event.Title),
+		zap.Time("start", event.StartTime),
+		zap.Strings("attendees", event.Attendees),
+		zap.Strings("recurrence", event.Recurrence))
+
+	collection, err := c.calendarURL(ctx)
+	if err != nil {
+		return dto.Event{}, fmt.Errorf("failed to create event: %w", err)
+	}
+
+	event.ID = newEventID()
+	href := collection.JoinPath(event.ID + ".ics")
+	if err := c.put(ctx, href, "", []dto.Event{event}); err != nil {
+		c.logger.Error(ctx, "Failed to create event", zap.Error(err))
+		return dto.Event{}, fmt.Errorf("failed to create event: %w", err)
+	}
+
+	c.logger.Info(ctx, "Successfully created event", zap.String("event_id", event.ID))
+	return event, nil
+}
+
+// UpdateEvent implements platform.Calendar. Changing one occurrence of a
+// recurring event stores it as an override in the series' resource.
+func (c *caldavCalendar) UpdateEvent(ctx context.Context, event dto.Event) (dto.Event, error) {
+	c.logger.Info(ctx, "Updating event on CalDAV", zap.String("event_id", event.ID))
+
+	err := c.modify(ctx, event.ID, func(events []dto.Event, current dto.Event) []dto.Event {
+		if i := slices.IndexFunc(events, func(e dto.Event) bool { return e.ID == event.ID }); i >= 0 {
+			events[i] = event
+			return events
+		}
+
+		// A new override: the series skips the occurrence it replaces
+		event.RecurringEventID = current.RecurringEventID
+		event.OriginalStartTime = current.OriginalStartTime
+		for i := range events {
+			if events[i].ID == current.RecurringEventID {
+				events[i].Recurrence = recurrence.Exclude(events[i].Recurrence, current.OriginalStartTime)
+			}
+		}
+		return append(events, event)
+	})
+	if err != nil {
+		c.logger.Error(ctx, "Failed to update event", zap.String("event_id", event.ID), zap.Error(err))
+		return dto.Event{}, fmt.Errorf("failed to update event: %w", err)
+	}
+
+	c.logger.Info(ctx, "Successfully updated event", zap.String("event_id", event.ID))
+	return event, nil
+}
+
+// Reschedule implements platform.Calendar.
+func (c *caldavCalendar) Reschedule(ctx context.Context, id string, startTime time.Time, duration time.Duration) (dto.Event, error) {
+	event, err := c.GetEvent(ctx, id)
+	if err != nil {
+		return dto.Event{}, err
+	}
+	return c.UpdateEvent(ctx, moved(event, c.loc, startTime, duration))
+}
+
+// Cancel implements platform.Calendar. Cancelling a series deletes its
+// resource; cancelling one occurrence excludes it from the series.
+func (c *caldavCalendar) Cancel(ctx context.Context, id string) error {
+	c.logger.Info(ctx, "Cancelling event on CalDAV", zap.String("event_id", id))
+
+	err := c.modify(ctx, id, func(events []dto.Event, current dto.Event) []dto.Event {
+		if current.RecurringEventID == "" {
+			return nil
+		}
+		// An override's occurrence is already excluded from the series
+		events = slices.DeleteFunc(events, func(e dto.Event) bool { return e.ID == id })
+		for i := range events {
+			if events[i].ID == current.RecurringEventID {
+				events[i].Recurrence = recurrence.Exclude(events[i].Recurrence, current.OriginalStartTime)
+			}
+		}
+		return events
+	})
+	if err != nil {
+		c.logger.Error(ctx, "Failed to cancel event", zap.String("event_id", id), zap.Error(err))
+		return fmt.Errorf("failed to cancel event: %w", err)
+	}
+
+	c.logger.Info(ctx, "Successfully cancelled event", zap.String("event_id", id))
+	return nil
+}
+
+// FreeBusy implements platform.Calendar from the events in the calendar. The
+// user is busy during every event and other calendars during the events they
+// attend, since CalDAV servers do not share other users' calendars.
+func (c *caldavCalendar) FreeBusy(ctx context.Context, calendars []string, from, to time.Time) (map[string][]dto.TimeRange, error) {
+	c.logger.Info(ctx, "Querying free/busy from CalDAV", zap.Strings("calendars", calendars))
+
+	events, err := c.between(ctx, from, to)
+	if err != nil {
+		c.logger.Error(ctx, "Failed to query free/busy", zap.Error(err))
+		return nil, fmt.Errorf("failed to query free/busy: %w", err)
+	}
+
+	busy := make(map[string][]dto.TimeRange, len(calendars))
+	for _, id := range calendars {
+		busy[id] = []dto.TimeRange{}
+		for _, event := range events {
+			if id == c.config.UserEmail || contains(event.Attendees, id) {
+				busy[id] = append(busy[id], dto.TimeRange{Start: event.StartTime, End: event.EndTime})
+			}
+		}
+	}
+	return busy, nil
+}
+
+// between returns the occurrences of events overlapping [from, to), sorted by
+// start time.
+func (c *caldavCalendar) between(ctx context.Context, from, to time.Time) ([]dto.Event, error) {
+	objects, err := c.query(ctx, fmt.Sprintf(`<C:time-range start="%s" end="%s"/>`,
+		from.UTC().Format(caldavTimeFormat), to.UTC().Format(caldavTimeFormat)))
+	if err != nil {
+		return nil, err
+	}
+
+	var stored []dto.Event
+	for _, object := range objects {
+		stored = append(stored, object.events...)
+	}
+	events := occurrences(ctx, c.logger, slices.Values(stored), c.loc, from, to)
+	sort.Slice(events, func(i, j int) bool { return events[i].StartTime.Before(events[j].StartTime) })
+	return events, nil
+}
+
+// lookup returns the resource holding event id with the event itself, which
+// may be a stored event or an occurrence of a recurring one.
+func (c *caldavCalendar) lookup(ctx context.Context, id string) (calendarObject, dto.Event, error) {
+	object, err := c.find(ctx, id)
+	if err == nil {
+		for _, event := range object.events {
+			if event.ID == id {
+				return object, event, nil
+			}
+		}
+	} else if !errors.Is(err, apperrors.ErrNotFound) {
+		return calendarObject{}, dto.Event{}, err
+	}
+
+	notFound := fmt.Errorf("%w: event %q", apperrors.ErrNotFound, id)
+	masterID, start, ok := splitInstanceID(id)
+	if !ok {
+		return calendarObject{}, dto.Event{}, notFound
+	}
+	object, err = c.find(ctx, masterID)
+	if err != nil {
+		return calendarObject{}, dto.Event{}, err
+	}
+	for _, event := range object.events {
+		if event.ID == id {
+			return object, event, nil
+		}
+	}
+	for _, event := range object.events {
+		if event.ID == masterID {
+			if occurrence, ok := occurrenceAt(event, c.loc, start); ok {
+				return object, occurrence, nil
+			}
+		}
+	}
+	return calendarObject{}, dto.Event{}, notFound
+}
+
+// find returns the resource of the event with the given UID.
+func (c *caldavCalendar) find(ctx context.Context, uid string) (calendarObject, error) {
+	var escaped bytes.Buffer
+	xml.EscapeText(&escaped, []byte(uid))
+	objects, err := c.query(ctx, fmt.Sprintf(
+		`<C:prop-filter name="UID"><C:text-match collation="i;octet">%s</C:text-match></C:prop-filter>`, escaped.String()))
+	if err != nil {
+		return calendarObject{}, err
+	}
+	if len(objects) == 0 {
+		return calendarObject{}, fmt.Errorf("%w: event %q", apperrors.ErrNotFound, uid)
+	}
+	return objects[0], nil
+}
+
+// modify applies change to the events of the resource holding event id and
+// writes the result back, deleting the resource when no events are left. The
+// change is reapplied to fresh data if the resource changed meanwhile.
+func (c *caldavCalendar) modify(ctx context.Context, id string, change func(events []dto.Event, current dto.Event) []dto.Event) error {
+	for attempt := 0; ; attempt++ {
+		object, current, err := c.lookup(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		err = c.put(ctx, object.href, object.etag, change(object.events, current))
+		if !errors.Is(err, errPreconditionFailed) || attempt == maxPreconditionRetries {
+			return err
+		}
+		c.logger.Warn(ctx, "Event changed on the server, retrying", zap.String("event_id", id), zap.Int("attempt", attempt+1))
+	}
+}
+
+// put writes events to the resource at href, creating it when etag is empty
+// and otherwise only if it still has that ETag. With no events the resource
+// is deleted.
+func (c *caldavCalendar) put(ctx context.Context, href *url.URL, etag string, events []dto.Event) error {
+	header := http.Header{}
+	if etag == "" {
+		header.Set("If-None-Match", "*")
+	} else {
+		header.Set("If-Match", etag)
+	}
+
+	if len(events) == 0 {
+		_, err := c.send(ctx, "DELETE", href, header, nil)
+		return err
+	}
+
+	var body bytes.Buffer
+	if err := ical.Encode(&body, events, ical.Options{Location: c.loc}); err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	header.Set("Content-Type", "text/calendar; charset=utf-8")
+	_, err := c.send(ctx, "PUT", href, header, body.Bytes())
+	return err
+}
+
+// query runs a calendar-query REPORT for VEVENTs matching filter.
+func (c *caldavCalendar) query(ctx context.Context, filter string) ([]calendarObject, error) {
+	collection, err := c.calendarURL(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := c.multistatus(ctx, "REPORT", collection, "1", fmt.Sprintf(calendarQueryBody, filter))
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []calendarObject
+	for _, resource := range resources {
+		if resource.prop.CalendarData == "" {
+			continue
+		}
+		events, err := ical.Decode(strings.NewReader(resource.prop.CalendarData), c.loc)
+		if err != nil {
+			c.logger.Warn(ctx, "Skipping unreadable calendar object", zap.String("href", resource.href.String()), zap.Error(err))
+			continue
+		}
+		objects = append(objects, calendarObject{href: resource.href, etag: resource.prop.ETag, events: events})
+	}
+	return objects, nil
+}
+
+// calendarURL returns the calendar collection, discovering it on first use:
+// CalDAVURL itself if it is a calendar, and otherwise the calendar named by
+// CalendarID, or the first that holds events, in the user's calendar home.
+func (c *caldavCalendar) calendarURL(ctx context.Context) (*url.URL, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.collection != nil {
+		return c.collection, nil
+	}
+
+	resources, err := c.multistatus(ctx, "PROPFIND", c.base, "0", propfindBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover calendar: %w", err)
+	}
+	root := resources[0]
+	if root.prop.ResourceType.Calendar != nil {
+		c.collection = root.href
+		return c.collection, nil
+	}
+
+	home := root.prop.CalendarHomeSet.Href
+	if home == "" && root.prop.CurrentUserPrincipal.Href != "" {
+		principal := root.href.ResolveReference(&url.URL{Path: root.prop.CurrentUserPrincipal.Href})
+		if resources, err = c.multistatus(ctx, "PROPFIND", principal, "0", propfindBody); err != nil {
+			return nil, fmt.Errorf("failed to discover calendar: %w", err)
+		}
+		home = resources[0].prop.CalendarHomeSet.Href
+	}
+	if home == "" {
+		return nil, fmt.Errorf("%w: no calendar home found at %s", apperrors.ErrNotFound, c.base.Redacted())
+	}
+
+	homeURL := root.href.ResolveReference(&url.URL{Path: home})
+	if resources, err = c.multistatus(ctx, "PROPFIND", homeURL, "1", propfindBody); err != nil {
+		return nil, fmt.Errorf("failed to discover calendar: %w", err)
+	}
+	for _, resource := range resources {
+		if resource.prop.ResourceType.Calendar == nil || !holdsEvents(resource.prop) {
+			continue
+		}
+		name := path.Base(strings.TrimSuffix(resource.href.Path, "/"))
+		if c.config.CalendarID == "" || c.config.CalendarID == name || c.config.CalendarID == resource.prop.DisplayName {
+			c.logger.Info(ctx, "Discovered CalDAV calendar", zap.String("href", resource.href.String()), zap.String("name", resource.prop.DisplayName))
+			c.collection = resource.href
+			return c.collection, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no calendar %q found in %s", apperrors.ErrNotFound, c.config.CalendarID, homeURL.Redacted())
+}
+
+// holdsEvents reports whether a calendar accepts VEVENTs. Calendars that do
+// not say accept every component.
+func holdsEvents(prop davProp) bool {
+	if len(prop.Components) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(prop.Components, func(comp davComponent) bool {
+		return strings.EqualFold(comp.Name, "VEVENT")
+	})
+}
+
+// multistatus sends a PROPFIND or REPORT and returns the resources of its 207
+// response with their found properties.
+func (c *caldavCalendar) multistatus(ctx context.Context, method string, target *url.URL, depth, body string) ([]davResource, error) {
+	header := http.Header{}
+	header.Set("Depth", depth)
+	header.Set("Content-Type", "application/xml; charset=utf-8")
+
+	// Queries have no side effects, so replaying them is safe
+	data, err := c.send(httpclient.WithIdempotent(ctx), method, target, header, []byte(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var status multistatus
+	if err := xml.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	resources := make([]davResource, 0, len(status.Responses))
+	for _, response := range status.Responses {
+		href, err := url.Parse(strings.TrimSpace(response.Href))
+		if err != nil {
+			c.logger.Warn(ctx, "Skipping response with invalid href", zap.String("href", response.Href))
+			continue
+		}
+		resource := davResource{href: target.ResolveReference(href)}
+		for _, propstat := range response.Propstats {
+			// Properties the resource lacks are reported with a 404 status
+			if strings.Contains(propstat.Status, " 200 ") {
+				resource.prop = propstat.Prop
+			}
+		}
+		resources = append(resources, resource)
+	}
+	if len(resources) == 0 && method == "PROPFIND" {
+		return nil, fmt.Errorf("%w: empty response for %s", apperrors.ErrNotFound, target.Redacted())
+	}
+	return resources, nil
+}
+
+// send makes an authenticated request and returns the response body. A failed
+// If-Match or If-None-Match condition is reported as errPreconditionFailed.
+func (c *caldavCalendar) send(ctx context.Context, method string, target *url.URL, header http.Header, body []byte) ([]byte, error) {
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header = header
+	if c.config.CalDAVUsername != "" {
+		req.SetBasicAuth(c.config.CalDAVUsername, c.config.CalDAVPassword)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusPreconditionFailed:
+		return nil, errPreconditionFailed
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		c.logger.Error(ctx, "CalDAV server returned error status", zap.String("method", method), zap.Int("status", resp.StatusCode))
+		return nil, fmt.Errorf("%w: CalDAV server returned status: %d", apperrors.FromStatus(resp.StatusCode), resp.StatusCode)
+	}
+	return data, nil
+}
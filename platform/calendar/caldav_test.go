@@ -0,0 +1,439 @@
+package calendar
+
+import (
+	apperrors "ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/ical"
+	"ai_agent/platform/logger"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	fakeUser     = "alice"
+	fakePassword = "secret"
+	fakeHome     = "/dav/calendars/alice/"
+)
+
+var textMatchRe = regexp.MustCompile(`<C:text-match[^>]*>([^<]*)</C:text-match>`)
+
+// fakeCalDAV is a CalDAV server holding calendar objects in memory. It
+// serves discovery PROPFINDs, calendar-query REPORTs, and PUT and DELETE
+// with If-Match and If-None-Match preconditions.
+type fakeCalDAV struct {
+	t  *testing.T
+	mu sync.Mutex
+
+	objects map[string]fakeObject
+	etags   int
+
+	// propfinds records the path and depth of every PROPFIND
+	propfinds []string
+	// beforeWrite runs before the precondition of a PUT or DELETE is checked,
+	// standing in for another client changing the object first
+	beforeWrite func(f *fakeCalDAV, path string)
+	// preconditionFailures counts writes refused with 412
+	preconditionFailures int
+}
+
+type fakeObject struct {
+	etag string
+	data string
+}
+
+func newFakeCalDAV(t *testing.T) (*fakeCalDAV, *httptest.Server) {
+	f := &fakeCalDAV{t: t, objects: map[string]fakeObject{}}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func (f *fakeCalDAV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if user, password, ok := r.BasicAuth(); !ok || user != fakeUser || password != fakePassword {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+
+	switch r.Method {
+	case "PROPFIND":
+		f.propfinds = append(f.propfinds, r.URL.Path+" depth "+r.Header.Get("Depth"))
+		f.propfind(w, r.URL.Path)
+	case "REPORT":
+		f.report(w, r.URL.Path, string(body))
+	case http.MethodPut, http.MethodDelete:
+		f.write(w, r, string(body))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeCalDAV) propfind(w http.ResponseWriter, path string) {
+	calendar := func(href, name string, components ...string) string {
+		var comps strings.Builder
+		for _, comp := range components {
+			comps.WriteString(`<C:comp name="` + comp + `"/>`)
+		}
+		return davResponse(href, `<D:resourcetype><D:collection/><C:calendar/></D:resourcetype>`+
+			`<D:displayname>`+name+`</D:displayname>`+
+			`<C:supported-calendar-component-set>`+comps.String()+`</C:supported-calendar-component-set>`)
+	}
+
+	switch path {
+	case "/dav/":
+		writeMultistatus(w, davResponse(path, `<D:resourcetype><D:collection/></D:resourcetype>`+
+			`<D:current-user-principal><D:href>/dav/principals/alice/</D:href></D:current-user-principal>`))
+	case "/dav/principals/alice/":
+		writeMultistatus(w, davResponse(path, `<D:resourcetype><D:principal/></D:resourcetype>`+
+			`<C:calendar-home-set><D:href>`+fakeHome+`</D:href></C:calendar-home-set>`))
+	case fakeHome:
+		writeMultistatus(w,
+			davResponse(path, `<D:resourcetype><D:collection/></D:resourcetype>`),
+			calendar(fakeHome+"tasks/", "Tasks", "VTODO"),
+			calendar(fakeHome+"work/", "Work", "VEVENT", "VTODO"),
+			calendar(fakeHome+"personal/", "Personal"))
+	case fakeHome + "work/":
+		writeMultistatus(w, calendar(path, "Work", "VEVENT", "VTODO"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// report answers a calendar-query with the collection's objects, only those
+// with the UID when the query matches one. Time ranges are not applied; the
+// client filters occurrences itself.
+func (f *fakeCalDAV) report(w http.ResponseWriter, collection, body string) {
+	uid := ""
+	if m := textMatchRe.FindStringSubmatch(body); m != nil {
+		uid = m[1]
+	}
+
+	var responses []string
+	for _, path := range slices.Sorted(maps.Keys(f.objects)) {
+		object := f.objects[path]
+		if !strings.HasPrefix(path, collection) {
+			continue
+		}
+		if uid != "" && !strings.Contains(object.data, "\r\nUID:"+uid+"\r\n") {
+			continue
+		}
+		var data bytes.Buffer
+		xml.EscapeText(&data, []byte(object.data))
+		responses = append(responses, davResponse(path,
+			`<D:getetag>`+object.etag+`</D:getetag><C:calendar-data>`+data.String()+`</C:calendar-data>`))
+	}
+	writeMultistatus(w, responses...)
+}
+
+func (f *fakeCalDAV) write(w http.ResponseWriter, r *http.Request, body string) {
+	path := r.URL.Path
+	if f.beforeWrite != nil {
+		f.beforeWrite(f, path)
+	}
+
+	object, exists := f.objects[path]
+	switch {
+	case r.Header.Get("If-None-Match") == "*" && exists,
+		r.Header.Get("If-Match") != "" && (!exists || r.Header.Get("If-Match") != object.etag):
+		f.preconditionFailures++
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		delete(f.objects, path)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	f.store(path, body)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// store saves an object under a new ETag. f.mu must be held.
+func (f *fakeCalDAV) store(path, data string) {
+	f.etags++
+	f.objects[path] = fakeObject{etag: `"` + strconv.Itoa(f.etags) + `"`, data: data}
+}
+
+// seed stores events as one object in the work calendar.
+func (f *fakeCalDAV) seed(name string, loc *time.Location, events ...dto.Event) {
+	var data bytes.Buffer
+	if err := ical.Encode(&data, events, ical.Options{Location: loc}); err != nil {
+		f.t.Fatalf("failed to encode seed events: %v", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.store(fakeHome+"work/"+name, data.String())
+}
+
+// events decodes the object at path.
+func (f *fakeCalDAV) events(path string, loc *time.Location) []dto.Event {
+	f.mu.Lock()
+	object, ok := f.objects[path]
+	f.mu.Unlock()
+	if !ok {
+		f.t.Fatalf("no object at %s", path)
+	}
+	events, err := ical.Decode(strings.NewReader(object.data), loc)
+	if err != nil {
+		f.t.Fatalf("stored object at %s is unreadable: %v", path, err)
+	}
+	return events
+}
+
+func davResponse(href, props string) string {
+	return `<D:response><D:href>` + href + `</D:href><D:propstat><D:prop>` + props +
+		`</D:prop><D:status>HTTP/1.1 200 OK</D:status></D:propstat></D:response>`
+}
+
+func writeMultistatus(w http.ResponseWriter, responses ...string) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?>`+
+		`<D:multistatus xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">`+
+		strings.Join(responses, "")+`</D:multistatus>`)
+}
+
+func newTestCalDAV(t *testing.T, url, calendarID string) (*caldavCalendar, *time.Location) {
+	t.Helper()
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	c := InitCalDAVCalendar(dto.Config{
+		CalDAVURL:      url,
+		CalDAVUsername: fakeUser,
+		CalDAVPassword: fakePassword,
+		CalendarID:     calendarID,
+		TimeZone:       loc.String(),
+		UserEmail:      "alice@example.com",
+	}, logger.InitLogger(zap.NewNop()))
+	return c.(*caldavCalendar), loc
+}
+
+func TestCalDAVDiscovery(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		calendarID    string
+		wantPropfinds []string
+		// wantCollection is where the new event is created; empty expects
+		// discovery to fail with ErrNotFound
+		wantCollection string
+	}{
+		{
+			name:           "first event calendar in the home",
+			path:           "/dav/",
+			wantPropfinds:  []string{"/dav/ depth 0", "/dav/principals/alice/ depth 0", fakeHome + " depth 1"},
+			wantCollection: fakeHome + "work/",
+		},
+		{
+			name:           "calendar by display name",
+			path:           "/dav/",
+			calendarID:     "Personal",
+			wantPropfinds:  []string{"/dav/ depth 0", "/dav/principals/alice/ depth 0", fakeHome + " depth 1"},
+			wantCollection: fakeHome + "personal/",
+		},
+		{
+			name:           "calendar by path name",
+			path:           "/dav/principals/alice/",
+			calendarID:     "work",
+			wantPropfinds:  []string{"/dav/principals/alice/ depth 0", fakeHome + " depth 1"},
+			wantCollection: fakeHome + "work/",
+		},
+		{
+			name:           "calendar URL",
+			path:           fakeHome + "work/",
+			wantPropfinds:  []string{fakeHome + "work/ depth 0"},
+			wantCollection: fakeHome + "work/",
+		},
+		{
+			name:          "calendar without events",
+			path:          "/dav/",
+			calendarID:    "Tasks",
+			wantPropfinds: []string{"/dav/ depth 0", "/dav/principals/alice/ depth 0", fakeHome + " depth 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, server := newFakeCalDAV(t)
+			c, loc := newTestCalDAV(t, server.URL+tt.path, tt.calendarID)
+
+			start := time.Date(2026, 10, 27, 14, 0, 0, 0, loc)
+			created, err := c.ScheduleMeeting(context.Background(), dto.Event{
+				Title:     "Planning",
+				Attendees: []string{"bob@example.com"},
+				StartTime: start,
+				EndTime:   start.Add(time.Hour),
+			})
+			if !slices.Equal(fake.propfinds, tt.wantPropfinds) {
+				t.Errorf("PROPFINDs = %q, want %q", fake.propfinds, tt.wantPropfinds)
+			}
+			if tt.wantCollection == "" {
+				if !errors.Is(err, apperrors.ErrNotFound) {
+					t.Fatalf("ScheduleMeeting error = %v, want ErrNotFound", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ScheduleMeeting failed: %v", err)
+			}
+
+			events := fake.events(tt.wantCollection+created.ID+".ics", loc)
+			if len(events) != 1 || events[0].Title != "Planning" || !events[0].StartTime.Equal(start) {
+				t.Errorf("stored events = %+v, want the new meeting", events)
+			}
+
+			// The collection is discovered once
+			fake.propfinds = nil
+			page, err := c.ListEvents(context.Background(), dto.EventQuery{From: start.Add(-time.Hour), To: start.Add(2 * time.Hour)})
+			if err != nil {
+				t.Fatalf("ListEvents failed: %v", err)
+			}
+			if len(page.Events) != 1 || page.Events[0].ID != created.ID {
+				t.Errorf("ListEvents = %+v, want the new meeting", page.Events)
+			}
+			if len(fake.propfinds) != 0 {
+				t.Errorf("collection discovered again: %q", fake.propfinds)
+			}
+		})
+	}
+}
+
+func TestCalDAVModifyRetriesPreconditionFailed(t *testing.T) {
+	tests := []struct {
+		name string
+		// conflicts is how many writes another client gets in first
+		conflicts int
+		wantErr   error
+	}{
+		{name: "no conflict"},
+		{name: "one conflict", conflicts: 1},
+		{name: "conflicts up to the limit", conflicts: maxPreconditionRetries},
+		{name: "conflicts past the limit", conflicts: maxPreconditionRetries + 1, wantErr: apperrors.ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, server := newFakeCalDAV(t)
+			c, loc := newTestCalDAV(t, server.URL+"/dav/", "")
+
+			start := time.Date(2026, 10, 28, 15, 0, 0, 0, loc)
+			original := dto.Event{ID: "review", Title: "Review", StartTime: start, EndTime: start.Add(time.Hour)}
+			fake.seed("review.ics", loc, original)
+
+			edits := 0
+			fake.beforeWrite = func(f *fakeCalDAV, path string) {
+				if edits == tt.conflicts {
+					return
+				}
+				edits++
+				// Another client saves the object, changing its ETag
+				f.store(path, f.objects[path].data)
+			}
+
+			updated := original
+			updated.Title = "Quarterly review"
+			_, err := c.UpdateEvent(context.Background(), updated)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("UpdateEvent error = %v, want %v", err, tt.wantErr)
+				}
+				if fake.preconditionFailures != maxPreconditionRetries+1 {
+					t.Errorf("writes refused = %d, want %d", fake.preconditionFailures, maxPreconditionRetries+1)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateEvent failed: %v", err)
+			}
+
+			if fake.preconditionFailures != tt.conflicts {
+				t.Errorf("writes refused = %d, want %d", fake.preconditionFailures, tt.conflicts)
+			}
+			events := fake.events(fakeHome+"work/review.ics", loc)
+			if len(events) != 1 || events[0].Title != "Quarterly review" {
+				t.Errorf("stored events = %+v, want the updated review", events)
+			}
+		})
+	}
+}
+
+func TestCalDAVCancel(t *testing.T) {
+	fake, server := newFakeCalDAV(t)
+	c, loc := newTestCalDAV(t, server.URL+"/dav/", "")
+	ctx := context.Background()
+
+	start := time.Date(2026, 10, 6, 9, 30, 0, 0, loc)
+	fake.seed("standup.ics", loc, dto.Event{
+		ID:         "standup",
+		Title:      "Standup",
+		StartTime:  start,
+		EndTime:    start.Add(15 * time.Minute),
+		Recurrence: []string{"RRULE:FREQ=WEEKLY;COUNT=6"},
+	})
+	fake.seed("lunch.ics", loc, dto.Event{
+		ID:        "lunch",
+		Title:     "Lunch",
+		StartTime: time.Date(2026, 10, 7, 12, 0, 0, 0, loc),
+		EndTime:   time.Date(2026, 10, 7, 13, 0, 0, 0, loc),
+	})
+
+	// The occurrence of October 20 at 9:30 in New York
+	cancelled := time.Date(2026, 10, 20, 9, 30, 0, 0, loc)
+	if err := c.Cancel(ctx, "standup_20261020T133000Z"); err != nil {
+		t.Fatalf("Cancel of an occurrence failed: %v", err)
+	}
+
+	series := fake.events(fakeHome+"work/standup.ics", loc)
+	if len(series) != 1 {
+		t.Fatalf("stored events = %+v, want the series alone", series)
+	}
+	if want := []string{"RRULE:FREQ=WEEKLY;COUNT=6", "EXDATE:20261020T133000Z"}; !slices.Equal(series[0].Recurrence, want) {
+		t.Errorf("recurrence = %q, want %q", series[0].Recurrence, want)
+	}
+
+	page, err := c.ListEvents(ctx, dto.EventQuery{From: start, To: start.AddDate(0, 2, 0), Query: "standup"})
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	if len(page.Events) != 5 {
+		t.Errorf("ListEvents returned %d occurrences, want 5", len(page.Events))
+	}
+	for _, event := range page.Events {
+		if event.StartTime.Equal(cancelled) {
+			t.Errorf("cancelled occurrence %s is still listed", event.ID)
+		}
+	}
+
+	// Cancelling an event that does not repeat deletes its object
+	if err := c.Cancel(ctx, "lunch"); err != nil {
+		t.Fatalf("Cancel of an event failed: %v", err)
+	}
+	if _, ok := fake.objects[fakeHome+"work/lunch.ics"]; ok {
+		t.Error("cancelled event's object was not deleted")
+	}
+	if _, err := c.GetEvent(ctx, "lunch"); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("GetEvent of the cancelled event error = %v, want ErrNotFound", err)
+	}
+}
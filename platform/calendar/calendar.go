@@ -1,186 +1,496 @@
 package calendar
 
 import (
+	"ai_agent/internal/constants/errors"
 	"ai_agent/internal/constants/model/dto"
 	"ai_agent/platform"
+	"ai_agent/platform/googleauth"
+	"ai_agent/platform/httpclient"
 	"ai_agent/platform/logger"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"go.uber.org/zap"
 )
 
+const (
+	calendarsURL = "https://www.googleapis.com/calendar/v3/calendars"
+	freeBusyURL  = "https://www.googleapis.com/calendar/v3/freeBusy"
+
+	// maxPageSize is the most events Google returns in one page
+	maxPageSize = 2500
+)
+
 type calendar struct {
 	config dto.Config
-	client *http.Client
+	client *httpclient.Client
+	// auth supplies OAuth2 tokens; without it requests carry only the API key
+	auth   *googleauth.Auth
 	logger logger.Logger
+	// loc is the zone all-day events are read in
+	loc *time.Location
+	// eventsURL is the events collection of config.CalendarID
+	eventsURL string
 }
 
 type GoogleCalendarEvent struct {
-	Summary     string `json:"summary"`
-	Description string `json:"description,omitempty"`
-	Start       struct {
-		DateTime string `json:"dateTime"`
-		TimeZone string `json:"timeZone"`
-	} `json:"start"`
-	End struct {
-		DateTime string `json:"dateTime"`
-		TimeZone string `json:"timeZone"`
-	} `json:"end"`
-	Attendees []struct {
-		Email string `json:"email"`
-	} `json:"attendees,omitempty"`
+	ID          string                   `json:"id,omitempty"`
+	Summary     string                   `json:"summary"`
+	Description string                   `json:"description,omitempty"`
+	Location    string                   `json:"location,omitempty"`
+	Status      string                   `json:"status,omitempty"`
+	Start       GoogleEventTime          `json:"start"`
+	End         GoogleEventTime          `json:"end"`
+	Attendees   []GoogleCalendarAttendee `json:"attendees,omitempty"`
+	// Organizer, HangoutLink and ConferenceData are set by Google and
+	// ignored when writing
+	Organizer      *GoogleCalendarAttendee `json:"organizer,omitempty"`
+	HangoutLink    string                  `json:"hangoutLink,omitempty"`
+	ConferenceData *GoogleConferenceData   `json:"conferenceData,omitempty"`
+	// Recurrence is set on the series, and RecurringEventID and
+	// OriginalStartTime on its instances
+	Recurrence        []string         `json:"recurrence,omitempty"`
+	RecurringEventID  string           `json:"recurringEventId,omitempty"`
+	OriginalStartTime *GoogleEventTime `json:"originalStartTime,omitempty"`
+}
+
+// GoogleEventTime is a time, or a date for all-day events.
+type GoogleEventTime struct {
+	Date     string `json:"date,omitempty"`
+	DateTime string `json:"dateTime,omitempty"`
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+type GoogleCalendarAttendee struct {
+	Email          string `json:"email"`
+	ResponseStatus string `json:"responseStatus,omitempty"`
+}
+
+type GoogleConferenceData struct {
+	EntryPoints []struct {
+		EntryPointType string `json:"entryPointType"`
+		URI            string `json:"uri"`
+	} `json:"entryPoints"`
 }
 
 type GoogleCalendarResponse struct {
-	Items []GoogleCalendarEvent `json:"items"`
+	Items         []GoogleCalendarEvent `json:"items"`
+	NextPageToken string                `json:"nextPageToken,omitempty"`
+}
+
+type GoogleFreeBusyRequest struct {
+	TimeMin  string               `json:"timeMin"`
+	TimeMax  string               `json:"timeMax"`
+	TimeZone string               `json:"timeZone,omitempty"`
+	Items    []GoogleFreeBusyItem `json:"items"`
+}
+
+type GoogleFreeBusyItem struct {
+	ID string `json:"id"`
+}
+
+type GoogleFreeBusyResponse struct {
+	Calendars map[string]struct {
+		Busy []struct {
+			Start string `json:"start"`
+			End   string `json:"end"`
+		} `json:"busy"`
+		Errors []struct {
+			Reason string `json:"reason"`
+		} `json:"errors"`
+	} `json:"calendars"`
 }
 
-func InitCalendar(config dto.Config, logger logger.Logger) platform.Calendar {
+func InitCalendar(config dto.Config, auth *googleauth.Auth, logger logger.Logger) platform.Calendar {
+	loc, err := time.LoadLocation(config.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	calendarID := config.CalendarID
+	if calendarID == "" {
+		calendarID = "primary"
+	}
 	return &calendar{
 		config: config,
-		client: &http.Client{Timeout: 30 * time.Second},
-		logger: logger,
+		auth:   auth,
+		client: httpclient.New(httpclient.Options{
+			Name:             "google_calendar",
+			Timeout:          30 * time.Second,
+			MaxRetries:       config.HTTPMaxRetries,
+			BreakerThreshold: config.BreakerThreshold,
+			BreakerCooldown:  time.Duration(config.BreakerCooldownSeconds) * time.Second,
+			RatePerMinute:    config.CalendarRatePerMinute,
+		}, logger),
+		logger:    logger,
+		loc:       loc,
+		eventsURL: calendarsURL + "/" + url.PathEscape(calendarID) + "/events",
 	}
 }
 
-func (c *calendar) GetUpcomingEvents(ctx context.Context) ([]dto.Event, error) {
-	c.logger.Info(ctx,"Fetching upcoming events from Google Calendar")
+// ListEvents implements platform.Calendar. Without a limit every page Google
+// returns is fetched; with one, each call returns one Google page and the
+// cursor is Google's page token. Google's search also matches descriptions
+// and locations, and attendees are filtered after fetching, so a page may
+// hold fewer than limit events.
+func (c *calendar) ListEvents(ctx context.Context, query dto.EventQuery) (dto.EventPage, error) {
+	c.logger.Info(ctx, "Fetching events from Google Calendar", zap.Time("from", query.From), zap.Time("to", query.To))
 
-	// Build the API URL
-	baseURL := "https://www.googleapis.com/calendar/v3/calendars/primary/events"
 	params := url.Values{}
-	params.Add("key", c.config.GoogleCalendarAPIKey)
-	params.Add("timeMin", time.Now().Format(time.RFC3339))
-	params.Add("timeMax", time.Now().AddDate(0, 0, 7).Format(time.RFC3339)) // Next 7 days
-	params.Add("singleEvents", "true")
-	params.Add("orderBy", "startTime")
+	params.Set("timeMin", query.From.Format(time.RFC3339))
+	params.Set("timeMax", query.To.Format(time.RFC3339))
+	params.Set("singleEvents", "true")
+	params.Set("orderBy", "startTime")
+	if query.Query != "" {
+		params.Set("q", query.Query)
+	} else if query.Attendee != "" {
+		// Narrows the results to events mentioning the attendee
+		params.Set("q", query.Attendee)
+	}
+	if query.Limit > 0 {
+		params.Set("maxResults", strconv.Itoa(min(query.Limit, maxPageSize)))
+	}
+
+	var result dto.EventPage
+	pageToken := query.Cursor
+	for {
+		if pageToken != "" {
+			params.Set("pageToken", pageToken)
+		}
+		var calendarResp GoogleCalendarResponse
+		if err := c.do(ctx, "GET", "", params, nil, &calendarResp); err != nil {
+			c.logger.Error(ctx, "Failed to fetch events", zap.Error(err))
+			return dto.EventPage{}, fmt.Errorf("failed to fetch events: %w", err)
+		}
 
-	reqURL := fmt.Sprintf("%s?%s", baseURL, params.Encode())
+		// Convert to our Event format
+		for _, item := range calendarResp.Items {
+			event, err := c.toEvent(item)
+			if err != nil {
+				c.logger.Warn(ctx, "Skipping event with invalid times", zap.String("event_id", item.ID), zap.Error(err))
+				continue
+			}
+			if matches(event, dto.EventQuery{Attendee: query.Attendee}) {
+				result.Events = append(result.Events, event)
+			}
+		}
 
-	// Make the request
-	req, err := http.NewRequest("GET", reqURL, nil)
-	if err != nil {
-		c.logger.Error(ctx, "Failed to create request", zap.Error(err))
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		pageToken = calendarResp.NextPageToken
+		if pageToken == "" {
+			break
+		}
+		if query.Limit > 0 {
+			result.NextCursor = pageToken
+			break
+		}
 	}
 
-	resp, err := c.client.Do(req)
+	c.logger.Info(ctx, "Successfully fetched events", zap.Int("count", len(result.Events)))
+	return result, nil
+}
+
+// GetEvent implements platform.Calendar.
+func (c *calendar) GetEvent(ctx context.Context, id string) (dto.Event, error) {
+	c.logger.Info(ctx, "Fetching event from Google Calendar", zap.String("event_id", id))
+
+	var item GoogleCalendarEvent
+	if err := c.do(ctx, "GET", id, url.Values{}, nil, &item); err != nil {
+		c.logger.Error(ctx, "Failed to fetch event", zap.String("event_id", id), zap.Error(err))
+		return dto.Event{}, fmt.Errorf("failed to fetch event: %w", err)
+	}
+	return c.toEvent(item)
+}
+
+// ScheduleMeeting implements platform.Calendar.
+func (c *calendar) ScheduleMeeting(ctx context.Context, event dto.Event) (dto.Event, error) {
+	c.logger.Info(ctx, "Scheduling meeting",
+		zap.String("title", event.Title),
+		zap.Time("startTime", event.StartTime),
+		zap.Strings("attendees", event.Attendees),
+		zap.Strings("recurrence", event.Recurrence))
+
+	var created GoogleCalendarEvent
+	if err := c.do(ctx, "POST", "", notifyParams(), c.fromEvent(event), &created); err != nil {
+		c.logger.Error(ctx, "Failed to schedule meeting", zap.Error(err))
+		return dto.Event{}, fmt.Errorf("failed to schedule meeting: %w", err)
+	}
+
+	c.logger.Info(ctx, "Successfully scheduled meeting", zap.String("title", event.Title), zap.String("event_id", created.ID))
+	return c.toEvent(created)
+}
+
+// UpdateEvent implements platform.Calendar.
+func (c *calendar) UpdateEvent(ctx context.Context, event dto.Event) (dto.Event, error) {
+	c.logger.Info(ctx, "Updating event", zap.String("event_id", event.ID), zap.String("title", event.Title))
+
+	var updated GoogleCalendarEvent
+	if err := c.do(ctx, "PATCH", event.ID, notifyParams(), c.fromEvent(event), &updated); err != nil {
+		c.logger.Error(ctx, "Failed to update event", zap.String("event_id", event.ID), zap.Error(err))
+		return dto.Event{}, fmt.Errorf("failed to update event: %w", err)
+	}
+
+	c.logger.Info(ctx, "Successfully updated event", zap.String("event_id", event.ID))
+	return c.toEvent(updated)
+}
+
+// Reschedule implements platform.Calendar.
+func (c *calendar) Reschedule(ctx context.Context, id string, startTime time.Time, duration time.Duration) (dto.Event, error) {
+	c.logger.Info(ctx, "Rescheduling event", zap.String("event_id", id), zap.Time("startTime", startTime))
+
+	event, err := c.GetEvent(ctx, id)
 	if err != nil {
-		c.logger.Error(ctx, "Failed to fetch events", zap.Error(err))
-		return nil, fmt.Errorf("failed to fetch events: %w", err)
+		return dto.Event{}, err
 	}
-	defer resp.Body.Close()
+	return c.UpdateEvent(ctx, moved(event, c.loc, startTime, duration))
+}
 
-	if resp.StatusCode != http.StatusOK {
-		c.logger.Error(ctx, "Calendar API returned error status", zap.Int("status", resp.StatusCode))
-		return nil, fmt.Errorf("calendar API returned status: %d", resp.StatusCode)
+// Cancel implements platform.Calendar.
+func (c *calendar) Cancel(ctx context.Context, id string) error {
+	c.logger.Info(ctx, "Cancelling event", zap.String("event_id", id))
+
+	if err := c.do(ctx, "DELETE", id, notifyParams(), nil, nil); err != nil {
+		c.logger.Error(ctx, "Failed to cancel event", zap.String("event_id", id), zap.Error(err))
+		return fmt.Errorf("failed to cancel event: %w", err)
+	}
+
+	c.logger.Info(ctx, "Successfully cancelled event", zap.String("event_id", id))
+	return nil
+}
+
+// FreeBusy implements platform.Calendar with a freeBusy query. Calendars
+// Google cannot read are logged and left out of the result.
+func (c *calendar) FreeBusy(ctx context.Context, calendars []string, from, to time.Time) (map[string][]dto.TimeRange, error) {
+	c.logger.Info(ctx, "Querying free/busy from Google Calendar", zap.Strings("calendars", calendars))
+
+	query := GoogleFreeBusyRequest{
+		TimeMin:  from.Format(time.RFC3339),
+		TimeMax:  to.Format(time.RFC3339),
+		TimeZone: c.config.TimeZone,
+	}
+	for _, id := range calendars {
+		query.Items = append(query.Items, GoogleFreeBusyItem{ID: id})
+	}
+
+	var freeBusy GoogleFreeBusyResponse
+	if err := c.call(httpclient.WithIdempotent(ctx), "POST", freeBusyURL, url.Values{}, query, &freeBusy); err != nil {
+		c.logger.Error(ctx, "Failed to query free/busy", zap.Error(err))
+		return nil, fmt.Errorf("failed to query free/busy: %w", err)
+	}
+
+	busy := make(map[string][]dto.TimeRange, len(freeBusy.Calendars))
+	for id, cal := range freeBusy.Calendars {
+		if len(cal.Errors) > 0 {
+			c.logger.Warn(ctx, "Free/busy unavailable for calendar", zap.String("calendar", id), zap.String("reason", cal.Errors[0].Reason))
+			continue
+		}
+		for _, b := range cal.Busy {
+			start, _ := time.Parse(time.RFC3339, b.Start)
+			end, _ := time.Parse(time.RFC3339, b.End)
+			busy[id] = append(busy[id], dto.TimeRange{Start: start, End: end})
+		}
 	}
+	return busy, nil
+}
 
-	var calendarResp GoogleCalendarResponse
-	if err := json.NewDecoder(resp.Body).Decode(&calendarResp); err != nil {
-		c.logger.Error(ctx, "Failed to decode response", zap.Error(err))
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// do calls the events API at c.eventsURL/id.
+func (c *calendar) do(ctx context.Context, method, id string, params url.Values, body, out any) error {
+	reqURL := c.eventsURL
+	if id != "" {
+		reqURL += "/" + url.PathEscape(id)
 	}
+	return c.call(ctx, method, reqURL, params, body, out)
+}
 
-	// Convert to our Event format
-	events := make([]dto.Event, 0, len(calendarResp.Items))
-	for _, item := range calendarResp.Items {
-		startTime, _ := time.Parse(time.RFC3339, item.Start.DateTime)
-		endTime, _ := time.Parse(time.RFC3339, item.End.DateTime)
+// call sends a request to the Calendar API, encoding body and decoding the
+// response into out when they are not nil. A rejected OAuth2 token is
+// refreshed and the request sent once more.
+func (c *calendar) call(ctx context.Context, method, reqURL string, params url.Values, body, out any) error {
+	if c.config.GoogleCalendarAPIKey != "" {
+		params.Set("key", c.config.GoogleCalendarAPIKey)
+	}
+	reqURL = fmt.Sprintf("%s?%s", reqURL, params.Encode())
 
-		attendees := make([]string, 0, len(item.Attendees))
-		for _, attendee := range item.Attendees {
-			attendees = append(attendees, attendee.Email)
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to marshal event data: %w", err)
 		}
+	}
 
-		events = append(events, dto.Event{
-			Title:     item.Summary,
-			Attendees: attendees,
-			StartTime: startTime,
-			EndTime:   endTime,
-		})
+	// Updates carry the full new state, so replaying them is safe
+	if method == "PATCH" {
+		ctx = httpclient.WithIdempotent(ctx)
 	}
 
-	c.logger.Info(ctx, "Successfully fetched events", zap.Int("count", len(events)))
-	return events, nil
+	for attempt := 0; ; attempt++ {
+		var reqBody io.Reader
+		if data != nil {
+			reqBody = bytes.NewReader(data)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if c.auth != nil {
+			token, err := c.auth.Token(ctx)
+			if err != nil {
+				return err
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && c.auth != nil && attempt == 0 {
+			resp.Body.Close()
+			c.logger.Warn(ctx, "Calendar API rejected access token, refreshing")
+			c.auth.Invalidate()
+			continue
+		}
+		return c.decode(ctx, resp, out)
+	}
 }
 
-// ScheduleMeeting implements platform.Calendar.
-func (c *calendar) ScheduleMeeting(ctx context.Context, attendees []string, startTime time.Time, duration time.Duration, title string) error {
-	c.logger.Info(ctx, "Scheduling meeting", zap.String("title", title), zap.Time("startTime", startTime), zap.Strings("attendees", attendees))
-
-	endTime := startTime.Add(duration)
-
-	// Prepare the event data
-	event := GoogleCalendarEvent{
-		Summary: title,
-		Start: struct {
-			DateTime string `json:"dateTime"`
-			TimeZone string `json:"timeZone"`
-		}{
-			DateTime: startTime.Format(time.RFC3339),
-			TimeZone: c.config.TimeZone,
-		},
-		End: struct {
-			DateTime string `json:"dateTime"`
-			TimeZone string `json:"timeZone"`
-		}{
-			DateTime: endTime.Format(time.RFC3339),
-			TimeZone: c.config.TimeZone,
-		},
-	}
-
-	// Add attendees
-	for _, attendee := range attendees {
-		event.Attendees = append(event.Attendees, struct {
-			Email string `json:"email"`
-		}{Email: attendee})
-	}
-
-	// Convert to JSON
-	eventData, err := json.Marshal(event)
-	if err != nil {
-		c.logger.Error(ctx, "Failed to marshal event data", zap.Error(err))
-		return fmt.Errorf("failed to marshal event data: %w", err)
+// decode checks the status of a Calendar API response and reads its body
+// into out when that is not nil.
+func (c *calendar) decode(ctx context.Context, resp *http.Response, out any) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		c.logger.Error(ctx, "Calendar API returned error status", zap.Int("status", resp.StatusCode))
+		return fmt.Errorf("%w: calendar API returned status: %d", errors.FromStatus(resp.StatusCode), resp.StatusCode)
 	}
 
-	// Build the API URL
-	baseURL := "https://www.googleapis.com/calendar/v3/calendars/primary/events"
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// notifyParams keeps Google from emailing attendees about changes; the
+// assistant sends its own notices, the same for every calendar backend.
+func notifyParams() url.Values {
 	params := url.Values{}
-	fmt.Println("===================================",c.config.GoogleCalendarAPIKey)
-	params.Add("key", c.config.GoogleCalendarAPIKey)
-	params.Add("sendUpdates", "all") // Send invitations to attendees
+	params.Add("sendUpdates", "none")
+	return params
+}
 
-	reqURL := fmt.Sprintf("%s?%s", baseURL, params.Encode())
+func (c *calendar) fromEvent(event dto.Event) GoogleCalendarEvent {
+	item := GoogleCalendarEvent{
+		Summary:     event.Title,
+		Description: event.Description,
+		Location:    event.Location,
+		Status:      event.Status,
+		Start:       c.fromTime(event.StartTime, event.AllDay),
+		End:         c.fromTime(event.EndTime, event.AllDay),
+		// Google expands the rule in Start.TimeZone, keeping the wall-clock time across DST
+		Recurrence: event.Recurrence,
+	}
+	for _, attendee := range event.Attendees {
+		// Known responses are sent back so they are not reset
+		item.Attendees = append(item.Attendees, GoogleCalendarAttendee{Email: attendee, ResponseStatus: googleResponses[event.Responses[attendee]]})
+	}
+	return item
+}
 
-	// Create the request
-	req, err := http.NewRequest("POST", reqURL, bytes.NewBuffer(eventData))
-	if err != nil {
-		c.logger.Error(ctx, "Failed to create request", zap.Error(err))
-		return fmt.Errorf("failed to create request: %w", err)
+// fromTime writes an all-day event's times as dates; the end date is the day
+// after the event's last.
+func (c *calendar) fromTime(t time.Time, allDay bool) GoogleEventTime {
+	if allDay {
+		return GoogleEventTime{Date: t.In(c.loc).Format(time.DateOnly), TimeZone: c.config.TimeZone}
 	}
+	return GoogleEventTime{DateTime: t.Format(time.RFC3339), TimeZone: c.config.TimeZone}
+}
 
-	req.Header.Set("Content-Type", "application/json")
+// googleResponses maps attendee responses to Google's responseStatus values.
+var googleResponses = map[string]string{
+	dto.ResponseNeedsAction: "needsAction",
+	dto.ResponseAccepted:    "accepted",
+	dto.ResponseDeclined:    "declined",
+	dto.ResponseTentative:   "tentative",
+}
 
-	// Make the request
-	resp, err := c.client.Do(req)
+func (c *calendar) toEvent(item GoogleCalendarEvent) (dto.Event, error) {
+	startTime, allDay, err := c.toTime(item.Start)
 	if err != nil {
-		c.logger.Error(ctx, "Failed to schedule meeting", zap.Error(err))
-		return fmt.Errorf("failed to schedule meeting: %w", err)
+		return dto.Event{}, err
+	}
+	endTime, _, err := c.toTime(item.End)
+	if err != nil {
+		return dto.Event{}, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		c.logger.Error(ctx, "Calendar API returned error status", zap.Int("status", resp.StatusCode))
-		return fmt.Errorf("calendar API returned status: %d", resp.StatusCode)
+	event := dto.Event{
+		ID:               item.ID,
+		Title:            item.Summary,
+		Description:      item.Description,
+		Location:         item.Location,
+		Attendees:        make([]string, 0, len(item.Attendees)),
+		StartTime:        startTime,
+		EndTime:          endTime,
+		AllDay:           allDay,
+		Status:           item.Status,
+		ConferenceURL:    item.HangoutLink,
+		Recurrence:       item.Recurrence,
+		RecurringEventID: item.RecurringEventID,
 	}
+	for _, attendee := range item.Attendees {
+		event.Attendees = append(event.Attendees, attendee.Email)
+		for response, status := range googleResponses {
+			if status == attendee.ResponseStatus {
+				if event.Responses == nil {
+					event.Responses = map[string]string{}
+				}
+				event.Responses[attendee.Email] = response
+			}
+		}
+	}
+	if item.Organizer != nil {
+		event.Organizer = item.Organizer.Email
+	}
+	if item.ConferenceData != nil {
+		for _, entryPoint := range item.ConferenceData.EntryPoints {
+			if entryPoint.EntryPointType == "video" {
+				event.ConferenceURL = entryPoint.URI
+				break
+			}
+		}
+	}
+	if item.OriginalStartTime != nil {
+		if event.OriginalStartTime, _, err = c.toTime(*item.OriginalStartTime); err != nil {
+			return dto.Event{}, err
+		}
+	}
+	return event, nil
+}
 
-	c.logger.Info(ctx, "Successfully scheduled meeting", zap.String("title", title))
-	return nil
+// toTime reads a time, or the midnight starting a date in the calendar's
+// zone, reporting whether it was a date.
+func (c *calendar) toTime(t GoogleEventTime) (time.Time, bool, error) {
+	if t.Date != "" {
+		date, err := time.ParseInLocation(time.DateOnly, t.Date, c.loc)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("%w: invalid date %q", errors.ErrUnexpected, t.Date)
+		}
+		return date, true, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, t.DateTime)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%w: invalid time %q", errors.ErrUnexpected, t.DateTime)
+	}
+	return parsed, false, nil
 }
@@ -0,0 +1,41 @@
+package calendar
+
+import (
+	"ai_agent/internal/constants/model/dto"
+	"time"
+)
+
+// moved returns event starting at startTime, keeping its length when duration
+// is zero. An all-day event stays all-day when moved to midnight for whole
+// days, counted on the calendar so DST does not shift its end.
+func moved(event dto.Event, loc *time.Location, startTime time.Time, duration time.Duration) dto.Event {
+	if event.AllDay {
+		days := 0
+		if duration <= 0 {
+			days = calendarDays(event.StartTime.In(loc), event.EndTime.In(loc))
+		} else if duration%(24*time.Hour) == 0 {
+			days = int(duration / (24 * time.Hour))
+		}
+		local := startTime.In(loc)
+		if days > 0 && local.Equal(time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)) {
+			event.StartTime = local
+			event.EndTime = local.AddDate(0, 0, days)
+			return event
+		}
+		event.AllDay = false
+	}
+
+	if duration <= 0 {
+		duration = event.EndTime.Sub(event.StartTime)
+	}
+	event.StartTime = startTime
+	event.EndTime = startTime.Add(duration)
+	return event
+}
+
+// calendarDays returns the number of dates from start's to end's.
+func calendarDays(start, end time.Time) int {
+	from := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	return int(to.Sub(from) / (24 * time.Hour))
+}
@@ -0,0 +1,364 @@
+package calendar
+
+import (
+	apperrors "ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/recurrence"
+	"ai_agent/internal/storage"
+	"ai_agent/platform"
+	"ai_agent/platform/logger"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// localCalendar keeps events in a JSON file, so meetings scheduled without a
+// calendar provider survive restarts. With no file configured they are only
+// kept in memory.
+type localCalendar struct {
+	config dto.Config
+	logger logger.Logger
+
+	// loc is the zone recurring events are expanded in
+	loc  *time.Location
+	path string
+
+	mu     sync.Mutex
+	events map[string]dto.Event
+}
+
+// localFile is the file format, kept apart from dto.Event so the file stays
+// readable as the DTO changes.
+type localFile struct {
+	Events []localEvent `json:"events"`
+}
+
+type localEvent struct {
+	ID                string            `json:"id"`
+	Title             string            `json:"title"`
+	Description       string            `json:"description,omitempty"`
+	Location          string            `json:"location,omitempty"`
+	Attendees         []string          `json:"attendees,omitempty"`
+	Responses         map[string]string `json:"responses,omitempty"`
+	Organizer         string            `json:"organizer,omitempty"`
+	StartTime         time.Time         `json:"start_time"`
+	EndTime           time.Time         `json:"end_time"`
+	AllDay            bool              `json:"all_day,omitempty"`
+	Status            string            `json:"status,omitempty"`
+	ConferenceURL     string            `json:"conference_url,omitempty"`
+	Recurrence        []string          `json:"recurrence,omitempty"`
+	RecurringEventID  string            `json:"recurring_event_id,omitempty"`
+	OriginalStartTime time.Time         `json:"original_start_time,omitzero"`
+}
+
+func InitLocalCalendar(config dto.Config, logger logger.Logger) platform.Calendar {
+	loc, err := time.LoadLocation(config.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	c := &localCalendar{
+		config: config,
+		logger: logger,
+		loc:    loc,
+		path:   config.LocalCalendarFile,
+		events: map[string]dto.Event{},
+	}
+
+	if err := c.load(); err != nil {
+		// Keep the unreadable file for inspection rather than overwrite it
+		aside := fmt.Sprintf("%s.corrupt-%d", c.path, time.Now().Unix())
+		logger.Error(context.Background(), "Failed to load local calendar, starting empty",
+			zap.String("path", c.path), zap.String("moved_to", aside), zap.Error(err))
+		if err := os.Rename(c.path, aside); err != nil {
+			logger.Error(context.Background(), "Failed to move local calendar aside", zap.Error(err))
+		}
+		c.events = map[string]dto.Event{}
+	}
+	logger.Info(context.Background(), "Loaded local calendar", zap.String("path", c.path), zap.Int("events", len(c.events)))
+
+	return c
+}
+
+// ListEvents implements platform.Calendar. Recurring events are expanded
+// into their occurrences.
+func (c *localCalendar) ListEvents(ctx context.Context, query dto.EventQuery) (dto.EventPage, error) {
+	c.logger.Info(ctx, "Listing events (local mode)", zap.Time("from", query.From), zap.Time("to", query.To))
+
+	c.mu.Lock()
+	events := c.occurrences(ctx, query.From, query.To)
+	c.mu.Unlock()
+
+	result, err := page(events, query)
+	if err != nil {
+		return dto.EventPage{}, err
+	}
+
+	c.logger.Info(ctx, "Returning events", zap.Int("count", len(result.Events)))
+	return result, nil
+}
+
+// GetEvent implements platform.Calendar.
+func (c *localCalendar) GetEvent(ctx context.Context, id string) (dto.Event, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.event(id)
+}
+
+// ScheduleMeeting implements platform.Calendar.
+func (c *localCalendar) ScheduleMeeting(ctx context.Context, event dto.Event) (dto.Event, error) {
+	c.logger.Info(ctx, "Scheduling meeting (local mode)",
+		zap.String("title", event.Title),
+		zap.Time("startTime", event.StartTime),
+		zap.Strings("attendees", event.Attendees),
+		zap.Strings("recurrence", event.Recurrence))
+
+	event.ID = newEventID()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previous := maps.Clone(c.events)
+	c.events[event.ID] = event
+	if err := c.save(previous); err != nil {
+		c.logger.Error(ctx, "Failed to save meeting", zap.Error(err))
+		return dto.Event{}, err
+	}
+
+	c.logger.Info(ctx, "Meeting scheduled",
+		zap.String("event_id", event.ID),
+		zap.String("start", event.StartTime.Format("2006-01-02 15:04:05")),
+		zap.String("duration", event.EndTime.Sub(event.StartTime).String()))
+	return event, nil
+}
+
+// UpdateEvent implements platform.Calendar.
+func (c *localCalendar) UpdateEvent(ctx context.Context, event dto.Event) (dto.Event, error) {
+	c.logger.Info(ctx, "Updating event (local mode)", zap.String("event_id", event.ID))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := c.event(event.ID); err != nil {
+		return dto.Event{}, err
+	}
+	return c.store(event)
+}
+
+// Reschedule implements platform.Calendar.
+func (c *localCalendar) Reschedule(ctx context.Context, id string, startTime time.Time, duration time.Duration) (dto.Event, error) {
+	c.logger.Info(ctx, "Rescheduling event (local mode)", zap.String("event_id", id), zap.Time("startTime", startTime))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	event, err := c.event(id)
+	if err != nil {
+		return dto.Event{}, err
+	}
+	return c.store(moved(event, c.loc, startTime, duration))
+}
+
+// Cancel implements platform.Calendar. Cancelling a series removes all of its
+// occurrences; cancelling one occurrence excludes it from the series.
+func (c *localCalendar) Cancel(ctx context.Context, id string) error {
+	c.logger.Info(ctx, "Cancelling event (local mode)", zap.String("event_id", id))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	event, err := c.event(id)
+	if err != nil {
+		return err
+	}
+
+	previous := maps.Clone(c.events)
+	if _, stored := c.events[id]; !stored {
+		master := c.events[event.RecurringEventID]
+		master.Recurrence = recurrence.Exclude(master.Recurrence, event.StartTime)
+		c.events[master.ID] = master
+	} else {
+		delete(c.events, id)
+		for otherID, other := range c.events {
+			if other.RecurringEventID == id {
+				delete(c.events, otherID)
+			}
+		}
+	}
+	return c.save(previous)
+}
+
+// FreeBusy implements platform.Calendar from the stored events. The user is
+// busy during every event and other calendars during the events they attend.
+func (c *localCalendar) FreeBusy(ctx context.Context, calendars []string, from, to time.Time) (map[string][]dto.TimeRange, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	events := c.occurrences(ctx, from, to)
+	busy := make(map[string][]dto.TimeRange, len(calendars))
+	for _, id := range calendars {
+		busy[id] = []dto.TimeRange{}
+		for _, event := range events {
+			if id == c.config.UserEmail || contains(event.Attendees, id) {
+				busy[id] = append(busy[id], dto.TimeRange{Start: event.StartTime, End: event.EndTime})
+			}
+		}
+	}
+	return busy, nil
+}
+
+// occurrences returns the events overlapping [from, to), with recurring
+// events expanded. c.mu must be held.
+func (c *localCalendar) occurrences(ctx context.Context, from, to time.Time) []dto.Event {
+	return occurrences(ctx, c.logger, maps.Values(c.events), c.loc, from, to)
+}
+
+// event returns a stored event or an occurrence of a recurring one. c.mu must
+// be held.
+func (c *localCalendar) event(id string) (dto.Event, error) {
+	if event, ok := c.events[id]; ok {
+		return event, nil
+	}
+
+	if masterID, start, ok := splitInstanceID(id); ok {
+		if event, ok := occurrenceAt(c.events[masterID], c.loc, start); ok {
+			return event, nil
+		}
+	}
+	return dto.Event{}, fmt.Errorf("%w: event %q", apperrors.ErrNotFound, id)
+}
+
+// store saves event. Changing one occurrence of a recurring event detaches
+// it: the series skips that occurrence and the changed copy is stored under
+// the occurrence's ID. c.mu must be held.
+func (c *localCalendar) store(event dto.Event) (dto.Event, error) {
+	previous := maps.Clone(c.events)
+	if _, stored := c.events[event.ID]; !stored && event.RecurringEventID != "" {
+		original, _ := c.event(event.ID)
+		master := c.events[event.RecurringEventID]
+		master.Recurrence = recurrence.Exclude(master.Recurrence, original.StartTime)
+		c.events[master.ID] = master
+	}
+	c.events[event.ID] = event
+	if err := c.save(previous); err != nil {
+		return dto.Event{}, err
+	}
+	return event, nil
+}
+
+// load reads the events from the file, which need not exist yet.
+func (c *localCalendar) load() error {
+	if c.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var file localFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	for _, e := range file.Events {
+		c.events[e.ID] = dto.Event{
+			ID:                e.ID,
+			Title:             e.Title,
+			Description:       e.Description,
+			Location:          e.Location,
+			Attendees:         e.Attendees,
+			Responses:         e.Responses,
+			Organizer:         e.Organizer,
+			StartTime:         e.StartTime,
+			EndTime:           e.EndTime,
+			AllDay:            e.AllDay,
+			Status:            e.Status,
+			ConferenceURL:     e.ConferenceURL,
+			Recurrence:        e.Recurrence,
+			RecurringEventID:  e.RecurringEventID,
+			OriginalStartTime: e.OriginalStartTime,
+		}
+	}
+	return nil
+}
+
+// save writes the events to the file, replacing it atomically so a crash
+// cannot leave it half written. If that fails the events are reset to
+// previous, keeping memory and disk in step. c.mu must be held.
+func (c *localCalendar) save(previous map[string]dto.Event) error {
+	if c.path == "" {
+		return nil
+	}
+	if err := c.write(); err != nil {
+		c.events = previous
+		return fmt.Errorf("failed to save local calendar: %w", err)
+	}
+	return nil
+}
+
+func (c *localCalendar) write() error {
+	file := localFile{Events: make([]localEvent, 0, len(c.events))}
+	for _, event := range c.events {
+		file.Events = append(file.Events, localEvent{
+			ID:                event.ID,
+			Title:             event.Title,
+			Description:       event.Description,
+			Location:          event.Location,
+			Attendees:         event.Attendees,
+			Responses:         event.Responses,
+			Organizer:         event.Organizer,
+			StartTime:         event.StartTime,
+			EndTime:           event.EndTime,
+			AllDay:            event.AllDay,
+			Status:            event.Status,
+			ConferenceURL:     event.ConferenceURL,
+			Recurrence:        event.Recurrence,
+			RecurringEventID:  event.RecurringEventID,
+			OriginalStartTime: event.OriginalStartTime,
+		})
+	}
+	// A stable order keeps the file easy to read and diff
+	sort.Slice(file.Events, func(i, j int) bool {
+		if !file.Events[i].StartTime.Equal(file.Events[j].StartTime) {
+			return file.Events[i].StartTime.Before(file.Events[j].StartTime)
+		}
+		return file.Events[i].ID < file.Events[j].ID
+	})
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return storage.WriteFile(c.path, data)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// newEventID returns a random identifier for a locally stored event.
+func newEventID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
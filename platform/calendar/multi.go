@@ -0,0 +1,263 @@
+package calendar
+
+import (
+	"ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/platform"
+	"ai_agent/platform/logger"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// calendarSeparator joins a calendar's name to the IDs of its events. Events
+// on the default calendar keep the IDs their provider gives them, so adding
+// calendars does not change the IDs of existing events.
+const calendarSeparator = ":"
+
+// multiCalendar combines several calendars of one provider. Listings and
+// free/busy merge the readable calendars, and changes go to the calendar an
+// event is on, if it is writable.
+type multiCalendar struct {
+	config    dto.Config
+	logger    logger.Logger
+	calendars []dto.Calendar
+	backends  map[string]platform.Calendar
+	// def is the default calendar
+	def dto.Calendar
+}
+
+// InitMultiCalendar combines config.Calendars, opening each with open.
+// Exactly one of them must be the default.
+func InitMultiCalendar(config dto.Config, open func(dto.Calendar) platform.Calendar, logger logger.Logger) platform.Calendar {
+	c := &multiCalendar{
+		config:    config,
+		logger:    logger,
+		calendars: config.Calendars,
+		backends:  make(map[string]platform.Calendar, len(config.Calendars)),
+	}
+	for _, cal := range config.Calendars {
+		c.backends[cal.Name] = open(cal)
+		if cal.Default {
+			c.def = cal
+		}
+	}
+	return c
+}
+
+// ListEvents implements platform.Calendar. Each calendar returns every event
+// in the range, which are merged and then paged.
+func (c *multiCalendar) ListEvents(ctx context.Context, query dto.EventQuery) (dto.EventPage, error) {
+	var targets []dto.Calendar
+	if query.Calendar != "" {
+		cal, err := c.named(query.Calendar)
+		if err != nil {
+			return dto.EventPage{}, err
+		}
+		if err := readable(cal); err != nil {
+			return dto.EventPage{}, err
+		}
+		targets = []dto.Calendar{cal}
+	} else {
+		for _, cal := range c.calendars {
+			if cal.Read {
+				targets = append(targets, cal)
+			}
+		}
+	}
+
+	inner := query
+	inner.Limit, inner.Cursor, inner.Calendar = 0, "", ""
+
+	pages := make([]dto.EventPage, len(targets))
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, cal := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pages[i], errs[i] = c.backends[cal.Name].ListEvents(ctx, inner)
+		}()
+	}
+	wg.Wait()
+
+	var events []dto.Event
+	for i, cal := range targets {
+		if errs[i] != nil {
+			c.logger.Error(ctx, "Failed to list calendar", zap.String("calendar", cal.Name), zap.Error(errs[i]))
+			return dto.EventPage{}, fmt.Errorf("calendar %q: %w", cal.Name, errs[i])
+		}
+		for _, event := range pages[i].Events {
+			events = append(events, c.tag(cal, event))
+		}
+	}
+
+	// The calendars already applied the filters
+	return page(events, dto.EventQuery{Limit: query.Limit, Cursor: query.Cursor})
+}
+
+// GetEvent implements platform.Calendar.
+func (c *multiCalendar) GetEvent(ctx context.Context, id string) (dto.Event, error) {
+	cal, innerID := c.route(id)
+	if err := readable(cal); err != nil {
+		return dto.Event{}, err
+	}
+	event, err := c.backends[cal.Name].GetEvent(ctx, innerID)
+	if err != nil {
+		return dto.Event{}, err
+	}
+	return c.tag(cal, event), nil
+}
+
+// ScheduleMeeting implements platform.Calendar. The event goes on the
+// calendar it names, or else the default.
+func (c *multiCalendar) ScheduleMeeting(ctx context.Context, event dto.Event) (dto.Event, error) {
+	cal := c.def
+	if event.Calendar != "" {
+		var err error
+		if cal, err = c.named(event.Calendar); err != nil {
+			return dto.Event{}, err
+		}
+	}
+	if err := writable(cal); err != nil {
+		return dto.Event{}, err
+	}
+
+	c.logger.Info(ctx, "Scheduling meeting on calendar", zap.String("calendar", cal.Name))
+	event.Calendar = ""
+	created, err := c.backends[cal.Name].ScheduleMeeting(ctx, event)
+	if err != nil {
+		return dto.Event{}, err
+	}
+	return c.tag(cal, created), nil
+}
+
+// UpdateEvent implements platform.Calendar.
+func (c *multiCalendar) UpdateEvent(ctx context.Context, event dto.Event) (dto.Event, error) {
+	cal, innerID := c.route(event.ID)
+	if err := writable(cal); err != nil {
+		return dto.Event{}, err
+	}
+
+	event.ID = innerID
+	event.Calendar = ""
+	if event.RecurringEventID != "" {
+		_, event.RecurringEventID = c.route(event.RecurringEventID)
+	}
+	updated, err := c.backends[cal.Name].UpdateEvent(ctx, event)
+	if err != nil {
+		return dto.Event{}, err
+	}
+	return c.tag(cal, updated), nil
+}
+
+// Reschedule implements platform.Calendar.
+func (c *multiCalendar) Reschedule(ctx context.Context, id string, startTime time.Time, duration time.Duration) (dto.Event, error) {
+	cal, innerID := c.route(id)
+	if err := writable(cal); err != nil {
+		return dto.Event{}, err
+	}
+
+	event, err := c.backends[cal.Name].Reschedule(ctx, innerID, startTime, duration)
+	if err != nil {
+		return dto.Event{}, err
+	}
+	return c.tag(cal, event), nil
+}
+
+// Cancel implements platform.Calendar.
+func (c *multiCalendar) Cancel(ctx context.Context, id string) error {
+	cal, innerID := c.route(id)
+	if err := writable(cal); err != nil {
+		return err
+	}
+	return c.backends[cal.Name].Cancel(ctx, innerID)
+}
+
+// FreeBusy implements platform.Calendar. The default calendar's provider
+// answers for everyone; events on the other readable calendars add busy time
+// for the user and for the people they invite.
+func (c *multiCalendar) FreeBusy(ctx context.Context, calendars []string, from, to time.Time) (map[string][]dto.TimeRange, error) {
+	busy, err := c.backends[c.def.Name].FreeBusy(ctx, calendars, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cal := range c.calendars {
+		if !cal.Read || cal.Name == c.def.Name {
+			continue
+		}
+		result, err := c.backends[cal.Name].ListEvents(ctx, dto.EventQuery{From: from, To: to})
+		if err != nil {
+			c.logger.Error(ctx, "Failed to get busy time", zap.String("calendar", cal.Name), zap.Error(err))
+			return nil, fmt.Errorf("calendar %q: %w", cal.Name, err)
+		}
+		for _, event := range result.Events {
+			if event.Status == dto.EventCancelled {
+				continue
+			}
+			interval := dto.TimeRange{Start: event.StartTime, End: event.EndTime}
+			for _, email := range calendars {
+				if strings.EqualFold(email, c.config.UserEmail) || slices.ContainsFunc(event.Attendees, func(a string) bool { return strings.EqualFold(a, email) }) {
+					busy[email] = append(busy[email], interval)
+				}
+			}
+		}
+	}
+	return busy, nil
+}
+
+// named returns the calendar called name.
+func (c *multiCalendar) named(name string) (dto.Calendar, error) {
+	for _, cal := range c.calendars {
+		if strings.EqualFold(cal.Name, name) {
+			return cal, nil
+		}
+	}
+	return dto.Calendar{}, fmt.Errorf("%w: no calendar named %q", errors.ErrInvalidInput, name)
+}
+
+// route returns the calendar an event ID belongs to and the ID its provider
+// knows the event by.
+func (c *multiCalendar) route(id string) (dto.Calendar, string) {
+	if name, innerID, ok := strings.Cut(id, calendarSeparator); ok {
+		for _, cal := range c.calendars {
+			if cal.Name == name && cal.Name != c.def.Name {
+				return cal, innerID
+			}
+		}
+	}
+	return c.def, id
+}
+
+// tag marks an event with its calendar, prefixing the IDs of events that are
+// not on the default calendar.
+func (c *multiCalendar) tag(cal dto.Calendar, event dto.Event) dto.Event {
+	event.Calendar = cal.Name
+	if cal.Name != c.def.Name {
+		event.ID = cal.Name + calendarSeparator + event.ID
+		if event.RecurringEventID != "" {
+			event.RecurringEventID = cal.Name + calendarSeparator + event.RecurringEventID
+		}
+	}
+	return event
+}
+
+func readable(cal dto.Calendar) error {
+	if !cal.Read {
+		return fmt.Errorf("%w: calendar %q cannot be read", errors.ErrActionNotAllowed, cal.Name)
+	}
+	return nil
+}
+
+func writable(cal dto.Calendar) error {
+	if !cal.Write {
+		return fmt.Errorf("%w: calendar %q is read-only", errors.ErrActionNotAllowed, cal.Name)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package calendar
+
+import (
+	"ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// matches reports whether event passes the text and attendee filters of query.
+func matches(event dto.Event, query dto.EventQuery) bool {
+	if query.Attendee != "" && !strings.EqualFold(event.Organizer, query.Attendee) &&
+		!slices.ContainsFunc(event.Attendees, func(a string) bool { return strings.EqualFold(a, query.Attendee) }) {
+		return false
+	}
+	if query.Query == "" {
+		return true
+	}
+	text := strings.ToLower(query.Query)
+	if strings.Contains(strings.ToLower(event.Title), text) {
+		return true
+	}
+	return slices.ContainsFunc(event.Attendees, func(a string) bool { return strings.Contains(strings.ToLower(a), text) })
+}
+
+// page filters events by query and returns the page after query.Cursor. The
+// cursor holds the start time and ID of the last event returned, so a page
+// does not shift when earlier events are added or removed.
+func page(events []dto.Event, query dto.EventQuery) (dto.EventPage, error) {
+	var found []dto.Event
+	for _, event := range events {
+		if matches(event, query) {
+			found = append(found, event)
+		}
+	}
+	slices.SortFunc(found, compareEvents)
+
+	if query.Cursor != "" {
+		after, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return dto.EventPage{}, err
+		}
+		i, _ := slices.BinarySearchFunc(found, after, compareEvents)
+		if i < len(found) && compareEvents(found[i], after) == 0 {
+			i++
+		}
+		found = found[i:]
+	}
+
+	if query.Limit <= 0 || len(found) <= query.Limit {
+		return dto.EventPage{Events: found}, nil
+	}
+	found = found[:query.Limit]
+	return dto.EventPage{Events: found, NextCursor: encodeCursor(found[len(found)-1])}, nil
+}
+
+// compareEvents orders events by start time, then ID.
+func compareEvents(a, b dto.Event) int {
+	if c := a.StartTime.Compare(b.StartTime); c != 0 {
+		return c
+	}
+	return strings.Compare(a.ID, b.ID)
+}
+
+func encodeCursor(last dto.Event) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(last.StartTime.UTC().Format(time.RFC3339Nano) + " " + last.ID))
+}
+
+func decodeCursor(cursor string) (dto.Event, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return dto.Event{}, fmt.Errorf("%w: invalid cursor", errors.ErrInvalidInput)
+	}
+	start, id, ok := strings.Cut(string(data), " ")
+	if !ok {
+		return dto.Event{}, fmt.Errorf("%w: invalid cursor", errors.ErrInvalidInput)
+	}
+	startTime, err := time.Parse(time.RFC3339Nano, start)
+	if err != nil {
+		return dto.Event{}, fmt.Errorf("%w: invalid cursor", errors.ErrInvalidInput)
+	}
+	return dto.Event{ID: id, StartTime: startTime}, nil
+}
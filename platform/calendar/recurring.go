@@ -0,0 +1,89 @@
+package calendar
+
+import (
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/recurrence"
+	"ai_agent/platform/logger"
+	"context"
+	"iter"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// instanceTimeFormat is how an occurrence's original start time appears in
+// its ID, as Google formats them.
+const instanceTimeFormat = "20060102T150405Z"
+
+// occurrences returns the events overlapping [from, to), with recurring
+// events expanded on the wall clock of loc. Events whose recurrence cannot be
+// read are logged and left out.
+func occurrences(ctx context.Context, log logger.Logger, events iter.Seq[dto.Event], loc *time.Location, from, to time.Time) []dto.Event {
+	var found []dto.Event
+	for event := range events {
+		if len(event.Recurrence) == 0 {
+			if event.StartTime.Before(to) && event.EndTime.After(from) {
+				found = append(found, event)
+			}
+			continue
+		}
+
+		duration := event.EndTime.Sub(event.StartTime)
+		starts, err := recurrence.Expand(event.Recurrence, event.StartTime.In(loc), duration, from, to)
+		if err != nil {
+			log.Warn(ctx, "Skipping event with invalid recurrence", zap.String("event_id", event.ID), zap.Error(err))
+			continue
+		}
+		for _, start := range starts {
+			found = append(found, instance(event, start))
+		}
+	}
+	return found
+}
+
+// occurrenceAt returns the occurrence of master starting at start, if the
+// series has one.
+func occurrenceAt(master dto.Event, loc *time.Location, start time.Time) (dto.Event, bool) {
+	if len(master.Recurrence) == 0 {
+		return dto.Event{}, false
+	}
+	duration := master.EndTime.Sub(master.StartTime)
+	starts, err := recurrence.Expand(master.Recurrence, master.StartTime.In(loc), duration, start, start.Add(time.Nanosecond))
+	if err != nil {
+		return dto.Event{}, false
+	}
+	for _, occurrence := range starts {
+		if occurrence.Equal(start) {
+			return instance(master, occurrence), true
+		}
+	}
+	return dto.Event{}, false
+}
+
+// instance returns the occurrence of a recurring event starting at start. Its
+// ID is the series ID and its original start time.
+func instance(master dto.Event, start time.Time) dto.Event {
+	event := master
+	event.ID = master.ID + "_" + start.UTC().Format(instanceTimeFormat)
+	event.RecurringEventID = master.ID
+	event.Recurrence = nil
+	event.StartTime = start
+	event.EndTime = start.Add(master.EndTime.Sub(master.StartTime))
+	event.OriginalStartTime = start
+	return event
+}
+
+// splitInstanceID returns the series ID and original start time encoded in the
+// ID of an occurrence.
+func splitInstanceID(id string) (string, time.Time, bool) {
+	i := strings.LastIndex(id, "_")
+	if i < 0 {
+		return "", time.Time{}, false
+	}
+	start, err := time.Parse(instanceTimeFormat, id[i+1:])
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return id[:i], start, true
+}
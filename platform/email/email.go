@@ -1,11 +1,14 @@
 package email
 
 import (
+	"ai_agent/internal/constants/errors"
 	"ai_agent/internal/constants/model/dto"
 	"ai_agent/platform"
+	"ai_agent/platform/httpclient"
 	"ai_agent/platform/logger"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -18,19 +21,24 @@ import (
 
 type email struct {
 	config dto.Config
-	client *http.Client
+	client *httpclient.Client
 	logger logger.Logger
 }
 
 type SendGridEmail struct {
 	Personalizations []Personalization `json:"personalizations"`
 	From             From              `json:"from"`
+	ReplyTo          *To               `json:"reply_to,omitempty"`
 	Subject          string            `json:"subject"`
 	Content          []Content         `json:"content"`
+	Headers          map[string]string `json:"headers,omitempty"`
+	Attachments      []Attachment      `json:"attachments,omitempty"`
 }
 
 type Personalization struct {
-	To []To `json:"to"`
+	To  []To `json:"to"`
+	Cc  []To `json:"cc,omitempty"`
+	Bcc []To `json:"bcc,omitempty"`
 }
 
 type To struct {
@@ -48,42 +56,81 @@ type Content struct {
 	Value string `json:"value"`
 }
 
+type Attachment struct {
+	// Content is base64 encoded
+	Content     string `json:"content"`
+	Type        string `json:"type,omitempty"`
+	Filename    string `json:"filename"`
+	Disposition string `json:"disposition,omitempty"`
+}
+
 func InitEmail(config dto.Config, logger logger.Logger) platform.Email {
 	return &email{
 		config: config,
-		client: &http.Client{Timeout: 30 * time.Second},
+		client: httpclient.New(httpclient.Options{
+			Name:             "sendgrid",
+			Timeout:          30 * time.Second,
+			MaxRetries:       config.HTTPMaxRetries,
+			BreakerThreshold: config.BreakerThreshold,
+			BreakerCooldown:  time.Duration(config.BreakerCooldownSeconds) * time.Second,
+			RatePerMinute:    config.EmailRatePerMinute,
+		}, logger),
 		logger: logger,
 	}
 }
 
 // SendEmail implements platform.Email.
-func (e *email) SendEmail(ctx context.Context, toEmail string, subject string, body string) error {
-	e.logger.Info(ctx, "Sending email", zap.String("to", toEmail), zap.String("subject", subject))
+func (e *email) SendEmail(ctx context.Context, message dto.EmailMessage) error {
+	message, err := prepare(message)
+	if err != nil {
+		return err
+	}
+	e.logger.Info(ctx, "Sending email", zap.Strings("to", message.To), zap.String("subject", message.Subject),
+		zap.Int("cc", len(message.Cc)), zap.Int("bcc", len(message.Bcc)), zap.Int("attachments", len(message.Attachments)))
+
+	// SendGrid needs a To address; a message sent only to Cc or Bcc goes to
+	// the sender as well
+	personalization := Personalization{
+		To:  recipients(message.To),
+		Cc:  recipients(message.Cc),
+		Bcc: recipients(message.Bcc),
+	}
+	if len(personalization.To) == 0 {
+		personalization.To = []To{{Email: e.config.FromEmail, Name: e.config.FromName}}
+		personalization.Cc = withoutAddress(personalization.Cc, e.config.FromEmail)
+		personalization.Bcc = withoutAddress(personalization.Bcc, e.config.FromEmail)
+	}
 
-	// Prepare the email data
+	// Prepare the email data; the plain text must come before the HTML
 	emailData := SendGridEmail{
-		Personalizations: []Personalization{
-			{
-				To: []To{
-					{Email: toEmail},
-				},
-			},
-		},
+		Personalizations: []Personalization{personalization},
 		From: From{
 			Email: e.config.FromEmail,
 			Name:  e.config.FromName,
 		},
-		Subject: subject,
+		Subject: message.Subject,
 		Content: []Content{
-			{
-				Type:  "text/html",
-				Value: body,
-			},
 			{
 				Type:  "text/plain",
-				Value: stripHTML(body),
+				Value: message.Text,
 			},
 		},
+		Headers: message.Headers,
+	}
+	if message.HTML != "" {
+		emailData.Content = append(emailData.Content, Content{Type: "text/html", Value: message.HTML})
+	}
+	if message.ReplyTo != "" {
+		name, address := parseAddress(message.ReplyTo)
+		emailData.ReplyTo = &To{Email: address, Name: name}
+	}
+	for _, attachment := range message.Attachments {
+		emailData.Attachments = append(emailData.Attachments, Attachment{
+			Content:     base64.StdEncoding.EncodeToString(attachment.Content),
+			Type:        attachment.ContentType,
+			Filename:    attachment.Filename,
+			Disposition: "attachment",
+		})
 	}
 
 	// Convert to JSON
@@ -94,7 +141,7 @@ func (e *email) SendEmail(ctx context.Context, toEmail string, subject string, b
 	}
 
 	// Create the request
-	req, err := http.NewRequest("POST", "https://api.sendgrid.com/v3/mail/send", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.sendgrid.com/v3/mail/send", bytes.NewBuffer(jsonData))
 	if err != nil {
 		e.logger.Error(ctx, "Failed to create request", zap.Error(err))
 		return fmt.Errorf("failed to create request: %w", err)
@@ -113,13 +160,34 @@ func (e *email) SendEmail(ctx context.Context, toEmail string, subject string, b
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
 		e.logger.Error(ctx, "SendGrid API returned error status", zap.Int("status", resp.StatusCode))
-		return fmt.Errorf("sendgrid API returned status: %d", resp.StatusCode)
+		return fmt.Errorf("%w: sendgrid API returned status: %d", errors.FromStatus(resp.StatusCode), resp.StatusCode)
 	}
 
-	e.logger.Info(ctx, "Successfully sent email", zap.String("to", toEmail), zap.String("subject", subject))
+	e.logger.Info(ctx, "Successfully sent email", zap.Strings("to", message.To), zap.String("subject", message.Subject))
 	return nil
 }
 
+// recipients converts addresses to SendGrid's form.
+func recipients(addresses []string) []To {
+	var result []To
+	for _, address := range addresses {
+		name, email := parseAddress(address)
+		result = append(result, To{Email: email, Name: name})
+	}
+	return result
+}
+
+// withoutAddress leaves out an address, which SendGrid refuses to see twice.
+func withoutAddress(recipients []To, address string) []To {
+	var result []To
+	for _, recipient := range recipients {
+		if !strings.EqualFold(recipient.Email, address) {
+			result = append(result, recipient)
+		}
+	}
+	return result
+}
+
 // stripHTML removes HTML tags from text for plain text version
 func stripHTML(html string) string {
 	// Remove HTML tags
@@ -1,31 +1,52 @@
 package email
 
 import (
+	apperrors "ai_agent/internal/constants/errors"
 	"ai_agent/internal/constants/model/dto"
 	"ai_agent/platform"
+	"ai_agent/platform/httpclient"
 	"ai_agent/platform/logger"
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/mail"
 	"net/smtp"
+	"net/textproto"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 type gmail struct {
 	config dto.Config
+	// SMTP is not HTTP, but shares the client's rate limit and backoff policy
+	client *httpclient.Client
 	logger logger.Logger
 }
 
 func InitGmail(config dto.Config, logger logger.Logger) platform.Email {
 	return &gmail{
 		config: config,
+		client: httpclient.New(httpclient.Options{
+			Name:             "gmail",
+			MaxRetries:       config.HTTPMaxRetries,
+			RatePerMinute:    config.EmailRatePerMinute,
+			BreakerThreshold: config.BreakerThreshold,
+			BreakerCooldown:  time.Duration(config.BreakerCooldownSeconds) * time.Second,
+		}, logger),
 		logger: logger,
 	}
 }
 
 // SendEmail implements platform.Email using Gmail SMTP
-func (g *gmail) SendEmail(ctx context.Context, toEmail string, subject string, body string) error {
-	g.logger.Info(ctx, "Sending email via Gmail SMTP", zap.String("to", toEmail), zap.String("subject", subject))
+func (g *gmail) SendEmail(ctx context.Context, message dto.EmailMessage) error {
+	message, err := prepare(message)
+	if err != nil {
+		return err
+	}
+	g.logger.Info(ctx, "Sending email via Gmail SMTP", zap.Strings("to", message.To), zap.String("subject", message.Subject),
+		zap.Int("cc", len(message.Cc)), zap.Int("bcc", len(message.Bcc)), zap.Int("attachments", len(message.Attachments)))
 
 	// Gmail SMTP configuration
 	from := g.config.FromEmail
@@ -35,25 +56,88 @@ func (g *gmail) SendEmail(ctx context.Context, toEmail string, subject string, b
 	smtpHost := "smtp.gmail.com"
 	smtpPort := "587"
 
-	// Create message with proper MIME headers for HTML
-	message := fmt.Sprintf("From: %s\r\n"+
-		"To: %s\r\n"+
-		"Subject: %s\r\n"+
-		"MIME-Version: 1.0\r\n"+
-		"Content-Type: text/html; charset=UTF-8\r\n"+
-		"\r\n"+
-		"%s\r\n", from, toEmail, subject, body)
+	data, err := buildMIME(mail.Address{Name: g.config.FromName, Address: from}, message, time.Now())
+	if err != nil {
+		g.logger.Error(ctx, "Failed to build email", zap.Error(err))
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+	// Bcc recipients are only named to the server, not in the headers
+	var to []string
+	for _, recipient := range message.Recipients() {
+		_, address := parseAddress(recipient)
+		to = append(to, address)
+	}
 
 	// Authentication
 	auth := smtp.PlainAuth("", from, password, smtpHost)
 
-	// Send email
-	err := smtp.SendMail(smtpHost+":"+smtpPort, auth, from, []string{toEmail}, []byte(message))
+	breaker := g.client.Breaker()
+	if err := breaker.Allow(); err != nil {
+		g.logger.Warn(ctx, "Circuit breaker open, skipping Gmail send")
+		return fmt.Errorf("gmail: %w", err)
+	}
+
+	// Send email, retrying transient failures
+	for attempt := 0; ; attempt++ {
+		if err = g.client.Limiter().Wait(ctx); err != nil {
+			break
+		}
+		err = smtp.SendMail(smtpHost+":"+smtpPort, auth, from, to, data)
+		if err == nil || attempt >= g.client.MaxRetries() || !isTransientSMTPError(err) {
+			break
+		}
+
+		delay := g.client.Backoff(attempt)
+		g.logger.Warn(ctx, "Retrying Gmail send", zap.Int("attempt", attempt+1), zap.Duration("delay", delay), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-time.After(delay):
+			continue
+		}
+		break
+	}
+	switch {
+	case ctx.Err() != nil:
+		breaker.Cancel()
+	case err != nil && isTransientSMTPError(err):
+		breaker.Failure()
+	default:
+		breaker.Success()
+	}
 	if err != nil {
 		g.logger.Error(ctx, "Failed to send email via Gmail", zap.Error(err))
-		return fmt.Errorf("failed to send email: %w", err)
+		return fmt.Errorf("%w: failed to send email: %v", smtpErrorKind(err), err)
 	}
 
-	g.logger.Info(ctx, "Successfully sent email via Gmail", zap.String("to", toEmail), zap.String("subject", subject))
+	g.logger.Info(ctx, "Successfully sent email via Gmail", zap.Strings("to", message.To), zap.String("subject", message.Subject))
 	return nil
 }
+
+// isTransientSMTPError reports 4xx SMTP replies and failures to connect, where
+// the message was not accepted and sending again is safe.
+func isTransientSMTPError(err error) bool {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code >= 400 && protoErr.Code < 500
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return opErr.Op == "dial"
+	}
+	return false
+}
+
+// smtpErrorKind classifies a failed send for the HTTP layer.
+func smtpErrorKind(err error) error {
+	var protoErr *textproto.Error
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err
+	case errors.As(err, &protoErr) && (protoErr.Code == 530 || protoErr.Code == 534 || protoErr.Code == 535):
+		return apperrors.ErrUnauthorized
+	case errors.As(err, &protoErr) && protoErr.Code >= 500:
+		return apperrors.ErrInvalidInput
+	}
+	return apperrors.ErrUnavailable
+}
@@ -0,0 +1,71 @@
+package email
+
+import (
+	"ai_agent/internal/constants/model/dto"
+	"mime"
+	"net/mail"
+	"path/filepath"
+	"strings"
+)
+
+// prepare validates a message and fills in what the backends need: a plain
+// text body for HTML-only messages, attachment content types, and addresses
+// encoded for headers, without repeats since a provider may reject an address
+// listed twice.
+func prepare(message dto.EmailMessage) (dto.EmailMessage, error) {
+	if err := message.Validate(); err != nil {
+		return dto.EmailMessage{}, err
+	}
+
+	seen := make(map[string]bool)
+	message.To = uniqueAddresses(message.To, seen)
+	message.Cc = uniqueAddresses(message.Cc, seen)
+	message.Bcc = uniqueAddresses(message.Bcc, seen)
+	if message.ReplyTo != "" {
+		message.ReplyTo = uniqueAddresses([]string{message.ReplyTo}, make(map[string]bool))[0]
+	}
+
+	if message.Text == "" {
+		message.Text = stripHTML(message.HTML)
+	}
+
+	attachments := make([]dto.EmailAttachment, len(message.Attachments))
+	for i, attachment := range message.Attachments {
+		if attachment.ContentType == "" {
+			attachment.ContentType = mime.TypeByExtension(filepath.Ext(attachment.Filename))
+		}
+		if attachment.ContentType == "" {
+			attachment.ContentType = "application/octet-stream"
+		}
+		attachments[i] = attachment
+	}
+	message.Attachments = attachments
+	return message, nil
+}
+
+// uniqueAddresses parses addresses, leaving out those already seen.
+func uniqueAddresses(addresses []string, seen map[string]bool) []string {
+	var unique []string
+	for _, address := range addresses {
+		parsed, err := mail.ParseAddress(address)
+		if err != nil {
+			continue
+		}
+		key := strings.ToLower(parsed.Address)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, parsed.String())
+	}
+	return unique
+}
+
+// parseAddress splits a validated address into its name and address.
+func parseAddress(address string) (name, email string) {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return "", address
+	}
+	return parsed.Name, parsed.Address
+}
@@ -0,0 +1,137 @@
+package email
+
+import (
+	"ai_agent/internal/constants/model/dto"
+	"bytes"
+	"encoding/base64"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"slices"
+	"strings"
+	"time"
+)
+
+// mimeLineLength is the longest line written for base64 content.
+const mimeLineLength = 76
+
+// mimePart is one part of a MIME message, ready to be written.
+type mimePart struct {
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+// buildMIME renders a prepared message as an RFC 5322 email. The bodies are
+// multipart/alternative when there is HTML, wrapped in multipart/mixed with
+// the attachments when there are any. Bcc recipients are left out.
+func buildMIME(from mail.Address, message dto.EmailMessage, date time.Time) ([]byte, error) {
+	root := textPart("text/plain", message.Text)
+	if message.HTML != "" {
+		var err error
+		root, err = multipartOf("alternative", []mimePart{root, textPart("text/html", message.HTML)})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(message.Attachments) > 0 {
+		parts := []mimePart{root}
+		for _, attachment := range message.Attachments {
+			parts = append(parts, attachmentPart(attachment))
+		}
+		var err error
+		if root, err = multipartOf("mixed", parts); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	writeHeader := func(name, value string) {
+		buf.WriteString(name + ": " + value + "\r\n")
+	}
+	writeHeader("From", from.String())
+	if len(message.To) > 0 {
+		writeHeader("To", strings.Join(message.To, ", "))
+	} else {
+		writeHeader("To", "undisclosed-recipients:;")
+	}
+	if len(message.Cc) > 0 {
+		writeHeader("Cc", strings.Join(message.Cc, ", "))
+	}
+	if message.ReplyTo != "" {
+		writeHeader("Reply-To", message.ReplyTo)
+	}
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", message.Subject))
+	writeHeader("Date", date.Format(time.RFC1123Z))
+	// Sorted so the same message is always written the same way
+	names := make([]string, 0, len(message.Headers))
+	for name := range message.Headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		writeHeader(textproto.CanonicalMIMEHeaderKey(name), mime.QEncoding.Encode("utf-8", message.Headers[name]))
+	}
+	writeHeader("MIME-Version", "1.0")
+	for _, name := range []string{"Content-Type", "Content-Transfer-Encoding", "Content-Disposition"} {
+		if value := root.header.Get(name); value != "" {
+			writeHeader(name, value)
+		}
+	}
+	buf.WriteString("\r\n")
+	buf.Write(root.body)
+	return buf.Bytes(), nil
+}
+
+// textPart encodes text as quoted-printable UTF-8.
+func textPart(contentType, text string) mimePart {
+	var body bytes.Buffer
+	w := quotedprintable.NewWriter(&body)
+	w.Write([]byte(text))
+	w.Close()
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Type", contentType+"; charset=UTF-8")
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	return mimePart{header: header, body: body.Bytes()}
+}
+
+// attachmentPart encodes a file as base64.
+func attachmentPart(attachment dto.EmailAttachment) mimePart {
+	encoded := base64.StdEncoding.EncodeToString(attachment.Content)
+	var body bytes.Buffer
+	for len(encoded) > mimeLineLength {
+		body.WriteString(encoded[:mimeLineLength] + "\r\n")
+		encoded = encoded[mimeLineLength:]
+	}
+	body.WriteString(encoded + "\r\n")
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Type", attachment.ContentType)
+	header.Set("Content-Transfer-Encoding", "base64")
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	return mimePart{header: header, body: body.Bytes()}
+}
+
+// multipartOf joins parts into a multipart part of the given subtype.
+func multipartOf(subtype string, parts []mimePart) (mimePart, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, part := range parts {
+		pw, err := w.CreatePart(part.header)
+		if err != nil {
+			return mimePart{}, err
+		}
+		if _, err := pw.Write(part.body); err != nil {
+			return mimePart{}, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return mimePart{}, err
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Type", mime.FormatMediaType("multipart/"+subtype, map[string]string{"boundary": w.Boundary()}))
+	return mimePart{header: header, body: body.Bytes()}, nil
+}
@@ -1,27 +1,50 @@
 package gemini
 
 import (
+	"ai_agent/internal/constants/errors"
 	"ai_agent/internal/constants/model/dto"
 	"ai_agent/platform"
+	"ai_agent/platform/httpclient"
 	"ai_agent/platform/logger"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
 )
 
+const (
+	DefaultURL   = "https://generativelanguage.googleapis.com/v1beta"
+	DefaultModel = "gemini-1.5-flash-latest"
+)
+
 type gemini struct {
 	config dto.Config
-	client *http.Client
+	client *httpclient.Client
 	logger logger.Logger
 }
 
 type GeminiRequest struct {
-	Contents []Content `json:"contents"`
+	Contents         []Content         `json:"contents"`
+	GenerationConfig *GenerationConfig `json:"generationConfig,omitempty"`
+	SafetySettings   []SafetySetting   `json:"safetySettings,omitempty"`
+}
+
+type GenerationConfig struct {
+	ResponseMimeType string         `json:"responseMimeType,omitempty"`
+	ResponseSchema   map[string]any `json:"responseSchema,omitempty"`
+	Temperature      *float64       `json:"temperature,omitempty"`
+	MaxOutputTokens  int            `json:"maxOutputTokens,omitempty"`
+}
+
+type SafetySetting struct {
+	Category  string `json:"category"`
+	Threshold string `json:"threshold"`
 }
 
 type Content struct {
@@ -40,15 +63,41 @@ type Candidate struct {
 	Content Content `json:"content"`
 }
 
-func InitGemini(config dto.Config, logger logger.Logger) platform.Gemini {
+func InitGemini(config dto.Config, logger logger.Logger) platform.LLM {
 	return &gemini{
 		config: config,
-		client: &http.Client{Timeout: 30 * time.Second},
+		client: httpclient.New(httpclient.Options{
+			Name:             "gemini",
+			Timeout:          30 * time.Second,
+			MaxRetries:       config.HTTPMaxRetries,
+			BreakerThreshold: config.BreakerThreshold,
+			BreakerCooldown:  time.Duration(config.BreakerCooldownSeconds) * time.Second,
+			RatePerMinute:    config.GeminiRatePerMinute,
+		}, logger),
 		logger: logger,
 	}
 }
 
+// harmCategories are the categories the safety threshold applies to.
+var harmCategories = []string{
+	"HARM_CATEGORY_HARASSMENT",
+	"HARM_CATEGORY_HATE_SPEECH",
+	"HARM_CATEGORY_SEXUALLY_EXPLICIT",
+	"HARM_CATEGORY_DANGEROUS_CONTENT",
+}
+
+// Name implements platform.LLM.
+func (g *gemini) Name() string {
+	return "gemini"
+}
+
+// ProcessCommand implements platform.LLM.
 func (g *gemini) ProcessCommand(ctx context.Context, command string) (string, error) {
+	return g.Generate(ctx, command, dto.GenerationOptions{})
+}
+
+// Generate implements platform.LLM.
+func (g *gemini) Generate(ctx context.Context, command string, opts dto.GenerationOptions) (string, error) {
 	g.logger.Info(ctx, "Executing Gemini command", zap.String("command", command))
 
 	// Prepare the request data
@@ -62,6 +111,24 @@ func (g *gemini) ProcessCommand(ctx context.Context, command string) (string, er
 		},
 	}
 
+	if opts.ResponseMIMEType != "" || opts.ResponseSchema != nil || opts.Temperature != nil || opts.MaxOutputTokens > 0 {
+		requestData.GenerationConfig = &GenerationConfig{
+			ResponseMimeType: opts.ResponseMIMEType,
+			ResponseSchema:   toGeminiSchema(opts.ResponseSchema),
+			Temperature:      opts.Temperature,
+			MaxOutputTokens:  opts.MaxOutputTokens,
+		}
+	}
+
+	if g.config.GeminiSafetyThreshold != "" {
+		for _, category := range harmCategories {
+			requestData.SafetySettings = append(requestData.SafetySettings, SafetySetting{
+				Category:  category,
+				Threshold: g.config.GeminiSafetyThreshold,
+			})
+		}
+	}
+
 	// Convert to JSON
 	jsonData, err := json.Marshal(requestData)
 	if err != nil {
@@ -70,17 +137,28 @@ func (g *gemini) ProcessCommand(ctx context.Context, command string) (string, er
 	}
 
 	// Build the API URL
-	fmt.Println("apikey=============================================", g.config.GeminiAPIKey)
-	apiURL := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/gemini-1.5-flash-latest:generateContent?key=%s", g.config.GeminiAPIKey)
+	baseURL := g.config.GeminiURL
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	model := g.config.GeminiModel
+	if model == "" {
+		model = DefaultModel
+	}
+	apiURL := fmt.Sprintf("%s/models/%s:generateContent", strings.TrimSuffix(baseURL, "/"), url.PathEscape(model))
 
 	// Create the request
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
+	// Completions have no side effects, so failed calls can safely be replayed
+	req, err := http.NewRequestWithContext(httpclient.WithIdempotent(ctx), "POST", apiURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		g.logger.Error(ctx, "Failed to create request", zap.Error(err))
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	// The key goes in a header so it never appears in a URL that may be
+	// logged or quoted in an error
+	req.Header.Set("x-goog-api-key", g.config.GeminiAPIKey)
 
 	// Make the request
 	resp, err := g.client.Do(req)
@@ -92,7 +170,7 @@ func (g *gemini) ProcessCommand(ctx context.Context, command string) (string, er
 
 	if resp.StatusCode != http.StatusOK {
 		g.logger.Error(ctx, "Gemini API returned error status", zap.Int("status", resp.StatusCode))
-		return "", fmt.Errorf("gemini API returned status: %d", resp.StatusCode)
+		return "", fmt.Errorf("%w: gemini API returned status: %d", errors.FromStatus(resp.StatusCode), resp.StatusCode)
 	}
 
 	var geminiResp GeminiResponse
@@ -111,3 +189,39 @@ func (g *gemini) ProcessCommand(ctx context.Context, command string) (string, er
 
 	return response, nil
 }
+
+// toGeminiSchema converts a JSON Schema to Gemini's OpenAPI subset, which
+// spells types in upper case and marks string enums with format "enum".
+func toGeminiSchema(schema map[string]any) map[string]any {
+	if schema == nil {
+		return nil
+	}
+
+	converted := make(map[string]any, len(schema))
+	for key, value := range schema {
+		switch key {
+		case "type":
+			converted[key] = strings.ToUpper(fmt.Sprint(value))
+		case "items":
+			if items, ok := value.(map[string]any); ok {
+				converted[key] = toGeminiSchema(items)
+			}
+		case "properties":
+			if properties, ok := value.(map[string]any); ok {
+				convertedProperties := make(map[string]any, len(properties))
+				for name, property := range properties {
+					if propertySchema, ok := property.(map[string]any); ok {
+						convertedProperties[name] = toGeminiSchema(propertySchema)
+					}
+				}
+				converted[key] = convertedProperties
+			}
+		case "enum":
+			converted[key] = value
+			converted["format"] = "enum"
+		default:
+			converted[key] = value
+		}
+	}
+	return converted
+}
@@ -0,0 +1,317 @@
+// Package googleauth obtains OAuth2 access tokens for Google APIs, either
+// from a user's refresh token, granted through the consent flow, or from a
+// service account that acts for a user through domain-wide delegation.
+// Tokens are cached and refreshed shortly before they expire.
+package googleauth
+
+import (
+	apperrors "ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/internal/storage"
+	"ai_agent/platform/httpclient"
+	"ai_agent/platform/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultTokenURL = "https://oauth2.googleapis.com/token"
+	DefaultAuthURL  = "https://accounts.google.com/o/oauth2/v2/auth"
+
+	// CalendarScope grants read and write access to the user's calendars.
+	CalendarScope = "https://www.googleapis.com/auth/calendar"
+
+	// expiryDelta refreshes tokens this long before they expire, so a token
+	// does not run out while a request is in flight.
+	expiryDelta = time.Minute
+)
+
+// ErrNotConnected is returned when no refresh token has been granted yet.
+var ErrNotConnected = fmt.Errorf("%w: Google account not connected, visit /oauth/google/start to grant access", apperrors.ErrUnauthorized)
+
+// Auth provides access tokens for Google APIs.
+type Auth struct {
+	config dto.Config
+	client *httpclient.Client
+	logger logger.Logger
+
+	tokenURL string
+	scopes   []string
+	// account is set when authenticating as a service account
+	account *serviceAccount
+
+	mu    sync.Mutex
+	token storedToken
+}
+
+// storedToken is a token as cached, and for users saved to the token file.
+type storedToken struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// InitAuth returns the configured authentication: a service account when
+// GoogleServiceAccountFile is set, a user's refresh token when GoogleClientID
+// is set, and otherwise nil, leaving API key access.
+func InitAuth(config dto.Config, logger logger.Logger) (*Auth, error) {
+	if config.GoogleServiceAccountFile == "" && config.GoogleClientID == "" {
+		return nil, nil
+	}
+
+	a := &Auth{
+		config: config,
+		client: httpclient.New(httpclient.Options{
+			Name:             "google_oauth",
+			Timeout:          30 * time.Second,
+			MaxRetries:       config.HTTPMaxRetries,
+			BreakerThreshold: config.BreakerThreshold,
+			BreakerCooldown:  time.Duration(config.BreakerCooldownSeconds) * time.Second,
+		}, logger),
+		logger:   logger,
+		tokenURL: config.GoogleTokenURL,
+		scopes:   []string{CalendarScope},
+	}
+
+	if config.GoogleServiceAccountFile != "" {
+		account, err := loadServiceAccount(config.GoogleServiceAccountFile)
+		if err != nil {
+			return nil, err
+		}
+		a.account = account
+		if a.tokenURL == "" {
+			a.tokenURL = account.TokenURI
+		}
+	} else if err := a.loadToken(); err != nil {
+		return nil, err
+	}
+
+	if a.tokenURL == "" {
+		a.tokenURL = DefaultTokenURL
+	}
+	return a, nil
+}
+
+// Token returns a valid access token, refreshing it when it is about to
+// expire. Concurrent callers share a single refresh.
+func (a *Auth) Token(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token.AccessToken != "" && time.Now().Add(expiryDelta).Before(a.token.Expiry) {
+		return a.token.AccessToken, nil
+	}
+
+	var err error
+	if a.account != nil {
+		err = a.fetchServiceAccountToken(ctx)
+	} else {
+		err = a.refresh(ctx)
+	}
+	if err != nil {
+		a.logger.Error(ctx, "Failed to obtain Google access token", zap.Error(err))
+		return "", err
+	}
+	return a.token.AccessToken, nil
+}
+
+// Invalidate drops the cached access token, for when an API rejects it
+// before it was due to expire.
+func (a *Auth) Invalidate() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token.AccessToken = ""
+}
+
+// ServiceAccount reports whether tokens come from a service account, which
+// needs no consent from the user.
+func (a *Auth) ServiceAccount() bool {
+	return a.account != nil
+}
+
+// AuthCodeURL returns the consent page that asks the user to grant access,
+// which redirects back to GoogleRedirectURL with state.
+func (a *Auth) AuthCodeURL(state string) string {
+	authURL := a.config.GoogleAuthURL
+	if authURL == "" {
+		authURL = DefaultAuthURL
+	}
+	params := url.Values{}
+	params.Set("client_id", a.config.GoogleClientID)
+	params.Set("redirect_uri", a.config.GoogleRedirectURL)
+	params.Set("response_type", "code")
+	params.Set("scope", strings.Join(a.scopes, " "))
+	// Offline access with a fresh consent always returns a refresh token
+	params.Set("access_type", "offline")
+	params.Set("prompt", "consent")
+	params.Set("state", state)
+	return authURL + "?" + params.Encode()
+}
+
+// Exchange trades the code from the consent redirect for tokens and saves
+// the refresh token to GoogleTokenFile.
+func (a *Auth) Exchange(ctx context.Context, code string) error {
+	if a.account != nil {
+		return fmt.Errorf("%w: service accounts do not use the consent flow", apperrors.ErrActionNotAllowed)
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("client_id", a.config.GoogleClientID)
+	form.Set("client_secret", a.config.GoogleClientSecret)
+	form.Set("redirect_uri", a.config.GoogleRedirectURL)
+
+	// A code can only be redeemed once, so the exchange is not retried
+	resp, err := a.requestToken(ctx, form)
+	if err != nil {
+		a.logger.Error(ctx, "Failed to exchange authorization code", zap.Error(err))
+		return err
+	}
+	if resp.RefreshToken == "" {
+		return fmt.Errorf("%w: Google returned no refresh token", apperrors.ErrUnauthorized)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.setToken(resp)
+	a.logger.Info(ctx, "Connected Google account")
+	return a.saveToken()
+}
+
+// refresh gets an access token with the user's refresh token. a.mu must be
+// held.
+func (a *Auth) refresh(ctx context.Context) error {
+	if a.token.RefreshToken == "" {
+		return ErrNotConnected
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", a.token.RefreshToken)
+	form.Set("client_id", a.config.GoogleClientID)
+	form.Set("client_secret", a.config.GoogleClientSecret)
+
+	resp, err := a.requestToken(httpclient.WithIdempotent(ctx), form)
+	if err != nil {
+		return err
+	}
+	rotated := resp.RefreshToken != "" && resp.RefreshToken != a.token.RefreshToken
+	a.setToken(resp)
+	if rotated {
+		return a.saveToken()
+	}
+	return nil
+}
+
+// setToken caches a token response, keeping the current refresh token when
+// the response has none. a.mu must be held.
+func (a *Auth) setToken(resp tokenResponse) {
+	a.token.AccessToken = resp.AccessToken
+	a.token.Expiry = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	if resp.RefreshToken != "" {
+		a.token.RefreshToken = resp.RefreshToken
+	}
+}
+
+// requestToken posts form to the token endpoint.
+func (a *Auth) requestToken(ctx context.Context, form url.Values) (tokenResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", a.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return tokenResponse{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return tokenResponse{}, fmt.Errorf("failed to read token response: %w", err)
+	}
+	var token tokenResponse
+	// Error responses are JSON too, but a proxy may return anything
+	json.Unmarshal(body, &token)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// invalid_grant means the grant was revoked or has expired
+		if token.Error == "invalid_grant" || token.Error == "unauthorized_client" || token.Error == "invalid_client" {
+			return tokenResponse{}, fmt.Errorf("%w: token endpoint returned %s: %s", apperrors.ErrUnauthorized, token.Error, token.ErrorDescription)
+		}
+		return tokenResponse{}, fmt.Errorf("%w: token endpoint returned status: %d", apperrors.FromStatus(resp.StatusCode), resp.StatusCode)
+	}
+	if token.AccessToken == "" {
+		return tokenResponse{}, fmt.Errorf("%w: token endpoint returned no access token", apperrors.ErrUnexpected)
+	}
+	return token, nil
+}
+
+// loadToken reads the saved token, or starts from GoogleRefreshToken. A saved
+// token wins, since it comes from the most recent consent.
+func (a *Auth) loadToken() error {
+	a.token.RefreshToken = a.config.GoogleRefreshToken
+	if a.config.GoogleTokenFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(a.config.GoogleTokenFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read Google token file: %w", err)
+	}
+	var saved storedToken
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("failed to read Google token file: %w", err)
+	}
+	if saved.RefreshToken != "" {
+		a.token = saved
+	}
+	return nil
+}
+
+// saveToken writes the token to GoogleTokenFile, readable only by its owner.
+// a.mu must be held.
+func (a *Auth) saveToken() error {
+	if a.config.GoogleTokenFile == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(a.token, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(a.config.GoogleTokenFile), 0o700); err != nil {
+		return fmt.Errorf("failed to save Google token: %w", err)
+	}
+	// Replacing the file gives it the new file's mode even if an older token
+	// was readable by others
+	if err := storage.WriteFile(a.config.GoogleTokenFile, data); err != nil {
+		return fmt.Errorf("failed to save Google token: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,359 @@
+package googleauth
+
+import (
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/platform/logger"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// fakeTokenServer is a Google token endpoint. Each request is answered by
+// handle with the parsed form.
+type fakeTokenServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests int
+	handle   func(form map[string]string) (int, map[string]any)
+}
+
+func newFakeTokenServer(t *testing.T, handle func(form map[string]string) (int, map[string]any)) *fakeTokenServer {
+	f := &fakeTokenServer{handle: handle}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+			t.Errorf("token request = %s %s, want a form POST", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("unreadable token request: %v", err)
+		}
+		form := map[string]string{}
+		for key := range r.PostForm {
+			form[key] = r.PostForm.Get(key)
+		}
+
+		f.mu.Lock()
+		f.requests++
+		status, body := f.handle(form)
+		f.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeTokenServer) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests
+}
+
+func testLogger() logger.Logger {
+	return logger.InitLogger(zap.NewNop())
+}
+
+func TestTokenRefresh(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresIn int
+		// wantRequests is how many tokens two calls to Token fetch
+		wantRequests int
+	}{
+		{name: "cached until it expires", expiresIn: 3600, wantRequests: 1},
+		{name: "refreshed when about to expire", expiresIn: 30, wantRequests: 2},
+		{name: "refreshed when expired", expiresIn: 0, wantRequests: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeTokenServer(t, nil)
+			server.handle = func(form map[string]string) (int, map[string]any) {
+				want := map[string]string{
+					"grant_type":    "refresh_token",
+					"refresh_token": "refresh-1",
+					"client_id":     "client",
+					"client_secret": "shh",
+				}
+				for key, value := range want {
+					if form[key] != value {
+						t.Errorf("%s = %q, want %q", key, form[key], value)
+					}
+				}
+				return http.StatusOK, map[string]any{
+					"access_token": fmt.Sprintf("access-%d", server.requests),
+					"expires_in":   tt.expiresIn,
+				}
+			}
+
+			auth, err := InitAuth(dto.Config{
+				GoogleClientID:     "client",
+				GoogleClientSecret: "shh",
+				GoogleRefreshToken: "refresh-1",
+				GoogleTokenURL:     server.URL,
+			}, testLogger())
+			if err != nil {
+				t.Fatalf("InitAuth failed: %v", err)
+			}
+
+			first, err := auth.Token(context.Background())
+			if err != nil {
+				t.Fatalf("Token failed: %v", err)
+			}
+			second, err := auth.Token(context.Background())
+			if err != nil {
+				t.Fatalf("second Token failed: %v", err)
+			}
+
+			if first != "access-1" {
+				t.Errorf("first token = %q, want access-1", first)
+			}
+			if want := fmt.Sprintf("access-%d", tt.wantRequests); second != want {
+				t.Errorf("second token = %q, want %q", second, want)
+			}
+			if server.count() != tt.wantRequests {
+				t.Errorf("token requests = %d, want %d", server.count(), tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestTokenRevokedGrant(t *testing.T) {
+	server := newFakeTokenServer(t, func(form map[string]string) (int, map[string]any) {
+		return http.StatusBadRequest, map[string]any{"error": "invalid_grant", "error_description": "Token has been expired or revoked."}
+	})
+	auth, err := InitAuth(dto.Config{GoogleClientID: "client", GoogleRefreshToken: "revoked", GoogleTokenURL: server.URL}, testLogger())
+	if err != nil {
+		t.Fatalf("InitAuth failed: %v", err)
+	}
+
+	if _, err := auth.Token(context.Background()); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("Token error = %v, want invalid_grant", err)
+	}
+}
+
+func TestServiceAccountAssertion(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var server *fakeTokenServer
+	server = newFakeTokenServer(t, func(form map[string]string) (int, map[string]any) {
+		if form["grant_type"] != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+			t.Errorf("grant_type = %q, want jwt-bearer", form["grant_type"])
+		}
+
+		// The handler runs on the server's goroutine, so failures are
+		// reported with Errorf
+		parts := strings.Split(form["assertion"], ".")
+		if len(parts) != 3 {
+			t.Errorf("assertion %q is not a JWT", form["assertion"])
+			return http.StatusBadRequest, map[string]any{"error": "invalid_grant"}
+		}
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			t.Errorf("signature is not base64url: %v", err)
+		}
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			t.Errorf("assertion signature does not verify: %v", err)
+		}
+
+		var header map[string]string
+		decodeSegment(t, parts[0], &header)
+		if header["alg"] != "RS256" || header["typ"] != "JWT" || header["kid"] != "key-1" {
+			t.Errorf("header = %v, want RS256 JWT with kid key-1", header)
+		}
+
+		var claims struct {
+			Iss   string `json:"iss"`
+			Sub   string `json:"sub"`
+			Scope string `json:"scope"`
+			Aud   string `json:"aud"`
+			Iat   int64  `json:"iat"`
+			Exp   int64  `json:"exp"`
+		}
+		decodeSegment(t, parts[1], &claims)
+		if claims.Iss != "assistant@project.iam.gserviceaccount.com" {
+			t.Errorf("iss = %q", claims.Iss)
+		}
+		if claims.Sub != "alice@example.com" {
+			t.Errorf("sub = %q, want the impersonated user", claims.Sub)
+		}
+		if claims.Scope != CalendarScope {
+			t.Errorf("scope = %q, want %q", claims.Scope, CalendarScope)
+		}
+		if claims.Aud != server.URL {
+			t.Errorf("aud = %q, want the token endpoint %q", claims.Aud, server.URL)
+		}
+		if claims.Exp-claims.Iat != int64(jwtLifetime.Seconds()) {
+			t.Errorf("assertion lifetime = %ds, want %v", claims.Exp-claims.Iat, jwtLifetime)
+		}
+
+		return http.StatusOK, map[string]any{"access_token": "service-token", "expires_in": 3600}
+	})
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "service-account.json")
+	data, _ := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"client_email":   "assistant@project.iam.gserviceaccount.com",
+		"private_key_id": "key-1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})),
+		"token_uri":      server.URL,
+	})
+	if err := os.WriteFile(keyFile, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	auth, err := InitAuth(dto.Config{
+		GoogleServiceAccountFile: keyFile,
+		GoogleImpersonateUser:    "alice@example.com",
+	}, testLogger())
+	if err != nil {
+		t.Fatalf("InitAuth failed: %v", err)
+	}
+	if !auth.ServiceAccount() {
+		t.Error("ServiceAccount() = false for a service account key")
+	}
+
+	token, err := auth.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token failed: %v", err)
+	}
+	if token != "service-token" {
+		t.Errorf("token = %q, want service-token", token)
+	}
+	if err := auth.Exchange(context.Background(), "code"); err == nil {
+		t.Error("Exchange succeeded for a service account")
+	}
+}
+
+func TestTokenFile(t *testing.T) {
+	tests := []struct {
+		name string
+		// existing is the mode of a token file saved earlier; zero means none
+		existing os.FileMode
+		// connect obtains the token that is saved
+		connect func(auth *Auth) error
+	}{
+		{
+			name:    "consent",
+			connect: func(auth *Auth) error { return auth.Exchange(context.Background(), "code-1") },
+		},
+		{
+			name:     "consent over a file readable by others",
+			existing: 0o644,
+			connect:  func(auth *Auth) error { return auth.Exchange(context.Background(), "code-1") },
+		},
+		{
+			name:     "rotated refresh token",
+			existing: 0o644,
+			connect: func(auth *Auth) error {
+				_, err := auth.Token(context.Background())
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeTokenServer(t, func(form map[string]string) (int, map[string]any) {
+				switch form["grant_type"] {
+				case "authorization_code":
+					if form["code"] != "code-1" || form["redirect_uri"] != "http://localhost:8080/oauth/google/callback" {
+						t.Errorf("exchange form = %v", form)
+					}
+				case "refresh_token":
+					if form["refresh_token"] != "refresh-old" {
+						t.Errorf("refresh_token = %q, want the saved one", form["refresh_token"])
+					}
+				}
+				return http.StatusOK, map[string]any{"access_token": "access", "refresh_token": "refresh-new", "expires_in": 3600}
+			})
+
+			tokenFile := filepath.Join(t.TempDir(), "data", "google_token.json")
+			if tt.existing != 0 {
+				if err := os.MkdirAll(filepath.Dir(tokenFile), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(tokenFile, []byte(`{"refresh_token": "refresh-old"}`), tt.existing); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chmod(tokenFile, tt.existing); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			auth, err := InitAuth(dto.Config{
+				GoogleClientID:    "client",
+				GoogleTokenFile:   tokenFile,
+				GoogleRedirectURL: "http://localhost:8080/oauth/google/callback",
+				GoogleTokenURL:    server.URL,
+			}, testLogger())
+			if err != nil {
+				t.Fatalf("InitAuth failed: %v", err)
+			}
+			if err := tt.connect(auth); err != nil {
+				t.Fatalf("connecting failed: %v", err)
+			}
+
+			info, err := os.Stat(tokenFile)
+			if err != nil {
+				t.Fatalf("token file not written: %v", err)
+			}
+			if mode := info.Mode().Perm(); mode != 0o600 {
+				t.Errorf("token file mode = %o, want 600", mode)
+			}
+			var saved storedToken
+			data, _ := os.ReadFile(tokenFile)
+			if err := json.Unmarshal(data, &saved); err != nil || saved.RefreshToken != "refresh-new" {
+				t.Errorf("saved token = %s, want refresh-new", data)
+			}
+
+			// A restart picks up the saved refresh token
+			again, err := InitAuth(dto.Config{GoogleClientID: "client", GoogleTokenFile: tokenFile, GoogleRefreshToken: "from-env", GoogleTokenURL: server.URL}, testLogger())
+			if err != nil {
+				t.Fatalf("InitAuth from the token file failed: %v", err)
+			}
+			if again.token.RefreshToken != "refresh-new" {
+				t.Errorf("loaded refresh token = %q, want refresh-new", again.token.RefreshToken)
+			}
+		})
+	}
+}
+
+// decodeSegment decodes a JWT header or claims segment into v.
+func decodeSegment(t *testing.T, segment string, v any) {
+	t.Helper()
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		t.Errorf("segment %q is not base64url: %v", segment, err)
+		return
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Errorf("segment %s is not JSON: %v", data, err)
+	}
+}
@@ -0,0 +1,268 @@
+// Package httpclient is the shared outbound HTTP layer for platform clients.
+// It retries transient failures with exponential backoff and jitter, honours
+// Retry-After, only replays non-idempotent requests when that is safe,
+// rate-limits each provider with a token bucket and fails fast through a
+// circuit breaker while a provider is down.
+package httpclient
+
+import (
+	apperrors "ai_agent/internal/constants/errors"
+	"ai_agent/platform/logger"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultTimeout         = 30 * time.Second
+	defaultBaseDelay       = 500 * time.Millisecond
+	defaultMaxDelay        = 30 * time.Second
+	defaultMaxRetryAfter   = 2 * time.Minute
+	defaultBreakerCooldown = 30 * time.Second
+)
+
+// Options configure a Client. Zero values fall back to sensible defaults,
+// except MaxRetries (0 disables retries), RatePerMinute (0 disables limiting)
+// and BreakerThreshold (0 disables the circuit breaker).
+type Options struct {
+	// Name identifies the provider in logs.
+	Name          string
+	Timeout       time.Duration
+	MaxRetries    int
+	BaseDelay     time.Duration
+	MaxDelay      time.Duration
+	MaxRetryAfter time.Duration
+	RatePerMinute float64
+	Burst         int
+
+	// BreakerThreshold consecutive failed calls open the breaker for BreakerCooldown.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// Client wraps http.Client with retries, rate limiting and a circuit breaker.
+type Client struct {
+	client  *http.Client
+	opts    Options
+	limiter *Limiter
+	breaker *Breaker
+	logger  logger.Logger
+}
+
+type idempotentKey struct{}
+
+// WithIdempotent marks requests made with ctx as safe to replay, for POSTs
+// that have no side effects such as model completions.
+func WithIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+func New(opts Options, logger logger.Logger) *Client {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = defaultBaseDelay
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = defaultMaxDelay
+	}
+	if opts.MaxRetryAfter <= 0 {
+		opts.MaxRetryAfter = defaultMaxRetryAfter
+	}
+	if opts.BreakerCooldown <= 0 {
+		opts.BreakerCooldown = defaultBreakerCooldown
+	}
+
+	return &Client{
+		client:  &http.Client{Timeout: opts.Timeout},
+		opts:    opts,
+		limiter: NewLimiter(opts.RatePerMinute, opts.Burst),
+		breaker: NewBreaker(opts.BreakerThreshold, opts.BreakerCooldown),
+		logger:  logger,
+	}
+}
+
+// Do sends the request, retrying when it is safe to. The final response is
+// returned as is, so callers still check the status code. Transport failures
+// and an open breaker are reported as errors.ErrUnavailable.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if err := c.breaker.Allow(); err != nil {
+		c.logger.Warn(ctx, "Circuit breaker open, skipping request", zap.String("provider", c.opts.Name))
+		return nil, fmt.Errorf("%s: %w", c.opts.Name, err)
+	}
+
+	resp, err := c.do(req)
+	switch {
+	case ctx.Err() != nil:
+		c.breaker.Cancel()
+		return resp, err
+	case err != nil || resp.StatusCode >= 500:
+		c.breaker.Failure()
+	default:
+		c.breaker.Success()
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", apperrors.ErrUnavailable, c.opts.Name, withoutURL(err))
+	}
+	return resp, nil
+}
+
+// withoutURL drops the request URL from a transport error. The error may be
+// shown to clients, and a URL can carry credentials in its query.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
+
+// do runs the retry loop for a single call.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	replayable := isIdempotent(req)
+
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		if attempt > 0 && req.Body != nil {
+			if req.GetBody == nil {
+				return nil, fmt.Errorf("%s: cannot retry request without GetBody", c.opts.Name)
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("%s: failed to rewind request body: %w", c.opts.Name, err)
+			}
+			req.Body = body
+		}
+
+		resp, err := c.client.Do(req)
+		if attempt >= c.opts.MaxRetries || ctx.Err() != nil {
+			return resp, err
+		}
+
+		delay, retry := c.retryDelay(resp, err, replayable, attempt)
+		if !retry {
+			return resp, err
+		}
+
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		c.logger.Warn(ctx, "Retrying request",
+			zap.String("provider", c.opts.Name),
+			zap.String("method", req.Method),
+			zap.Int("attempt", attempt+1),
+			zap.Int("status", status),
+			zap.Duration("delay", delay),
+			zap.Error(err))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryDelay decides whether to retry and how long to wait first. Rate-limited
+// and Retry-After responses were not processed, so any method may be replayed;
+// other failures are only retried for idempotent requests.
+func (c *Client) retryDelay(resp *http.Response, err error, replayable bool, attempt int) (time.Duration, bool) {
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return 0, false
+		}
+		return c.Backoff(attempt), replayable
+	}
+
+	retryAfter, hasRetryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode == http.StatusServiceUnavailable && hasRetryAfter:
+		if hasRetryAfter {
+			if retryAfter > c.opts.MaxRetryAfter {
+				return 0, false
+			}
+			return retryAfter, true
+		}
+		return c.Backoff(attempt), true
+	case resp.StatusCode == http.StatusInternalServerError,
+		resp.StatusCode == http.StatusBadGateway,
+		resp.StatusCode == http.StatusServiceUnavailable,
+		resp.StatusCode == http.StatusGatewayTimeout:
+		return c.Backoff(attempt), replayable
+	}
+	return 0, false
+}
+
+// Backoff returns the exponential delay with full jitter for a retry attempt.
+func (c *Client) Backoff(attempt int) time.Duration {
+	delay := c.opts.BaseDelay << attempt
+	if delay <= 0 || delay > c.opts.MaxDelay {
+		delay = c.opts.MaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(delay)) + 1)
+}
+
+// MaxRetries returns how many times a failed call may be retried.
+func (c *Client) MaxRetries() int {
+	return c.opts.MaxRetries
+}
+
+// Limiter returns the provider's rate limiter, for clients that do not speak HTTP.
+func (c *Client) Limiter() *Limiter {
+	return c.limiter
+}
+
+// Breaker returns the provider's circuit breaker, for clients that do not speak HTTP.
+func (c *Client) Breaker() *Breaker {
+	return c.breaker
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	if req.Header.Get("Idempotency-Key") != "" {
+		return true
+	}
+	idempotent, _ := req.Context().Value(idempotentKey{}).(bool)
+	return idempotent
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,33 @@
+package httpclient
+
+import (
+	apperrors "ai_agent/internal/constants/errors"
+	"ai_agent/platform/logger"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestTransportErrorsLeaveOutURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	c := New(Options{Name: "test"}, logger.InitLogger(zap.NewNop()))
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL+"/models?key=secret", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Do(req)
+	if !errors.Is(err, apperrors.ErrUnavailable) {
+		t.Fatalf("Do error = %v, want ErrUnavailable", err)
+	}
+	if strings.Contains(err.Error(), "secret") || strings.Contains(err.Error(), server.URL) {
+		t.Errorf("error %q contains the request URL", err)
+	}
+}
@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/platform"
+	"ai_agent/platform/gemini"
+	"ai_agent/platform/logger"
+	"ai_agent/platform/ollama"
+	"ai_agent/platform/openai"
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// chain tries each provider in order until one answers.
+type chain struct {
+	providers []platform.LLM
+	logger    logger.Logger
+}
+
+// InitLLM builds the providers named in config.LLMProviders, in order. Providers
+// missing required settings are skipped. It returns nil when none are usable.
+func InitLLM(config dto.Config, logger logger.Logger) platform.LLM {
+	var providers []platform.LLM
+	for _, name := range config.LLMProviders {
+		switch strings.ToLower(name) {
+		case "gemini":
+			if config.GeminiAPIKey == "" {
+				logger.Warn(context.Background(), "Skipping Gemini provider, GEMINI_API_KEY is not set")
+				continue
+			}
+			providers = append(providers, gemini.InitGemini(config, logger))
+		case "openai":
+			if config.OpenAIAPIKey == "" && config.OpenAIURL == "" {
+				logger.Warn(context.Background(), "Skipping OpenAI provider, neither OPENAI_API_KEY nor OPENAI_URL is set")
+				continue
+			}
+			providers = append(providers, openai.InitOpenAI(config, logger))
+		case "ollama":
+			providers = append(providers, ollama.InitOllama(config, logger))
+		default:
+			logger.Warn(context.Background(), "Skipping unknown LLM provider", zap.String("provider", name))
+		}
+	}
+
+	if len(providers) == 0 {
+		return nil
+	}
+	return InitChain(providers, logger)
+}
+
+// InitChain returns an LLM that falls back to the next provider when one fails.
+func InitChain(providers []platform.LLM, logger logger.Logger) platform.LLM {
+	return &chain{
+		providers: providers,
+		logger:    logger,
+	}
+}
+
+// Name implements platform.LLM.
+func (c *chain) Name() string {
+	names := make([]string, 0, len(c.providers))
+	for _, provider := range c.providers {
+		names = append(names, provider.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+// ProcessCommand implements platform.LLM.
+func (c *chain) ProcessCommand(ctx context.Context, command string) (string, error) {
+	return c.Generate(ctx, command, dto.GenerationOptions{})
+}
+
+// Generate implements platform.LLM.
+func (c *chain) Generate(ctx context.Context, command string, opts dto.GenerationOptions) (string, error) {
+	var errs []string
+	for _, provider := range c.providers {
+		response, err := provider.Generate(ctx, command, opts)
+		if err == nil {
+			return response, nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+
+		c.logger.Warn(ctx, "LLM provider failed, trying next", zap.String("provider", provider.Name()), zap.Error(err))
+		errs = append(errs, fmt.Sprintf("%s: %v", provider.Name(), err))
+	}
+
+	return "", fmt.Errorf("%w: all LLM providers failed: %s", errors.ErrLLMUnavailable, strings.Join(errs, "; "))
+}
@@ -0,0 +1,150 @@
+package ollama
+
+import (
+	"ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/platform"
+	"ai_agent/platform/httpclient"
+	"ai_agent/platform/logger"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultURL   = "http://localhost:11434"
+	DefaultModel = "llama3.1"
+)
+
+type ollama struct {
+	config dto.Config
+	client *httpclient.Client
+	logger logger.Logger
+}
+
+type ChatRequest struct {
+	Model    string         `json:"model"`
+	Messages []Message      `json:"messages"`
+	Stream   bool           `json:"stream"`
+	Format   any            `json:"format,omitempty"`
+	Options  map[string]any `json:"options,omitempty"`
+}
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ChatResponse struct {
+	Message Message `json:"message"`
+}
+
+func InitOllama(config dto.Config, logger logger.Logger) platform.LLM {
+	return &ollama{
+		config: config,
+		// Local models can be slow to load and answer
+		client: httpclient.New(httpclient.Options{
+			Name:             "ollama",
+			Timeout:          120 * time.Second,
+			MaxRetries:       config.HTTPMaxRetries,
+			BreakerThreshold: config.BreakerThreshold,
+			BreakerCooldown:  time.Duration(config.BreakerCooldownSeconds) * time.Second,
+		}, logger),
+		logger: logger,
+	}
+}
+
+// Name implements platform.LLM.
+func (o *ollama) Name() string {
+	return "ollama"
+}
+
+// ProcessCommand implements platform.LLM.
+func (o *ollama) ProcessCommand(ctx context.Context, command string) (string, error) {
+	return o.Generate(ctx, command, dto.GenerationOptions{})
+}
+
+// Generate implements platform.LLM.
+func (o *ollama) Generate(ctx context.Context, command string, opts dto.GenerationOptions) (string, error) {
+	model := o.config.OllamaModel
+	if model == "" {
+		model = DefaultModel
+	}
+	o.logger.Info(ctx, "Executing Ollama command", zap.String("model", model), zap.String("command", command))
+
+	requestData := ChatRequest{
+		Model:    model,
+		Messages: []Message{{Role: "user", Content: command}},
+		Stream:   false,
+	}
+	switch {
+	case opts.ResponseSchema != nil:
+		requestData.Format = opts.ResponseSchema
+	case opts.ResponseMIMEType == "application/json":
+		requestData.Format = "json"
+	}
+	if opts.Temperature != nil || opts.MaxOutputTokens > 0 {
+		requestData.Options = map[string]any{}
+		if opts.Temperature != nil {
+			requestData.Options["temperature"] = *opts.Temperature
+		}
+		if opts.MaxOutputTokens > 0 {
+			requestData.Options["num_predict"] = opts.MaxOutputTokens
+		}
+	}
+
+	jsonData, err := json.Marshal(requestData)
+	if err != nil {
+		o.logger.Error(ctx, "Failed to marshal request data", zap.Error(err))
+		return "", fmt.Errorf("failed to marshal request data: %w", err)
+	}
+
+	baseURL := o.config.OllamaURL
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	apiURL := strings.TrimSuffix(baseURL, "/") + "/api/chat"
+
+	// Completions have no side effects, so failed calls can safely be replayed
+	req, err := http.NewRequestWithContext(httpclient.WithIdempotent(ctx), "POST", apiURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		o.logger.Error(ctx, "Failed to create request", zap.Error(err))
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		o.logger.Error(ctx, "Failed to execute Ollama command", zap.Error(err))
+		return "", fmt.Errorf("failed to execute Ollama command: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		o.logger.Error(ctx, "Ollama API returned error status", zap.Int("status", resp.StatusCode))
+		return "", fmt.Errorf("%w: ollama API returned status: %d", errors.FromStatus(resp.StatusCode), resp.StatusCode)
+	}
+
+	var chatResp ChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		o.logger.Error(ctx, "Failed to decode response", zap.Error(err))
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if chatResp.Message.Content == "" {
+		o.logger.Error(ctx, "No response from Ollama")
+		return "", fmt.Errorf("no response from Ollama")
+	}
+
+	response := chatResp.Message.Content
+	o.logger.Info(ctx, "Successfully executed Ollama command", zap.Int("response_length", len(response)))
+
+	return response, nil
+}
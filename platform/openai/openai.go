@@ -0,0 +1,162 @@
+package openai
+
+import (
+	"ai_agent/internal/constants/errors"
+	"ai_agent/internal/constants/model/dto"
+	"ai_agent/platform"
+	"ai_agent/platform/httpclient"
+	"ai_agent/platform/logger"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultURL   = "https://api.openai.com"
+	DefaultModel = "gpt-4o-mini"
+)
+
+// openAI talks to any server implementing the OpenAI /v1/chat/completions API.
+type openAI struct {
+	config dto.Config
+	client *httpclient.Client
+	logger logger.Logger
+}
+
+type ChatRequest struct {
+	Model          string          `json:"model"`
+	Messages       []Message       `json:"messages"`
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	Temperature    *float64        `json:"temperature,omitempty"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+}
+
+type ResponseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+type JSONSchema struct {
+	Name   string         `json:"name"`
+	Schema map[string]any `json:"schema"`
+}
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ChatResponse struct {
+	Choices []Choice `json:"choices"`
+}
+
+type Choice struct {
+	Message Message `json:"message"`
+}
+
+func InitOpenAI(config dto.Config, logger logger.Logger) platform.LLM {
+	return &openAI{
+		config: config,
+		client: httpclient.New(httpclient.Options{
+			Name:             "openai",
+			Timeout:          60 * time.Second,
+			MaxRetries:       config.HTTPMaxRetries,
+			BreakerThreshold: config.BreakerThreshold,
+			BreakerCooldown:  time.Duration(config.BreakerCooldownSeconds) * time.Second,
+		}, logger),
+		logger: logger,
+	}
+}
+
+// Name implements platform.LLM.
+func (o *openAI) Name() string {
+	return "openai"
+}
+
+// ProcessCommand implements platform.LLM.
+func (o *openAI) ProcessCommand(ctx context.Context, command string) (string, error) {
+	return o.Generate(ctx, command, dto.GenerationOptions{})
+}
+
+// Generate implements platform.LLM.
+func (o *openAI) Generate(ctx context.Context, command string, opts dto.GenerationOptions) (string, error) {
+	model := o.config.OpenAIModel
+	if model == "" {
+		model = DefaultModel
+	}
+	o.logger.Info(ctx, "Executing OpenAI-compatible command", zap.String("model", model), zap.String("command", command))
+
+	requestData := ChatRequest{
+		Model:       model,
+		Messages:    []Message{{Role: "user", Content: command}},
+		Temperature: opts.Temperature,
+		MaxTokens:   opts.MaxOutputTokens,
+	}
+	switch {
+	case opts.ResponseSchema != nil:
+		requestData.ResponseFormat = &ResponseFormat{
+			Type:       "json_schema",
+			JSONSchema: &JSONSchema{Name: "response", Schema: opts.ResponseSchema},
+		}
+	case opts.ResponseMIMEType == "application/json":
+		requestData.ResponseFormat = &ResponseFormat{Type: "json_object"}
+	}
+
+	jsonData, err := json.Marshal(requestData)
+	if err != nil {
+		o.logger.Error(ctx, "Failed to marshal request data", zap.Error(err))
+		return "", fmt.Errorf("failed to marshal request data: %w", err)
+	}
+
+	baseURL := o.config.OpenAIURL
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	apiURL := strings.TrimSuffix(baseURL, "/") + "/v1/chat/completions"
+
+	// Completions have no side effects, so failed calls can safely be replayed
+	req, err := http.NewRequestWithContext(httpclient.WithIdempotent(ctx), "POST", apiURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		o.logger.Error(ctx, "Failed to create request", zap.Error(err))
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if o.config.OpenAIAPIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.config.OpenAIAPIKey)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		o.logger.Error(ctx, "Failed to execute OpenAI-compatible command", zap.Error(err))
+		return "", fmt.Errorf("failed to execute OpenAI-compatible command: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		o.logger.Error(ctx, "OpenAI-compatible API returned error status", zap.Int("status", resp.StatusCode))
+		return "", fmt.Errorf("%w: openai API returned status: %d", errors.FromStatus(resp.StatusCode), resp.StatusCode)
+	}
+
+	var chatResp ChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		o.logger.Error(ctx, "Failed to decode response", zap.Error(err))
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(chatResp.Choices) == 0 || chatResp.Choices[0].Message.Content == "" {
+		o.logger.Error(ctx, "No response from OpenAI-compatible API")
+		return "", fmt.Errorf("no response from OpenAI-compatible API")
+	}
+
+	response := chatResp.Choices[0].Message.Content
+	o.logger.Info(ctx, "Successfully executed OpenAI-compatible command", zap.Int("response_length", len(response)))
+
+	return response, nil
+}
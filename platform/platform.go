@@ -7,15 +7,40 @@ import (
 )
 
 type Calendar interface {
-	ScheduleMeeting(ctx context.Context,attendees []string,
-		startTime time.Time, duration time.Duration, title string) error
-	GetUpcomingEvents(ctx context.Context) ([]dto.Event, error)
+	// ScheduleMeeting creates event, which may recur, and returns it with its ID.
+	ScheduleMeeting(ctx context.Context, event dto.Event) (dto.Event, error)
+	// ListEvents returns a page of the events matching query. From and To
+	// must be set.
+	ListEvents(ctx context.Context, query dto.EventQuery) (dto.EventPage, error)
+	GetEvent(ctx context.Context, id string) (dto.Event, error)
+	// UpdateEvent replaces the title, attendees and times of the event with event.ID.
+	UpdateEvent(ctx context.Context, event dto.Event) (dto.Event, error)
+	// Reschedule moves an event; a zero duration keeps its current length.
+	Reschedule(ctx context.Context, id string, startTime time.Time, duration time.Duration) (dto.Event, error)
+	Cancel(ctx context.Context, id string) error
+	// FreeBusy returns the busy intervals between from and to for each
+	// calendar, identified by its owner's email address.
+	FreeBusy(ctx context.Context, calendars []string, from, to time.Time) (map[string][]dto.TimeRange, error)
 }
 
 type Email interface {
-	SendEmail(ctx context.Context,toEmail string, subject string, body string) error
+	// SendEmail sends message from the configured sender to all of its
+	// recipients at once.
+	SendEmail(ctx context.Context, message dto.EmailMessage) error
 }
 
-type Gemini interface {
-	ProcessCommand(ctx context.Context,command string) (string, error)
+// OAuth grants the assistant access to a user's account through the provider's
+// consent page.
+type OAuth interface {
+	// AuthCodeURL returns the consent page, which redirects back with state.
+	AuthCodeURL(state string) string
+	// Exchange redeems the code from the consent redirect and stores the grant.
+	Exchange(ctx context.Context, code string) error
+}
+
+// LLM is a language model provider such as Gemini, an OpenAI-compatible server or Ollama.
+type LLM interface {
+	Name() string
+	ProcessCommand(ctx context.Context, command string) (string, error)
+	Generate(ctx context.Context, prompt string, opts dto.GenerationOptions) (string, error)
 }